@@ -0,0 +1,25 @@
+package myconfig
+
+import (
+	"context"
+)
+
+//go:generate mockgen -source=api.go -package myconfig -destination source_mock.go Source
+
+// Source resolves the current value of a named secret.
+// found is false when the source does not know the secret.
+type Source interface {
+	Lookup(c context.Context, name string) (value string, found bool, err error)
+}
+
+// Secret is a handle on a single credential. Its value is resolved on every use, so a rotated secret is picked up without a redeploy.
+type Secret interface {
+	Value(c context.Context) (string, error)
+}
+
+// StaticSecret is a secret with a fixed value, typically used in tests
+type StaticSecret string
+
+func (s StaticSecret) Value(c context.Context) (string, error) {
+	return string(s), nil
+}
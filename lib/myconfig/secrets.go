@@ -0,0 +1,175 @@
+package myconfig
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+const defaultCacheTTL = 5 * time.Minute
+
+type cachedSecret struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// Secrets resolves secrets from a source and caches them for a limited time.
+// A rotated secret is picked up as soon as its cached value expires.
+type Secrets struct {
+	sync.Mutex
+	source Source
+	ttl    time.Duration
+	nower  mytime.Nower
+	cache  map[string]cachedSecret
+	// concurrent lookups of the same secret share a single call to the source
+	lookups singleflight.Group
+}
+
+type lookupResult struct {
+	value string
+	found bool
+}
+
+// New selects the source based on the environment:
+//   - SECRETS_SOURCE=secretmanager: Google Secret Manager of GOOGLE_CLOUD_PROJECT
+//   - SECRETS_SOURCE=file: json file at SECRETS_FILE
+//   - SECRETS_SOURCE=env or unset: environment variables
+//
+// Secrets that are not found in the selected source fall back to the environment variable with the same name.
+// SECRETS_CACHE_TTL overrides how long a resolved value is cached.
+func New(c context.Context, nower mytime.Nower) (*Secrets, func(), error) {
+	ttl := defaultCacheTTL
+	if value := os.Getenv("SECRETS_CACHE_TTL"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing SECRETS_CACHE_TTL: %w", err)
+		}
+		ttl = d
+	}
+
+	switch sourceName := os.Getenv("SECRETS_SOURCE"); sourceName {
+	case "secretmanager":
+		projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
+		if projectID == "" {
+			return nil, nil, fmt.Errorf("secret-manager requires GOOGLE_CLOUD_PROJECT")
+		}
+		source, cleanup, err := newGcloudSource(c, projectID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewWithSource(withEnvFallback(source), ttl, nower), cleanup, nil
+	case "file":
+		filename := os.Getenv("SECRETS_FILE")
+		if filename == "" {
+			return nil, nil, fmt.Errorf("secrets-file requires SECRETS_FILE")
+		}
+		return NewWithSource(withEnvFallback(NewFileSource(filename)), ttl, nower), func() {}, nil
+	case "", "env":
+		return NewWithSource(NewEnvSource(), ttl, nower), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown SECRETS_SOURCE '%s'", sourceName)
+	}
+}
+
+func NewWithSource(source Source, ttl time.Duration, nower mytime.Nower) *Secrets {
+	return &Secrets{
+		source: source,
+		ttl:    ttl,
+		nower:  nower,
+		cache:  map[string]cachedSecret{},
+	}
+}
+
+// Get returns the current value of the secret. When the source is unavailable, the last known value is returned.
+func (s *Secrets) Get(c context.Context, name string) (string, error) {
+	now := s.nower.Now()
+
+	s.Lock()
+	cached, exists := s.cache[name]
+	s.Unlock()
+
+	if exists && now.Before(cached.fetchedAt.Add(s.ttl)) {
+		return cached.value, nil
+	}
+
+	// the source is remote: it is called without holding the lock
+	result, err, _ := s.lookups.Do(name, func() (interface{}, error) {
+		value, found, err := s.source.Lookup(c, name)
+		if err != nil || !found {
+			return lookupResult{}, err
+		}
+
+		s.Lock()
+		s.cache[name] = cachedSecret{
+			value:     value,
+			fetchedAt: now,
+		}
+		s.Unlock()
+
+		return lookupResult{value: value, found: true}, nil
+	})
+	if err != nil {
+		if exists {
+			log.Printf("Error refreshing secret %s, using last known value: %s", name, err)
+			return cached.value, nil
+		}
+		return "", fmt.Errorf("error resolving secret %s: %w", name, err)
+	}
+	if !result.(lookupResult).found {
+		return "", fmt.Errorf("secret %s not found", name)
+	}
+
+	return result.(lookupResult).value, nil
+}
+
+// Secret returns a handle that resolves the named secret on every use
+func (s *Secrets) Secret(name string) Secret {
+	return namedSecret{
+		secrets: s,
+		name:    name,
+	}
+}
+
+// Refresh drops all cached values, so the next Get fetches from the source
+func (s *Secrets) Refresh() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.cache = map[string]cachedSecret{}
+}
+
+type namedSecret struct {
+	secrets *Secrets
+	name    string
+}
+
+func (s namedSecret) Value(c context.Context) (string, error) {
+	return s.secrets.Get(c, s.name)
+}
+
+type fallbackSource struct {
+	primary  Source
+	fallback Source
+}
+
+func withEnvFallback(primary Source) Source {
+	return fallbackSource{
+		primary:  primary,
+		fallback: NewEnvSource(),
+	}
+}
+
+func (s fallbackSource) Lookup(c context.Context, name string) (string, bool, error) {
+	value, found, err := s.primary.Lookup(c, name)
+	if err != nil || found {
+		return value, found, err
+	}
+	return s.fallback.Lookup(c, name)
+}
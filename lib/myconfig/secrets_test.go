@@ -0,0 +1,241 @@
+package myconfig
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+func TestSecrets(t *testing.T) {
+	c := context.TODO()
+
+	t.Run("Value is resolved from source", func(t *testing.T) {
+		// setup
+		source := NewFakeSource(map[string]string{"STRIPE_API_KEY": "sk_1"})
+		sut := NewWithSource(source, time.Minute, mytime.RealNower{})
+
+		// when
+		value, err := sut.Secret("STRIPE_API_KEY").Value(c)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, "sk_1", value)
+	})
+
+	t.Run("Unknown secret", func(t *testing.T) {
+		// setup
+		sut := NewWithSource(NewFakeSource(nil), time.Minute, mytime.RealNower{})
+
+		// when
+		_, err := sut.Get(c, "STRIPE_API_KEY")
+
+		// then
+		assert.Error(t, err)
+	})
+
+	t.Run("Rotated value is picked up after cache expiry", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		nower := mytime.NewMockNower(ctrl)
+		source := NewFakeSource(map[string]string{"STRIPE_API_KEY": "sk_1"})
+		sut := NewWithSource(source, time.Minute, nower)
+
+		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		value, err := sut.Get(c, "STRIPE_API_KEY")
+		assert.NoError(t, err)
+		assert.Equal(t, "sk_1", value)
+
+		// when
+		source.Set("STRIPE_API_KEY", "sk_2")
+
+		// then
+		nower.EXPECT().Now().Return(mytime.ExampleTime.Add(30 * time.Second))
+		value, err = sut.Get(c, "STRIPE_API_KEY")
+		assert.NoError(t, err)
+		assert.Equal(t, "sk_1", value)
+
+		nower.EXPECT().Now().Return(mytime.ExampleTime.Add(61 * time.Second))
+		value, err = sut.Get(c, "STRIPE_API_KEY")
+		assert.NoError(t, err)
+		assert.Equal(t, "sk_2", value)
+	})
+
+	t.Run("Refresh drops cache", func(t *testing.T) {
+		// setup
+		source := NewFakeSource(map[string]string{"STRIPE_API_KEY": "sk_1"})
+		sut := NewWithSource(source, time.Hour, mytime.RealNower{})
+		_, err := sut.Get(c, "STRIPE_API_KEY")
+		assert.NoError(t, err)
+		source.Set("STRIPE_API_KEY", "sk_2")
+
+		// when
+		sut.Refresh()
+
+		// then
+		value, err := sut.Get(c, "STRIPE_API_KEY")
+		assert.NoError(t, err)
+		assert.Equal(t, "sk_2", value)
+	})
+
+	t.Run("Last known value is used when source fails", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		source := NewMockSource(ctrl)
+		nower := mytime.NewMockNower(ctrl)
+		sut := NewWithSource(source, time.Minute, nower)
+
+		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		source.EXPECT().Lookup(gomock.Any(), "STRIPE_API_KEY").Return("sk_1", true, nil)
+		_, err := sut.Get(c, "STRIPE_API_KEY")
+		assert.NoError(t, err)
+
+		// when
+		nower.EXPECT().Now().Return(mytime.ExampleTime.Add(time.Hour))
+		source.EXPECT().Lookup(gomock.Any(), "STRIPE_API_KEY").Return("", false, fmt.Errorf("unavailable"))
+		value, err := sut.Get(c, "STRIPE_API_KEY")
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, "sk_1", value)
+	})
+
+	t.Run("Source failure without known value", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		source := NewMockSource(ctrl)
+		sut := NewWithSource(source, time.Minute, mytime.RealNower{})
+
+		// given
+		source.EXPECT().Lookup(gomock.Any(), "STRIPE_API_KEY").Return("", false, fmt.Errorf("unavailable"))
+
+		// when
+		_, err := sut.Get(c, "STRIPE_API_KEY")
+
+		// then
+		assert.Error(t, err)
+	})
+
+	t.Run("Slow lookup does not block other secrets", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		source := NewMockSource(ctrl)
+		sut := NewWithSource(source, time.Minute, mytime.RealNower{})
+
+		// given
+		started := make(chan struct{})
+		release := make(chan struct{})
+		source.EXPECT().Lookup(gomock.Any(), "STRIPE_API_KEY").DoAndReturn(func(c context.Context, name string) (string, bool, error) {
+			close(started)
+			<-release
+			return "sk_1", true, nil
+		})
+		source.EXPECT().Lookup(gomock.Any(), "MOLLIE_API_KEY").Return("test_1", true, nil)
+
+		stripeValue := make(chan string)
+		go func() {
+			value, err := sut.Get(c, "STRIPE_API_KEY")
+			assert.NoError(t, err)
+			stripeValue <- value
+		}()
+		<-started
+
+		// when
+		value, err := sut.Get(c, "MOLLIE_API_KEY")
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, "test_1", value)
+
+		close(release)
+		assert.Equal(t, "sk_1", <-stripeValue)
+	})
+}
+
+func TestSources(t *testing.T) {
+	c := context.TODO()
+
+	t.Run("File source reflects edits", func(t *testing.T) {
+		// setup
+		filename := filepath.Join(t.TempDir(), "secrets.json")
+		err := os.WriteFile(filename, []byte(`{"MOLLIE_API_KEY":"test_1"}`), 0600)
+		assert.NoError(t, err)
+		sut := NewFileSource(filename)
+
+		// when
+		value, found, err := sut.Lookup(c, "MOLLIE_API_KEY")
+
+		// then
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "test_1", value)
+
+		// when
+		err = os.WriteFile(filename, []byte(`{"MOLLIE_API_KEY":"test_2"}`), 0600)
+		assert.NoError(t, err)
+		value, found, err = sut.Lookup(c, "MOLLIE_API_KEY")
+
+		// then
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "test_2", value)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		// setup
+		sut := NewFileSource(filepath.Join(t.TempDir(), "missing.json"))
+
+		// when
+		_, _, err := sut.Lookup(c, "MOLLIE_API_KEY")
+
+		// then
+		assert.Error(t, err)
+	})
+
+	t.Run("Falls back to env", func(t *testing.T) {
+		// setup
+		t.Setenv("ADYEN_API_KEY", "from_env")
+		sut := withEnvFallback(NewFakeSource(map[string]string{"STRIPE_API_KEY": "from_source"}))
+
+		// when
+		value, found, err := sut.Lookup(c, "ADYEN_API_KEY")
+
+		// then
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "from_env", value)
+
+		value, found, err = sut.Lookup(c, "STRIPE_API_KEY")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "from_source", value)
+	})
+
+	t.Run("Select source from env", func(t *testing.T) {
+		// setup
+		filename := filepath.Join(t.TempDir(), "secrets.json")
+		err := os.WriteFile(filename, []byte(`{"MOLLIE_API_KEY":"from_file"}`), 0600)
+		assert.NoError(t, err)
+		t.Setenv("SECRETS_SOURCE", "file")
+		t.Setenv("SECRETS_FILE", filename)
+
+		// when
+		sut, cleanup, err := New(c, mytime.RealNower{})
+
+		// then
+		assert.NoError(t, err)
+		defer cleanup()
+		value, err := sut.Get(c, "MOLLIE_API_KEY")
+		assert.NoError(t, err)
+		assert.Equal(t, "from_file", value)
+	})
+}
@@ -0,0 +1,21 @@
+package myconfig
+
+import (
+	"context"
+	"os"
+)
+
+// envSource resolves secrets from environment variables with the same name
+type envSource struct{}
+
+func NewEnvSource() Source {
+	return envSource{}
+}
+
+func (s envSource) Lookup(c context.Context, name string) (string, bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", false, nil
+	}
+	return value, true, nil
+}
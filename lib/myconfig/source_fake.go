@@ -0,0 +1,37 @@
+package myconfig
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeSource is an in-memory stand-in for a secret-manager. Set can be used to rotate a secret at runtime.
+type FakeSource struct {
+	sync.Mutex
+	secrets map[string]string
+}
+
+func NewFakeSource(secrets map[string]string) *FakeSource {
+	s := &FakeSource{
+		secrets: map[string]string{},
+	}
+	for name, value := range secrets {
+		s.secrets[name] = value
+	}
+	return s
+}
+
+func (s *FakeSource) Set(name string, value string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.secrets[name] = value
+}
+
+func (s *FakeSource) Lookup(c context.Context, name string) (string, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	value, found := s.secrets[name]
+	return value, found, nil
+}
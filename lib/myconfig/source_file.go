@@ -0,0 +1,39 @@
+package myconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// fileSource resolves secrets from a json file containing a single object of name-value pairs.
+// The file is read on every lookup, so editing the file rotates the secrets.
+type fileSource struct {
+	filename string
+}
+
+func NewFileSource(filename string) Source {
+	return &fileSource{
+		filename: filename,
+	}
+}
+
+func (s *fileSource) Lookup(c context.Context, name string) (string, bool, error) {
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return "", false, fmt.Errorf("error reading secrets-file %s: %w", s.filename, err)
+	}
+
+	secrets := map[string]string{}
+	err = json.Unmarshal(data, &secrets)
+	if err != nil {
+		return "", false, fmt.Errorf("error parsing secrets-file %s: %w", s.filename, err)
+	}
+
+	value, found := secrets[name]
+	if !found || value == "" {
+		return "", false, nil
+	}
+	return value, true, nil
+}
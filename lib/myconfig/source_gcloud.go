@@ -0,0 +1,45 @@
+package myconfig
+
+import (
+	"context"
+	"fmt"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gcloudSource resolves the latest version of a secret from Google Secret Manager.
+// Adding a new secret-version rotates the secret.
+type gcloudSource struct {
+	client    *secretmanager.Client
+	projectID string
+}
+
+func newGcloudSource(c context.Context, projectID string) (*gcloudSource, func(), error) {
+	client, err := secretmanager.NewClient(c)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating secret-manager client: %w", err)
+	}
+
+	return &gcloudSource{
+		client:    client,
+		projectID: projectID,
+	}, func() {
+		client.Close()
+	}, nil
+}
+
+func (s *gcloudSource) Lookup(c context.Context, name string) (string, bool, error) {
+	resp, err := s.client.AccessSecretVersion(c, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/latest", s.projectID, name),
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("error accessing secret %s: %w", name, err)
+	}
+	return string(resp.Payload.Data), true, nil
+}
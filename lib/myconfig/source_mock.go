@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api.go -package myconfig -destination source_mock.go Source
+//
+
+// Package myconfig is a generated GoMock package.
+package myconfig
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSource is a mock of Source interface.
+type MockSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockSourceMockRecorder
+	isgomock struct{}
+}
+
+// MockSourceMockRecorder is the mock recorder for MockSource.
+type MockSourceMockRecorder struct {
+	mock *MockSource
+}
+
+// NewMockSource creates a new mock instance.
+func NewMockSource(ctrl *gomock.Controller) *MockSource {
+	mock := &MockSource{ctrl: ctrl}
+	mock.recorder = &MockSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSource) EXPECT() *MockSourceMockRecorder {
+	return m.recorder
+}
+
+// Lookup mocks base method.
+func (m *MockSource) Lookup(c context.Context, name string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lookup", c, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Lookup indicates an expected call of Lookup.
+func (mr *MockSourceMockRecorder) Lookup(c, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lookup", reflect.TypeOf((*MockSource)(nil).Lookup), c, name)
+}
+
+// MockSecret is a mock of Secret interface.
+type MockSecret struct {
+	ctrl     *gomock.Controller
+	recorder *MockSecretMockRecorder
+	isgomock struct{}
+}
+
+// MockSecretMockRecorder is the mock recorder for MockSecret.
+type MockSecretMockRecorder struct {
+	mock *MockSecret
+}
+
+// NewMockSecret creates a new mock instance.
+func NewMockSecret(ctrl *gomock.Controller) *MockSecret {
+	mock := &MockSecret{ctrl: ctrl}
+	mock.recorder = &MockSecretMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSecret) EXPECT() *MockSecretMockRecorder {
+	return m.recorder
+}
+
+// Value mocks base method.
+func (m *MockSecret) Value(c context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Value", c)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Value indicates an expected call of Value.
+func (mr *MockSecretMockRecorder) Value(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Value", reflect.TypeOf((*MockSecret)(nil).Value), c)
+}
@@ -11,18 +11,67 @@ import (
 // CtxTraceContext is a context key for the trace context this (used by mylog)
 type CtxTraceContext struct{}
 
+// CtxRequestID is a context key for the id of the http-request being handled (used by mylog)
+type CtxRequestID struct{}
+
+// RequestIDHeader carries the request-id from the caller and is echoed in the response
+const RequestIDHeader = "X-Request-ID"
+
+// ContextFromHTTPRequest derives from the request context, so cancellation by the client is propagated.
+// The trace and request-id put in place by the myhttp middleware are kept, otherwise they are taken from the headers.
 func ContextFromHTTPRequest(r *http.Request) context.Context {
-	var trace string
+	c := r.Context()
 
+	if TraceFromContext(c) == "" {
+		c = WithTrace(c, TraceFromHTTPRequest(r))
+	}
+
+	if RequestIDFromContext(c) == "" {
+		if requestID := r.Header.Get(RequestIDHeader); requestID != "" {
+			c = WithRequestID(c, requestID)
+		}
+	}
+
+	return c
+}
+
+// TraceFromHTTPRequest converts the trace header of Google Cloud into a trace as understood by Cloud Logging
+func TraceFromHTTPRequest(r *http.Request) string {
 	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
 	traceContext := r.Header.Get("X-Cloud-Trace-Context")
 	traceParts := strings.Split(traceContext, "/")
 
 	if len(traceParts) > 0 && len(traceParts[0]) > 0 {
-		trace = fmt.Sprintf("projects/%s/traces/%s", projectID, traceParts[0])
+		return fmt.Sprintf("projects/%s/traces/%s", projectID, traceParts[0])
 	}
 
-	ctx := context.WithValue(context.Background(), CtxTraceContext{}, trace)
+	return ""
+}
 
-	return ctx
+// WithTrace returns a context that carries the given trace, so it can be continued across an async hop
+func WithTrace(c context.Context, trace string) context.Context {
+	return context.WithValue(c, CtxTraceContext{}, trace)
+}
+
+// TraceFromContext returns the trace stored in the context or an empty string when there is none
+func TraceFromContext(c context.Context) string {
+	trace, ok := c.Value(CtxTraceContext{}).(string)
+	if !ok {
+		return ""
+	}
+	return trace
+}
+
+// WithRequestID returns a context that carries the given request-id
+func WithRequestID(c context.Context, requestID string) context.Context {
+	return context.WithValue(c, CtxRequestID{}, requestID)
+}
+
+// RequestIDFromContext returns the request-id stored in the context or an empty string when there is none
+func RequestIDFromContext(c context.Context) string {
+	requestID, ok := c.Value(CtxRequestID{}).(string)
+	if !ok {
+		return ""
+	}
+	return requestID
 }
@@ -1,8 +1,10 @@
 package myerrors
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 type httpErrorCoder interface {
@@ -11,8 +13,12 @@ type httpErrorCoder interface {
 }
 
 type httpError struct {
-	httpCode int
-	err      error
+	httpCode    int
+	problemType ProblemType
+	fieldErrors []FieldError
+	// detail replaces the message of err towards the client
+	detail string
+	err    error
 }
 
 func (e httpError) Error() string {
@@ -23,19 +29,61 @@ func (e httpError) GetHTTPErrorCode() int {
 	return e.httpCode
 }
 
-func newError(httpCode int, err error) *httpError {
+func (e httpError) Unwrap() error {
+	return e.err
+}
+
+// Is makes errors.Is(err, myerrors.ErrNotFound) match on the kind of error
+func (e httpError) Is(target error) bool {
+	kind, ok := target.(Kind)
+	return ok && kindOfStatus(e.httpCode) == kind
+}
+
+func newError(problemType ProblemType, err error) *httpError {
 	return &httpError{
-		httpCode: httpCode,
-		err:      err,
+		httpCode:    problemType.Status,
+		problemType: problemType,
+		err:         err,
+	}
+}
+
+// NewProblem creates an error of a specific, documented problem type
+func NewProblem(problemType ProblemType, err error) *httpError {
+	return newError(problemType, err)
+}
+
+// NewProblemf creates an error of a specific, documented problem type
+func NewProblemf(problemType ProblemType, format string, args ...interface{}) *httpError {
+	return newError(problemType, fmt.Errorf(format, args...))
+}
+
+// NewValidationError reports which input fields are invalid and why
+func NewValidationError(fieldErrors ...FieldError) *httpError {
+	messages := []string{}
+	for _, fe := range fieldErrors {
+		messages = append(messages, fmt.Sprintf("%s: %s", fe.Field, fe.Message))
 	}
+	return newError(ProblemValidation, fmt.Errorf("%s", strings.Join(messages, ", "))).WithFieldErrors(fieldErrors...)
+}
+
+// WithFieldErrors attaches field-level validation errors
+func (e *httpError) WithFieldErrors(fieldErrors ...FieldError) *httpError {
+	e.fieldErrors = append(e.fieldErrors, fieldErrors...)
+	return e
+}
+
+// WithDetail sets the message shown to the client, so the wrapped error can hold details that must not be exposed
+func (e *httpError) WithDetail(detail string) *httpError {
+	e.detail = detail
+	return e
 }
 
 func NewInvalidInputError(err error) *httpError {
-	return newError(http.StatusBadRequest, err)
+	return newError(ProblemInvalidInput, err)
 }
 
 func NewUnsupportedMediaTypeError(err error) *httpError {
-	return newError(http.StatusUnsupportedMediaType, err)
+	return newError(ProblemUnsupportedMediaType, err)
 }
 
 func NewInvalidInputErrorf(format string, args ...interface{}) *httpError {
@@ -43,32 +91,69 @@ func NewInvalidInputErrorf(format string, args ...interface{}) *httpError {
 }
 
 func NewNotFoundError(err error) *httpError {
-	return newError(http.StatusNotFound, err)
+	return newError(ProblemNotFound, err)
+}
+
+// NewUnauthorizedError is for requests without valid credentials
+func NewUnauthorizedError(err error) *httpError {
+	return newError(ProblemUnauthorized, err)
 }
 
+// NewAuthenticationError is for authenticated requests that are not allowed
 func NewAuthenticationError(err error) *httpError {
-	return newError(http.StatusForbidden, err)
+	return newError(ProblemForbidden, err)
+}
+
+func NewConflictError(err error) *httpError {
+	return newError(ProblemConflict, err)
+}
+
+func NewRateLimitedError(err error) *httpError {
+	return newError(ProblemRateLimited, err)
+}
+
+// NewUpstreamError is for failures of a remote system, such as a payment provider
+func NewUpstreamError(err error) *httpError {
+	return newError(ProblemUpstream, err)
 }
 
 func NewInternalError(err error) *httpError {
-	return newError(http.StatusInternalServerError, err)
+	return newError(ProblemInternal, err)
 }
 
 func NewNotImplementedError(err error) *httpError {
-	return newError(http.StatusNotImplemented, err)
+	return newError(ProblemNotImplemented, err)
 }
 
 func NewUnavailableError(err error) *httpError {
-	return newError(http.StatusServiceUnavailable, err)
+	return newError(ProblemUnavailable, err)
 }
 
 func GetHTTPStatus(err error) int {
-	if err != nil {
-		myError, ok := err.(httpErrorCoder)
-		if ok {
-			return myError.GetHTTPErrorCode()
-		}
+	var myError httpErrorCoder
+	if errors.As(err, &myError) {
+		return myError.GetHTTPErrorCode()
 	}
 
 	return http.StatusInternalServerError
 }
+
+// IsPermanent tells whether retrying the failed operation is pointless: the request itself is wrong.
+// Errors that are not classified are considered temporary.
+func IsPermanent(err error) bool {
+	var myError httpErrorCoder
+	if !errors.As(err, &myError) {
+		return false
+	}
+
+	switch code := myError.GetHTTPErrorCode(); {
+	case code == http.StatusRequestTimeout || code == http.StatusTooManyRequests:
+		return false
+	case code >= 400 && code < 500:
+		return true
+	case code == http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
@@ -82,3 +82,29 @@ func TestErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestIsPermanent(t *testing.T) {
+	myErr := fmt.Errorf("my error")
+
+	testCases := []struct {
+		name      string
+		in        error
+		permanent bool
+	}{
+		{name: "No error", in: nil, permanent: false},
+		{name: "No http error", in: myErr, permanent: false},
+		{name: "Invalid input error", in: NewInvalidInputError(myErr), permanent: true},
+		{name: "Not found error", in: NewNotFoundError(myErr), permanent: true},
+		{name: "Not implemented error", in: NewNotImplementedError(myErr), permanent: true},
+		{name: "Internal error", in: NewInternalError(myErr), permanent: false},
+		{name: "Not available error", in: NewUnavailableError(myErr), permanent: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			permanent := IsPermanent(tc.in)
+			if permanent != tc.permanent {
+				t.Errorf("Permanent: got %v, want %v", permanent, tc.permanent)
+			}
+		})
+	}
+}
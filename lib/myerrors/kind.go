@@ -0,0 +1,116 @@
+package myerrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind classifies an error independent of how often it has been wrapped:
+//
+//	if errors.Is(err, myerrors.ErrNotFound) { ... }
+//
+// A kind can also be wrapped directly: fmt.Errorf("basket %s: %w", uid, myerrors.ErrNotFound)
+type Kind string
+
+const (
+	ErrInvalidInput         Kind = "invalid input"
+	ErrUnauthorized         Kind = "unauthorized"
+	ErrForbidden            Kind = "forbidden"
+	ErrNotFound             Kind = "not found"
+	ErrConflict             Kind = "conflict"
+	ErrUnsupportedMediaType Kind = "unsupported media type"
+	ErrRateLimited          Kind = "rate limited"
+	ErrInternal             Kind = "internal"
+	ErrNotImplemented       Kind = "not implemented"
+	ErrUpstream             Kind = "upstream failure"
+	ErrUnavailable          Kind = "unavailable"
+)
+
+var kindProblemTypes = map[Kind]ProblemType{
+	ErrInvalidInput:         ProblemInvalidInput,
+	ErrUnauthorized:         ProblemUnauthorized,
+	ErrForbidden:            ProblemForbidden,
+	ErrNotFound:             ProblemNotFound,
+	ErrConflict:             ProblemConflict,
+	ErrUnsupportedMediaType: ProblemUnsupportedMediaType,
+	ErrRateLimited:          ProblemRateLimited,
+	ErrInternal:             ProblemInternal,
+	ErrNotImplemented:       ProblemNotImplemented,
+	ErrUpstream:             ProblemUpstream,
+	ErrUnavailable:          ProblemUnavailable,
+}
+
+func (k Kind) Error() string {
+	return string(k)
+}
+
+func (k Kind) GetHTTPErrorCode() int {
+	return k.problemType().Status
+}
+
+func (k Kind) problemType() ProblemType {
+	problemType, found := kindProblemTypes[k]
+	if !found {
+		return ProblemInternal
+	}
+	return problemType
+}
+
+// kindOfStatus derives the kind from the http-status, so service specific problem types are classified as well
+func kindOfStatus(httpStatus int) Kind {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return ErrInvalidInput
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound, http.StatusGone:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusUnsupportedMediaType:
+		return ErrUnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusNotImplemented:
+		return ErrNotImplemented
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return ErrUpstream
+	case http.StatusServiceUnavailable:
+		return ErrUnavailable
+	default:
+		return ErrInternal
+	}
+}
+
+// KindOf returns the kind of the first classified error in the chain, ErrInternal when there is none
+func KindOf(err error) Kind {
+	var myError *httpError
+	if errors.As(err, &myError) {
+		return kindOfStatus(myError.httpCode)
+	}
+
+	var kind Kind
+	if errors.As(err, &kind) {
+		return kind
+	}
+
+	return ErrInternal
+}
+
+// ClassifyUpstream classifies the failure of a call to a remote system by the http-status it responded with.
+// A remote system that rejects our own request or credentials is not something our caller can fix: that is an
+// upstream failure, which also keeps the response of the remote system away from our caller.
+func ClassifyUpstream(httpStatus int, err error) error {
+	switch httpStatus {
+	case http.StatusNotFound:
+		return NewNotFoundError(err)
+	case http.StatusConflict:
+		return NewConflictError(err)
+	case http.StatusTooManyRequests:
+		return NewRateLimitedError(err)
+	default:
+		return NewUpstreamError(err)
+	}
+}
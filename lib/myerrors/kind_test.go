@@ -0,0 +1,90 @@
+package myerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapping(t *testing.T) {
+	cause := fmt.Errorf("datastore: no such entity")
+
+	t.Run("Wrapped error keeps its status", func(t *testing.T) {
+		err := fmt.Errorf("error fetching basket: %w", NewNotFoundError(cause))
+
+		assert.Equal(t, 404, GetHTTPStatus(err))
+		assert.True(t, errors.Is(err, ErrNotFound))
+		assert.False(t, errors.Is(err, ErrConflict))
+		assert.True(t, errors.Is(err, cause))
+		assert.True(t, IsPermanent(err))
+	})
+
+	t.Run("Kind can be wrapped directly", func(t *testing.T) {
+		err := fmt.Errorf("basket 123: %w", ErrConflict)
+
+		assert.Equal(t, 409, GetHTTPStatus(err))
+		assert.Equal(t, ErrConflict, KindOf(err))
+		assert.Equal(t, Problem{Type: "/problems/conflict", Title: "Conflicts with the current state", Status: 409, Detail: "basket 123: conflict"}, ToProblem(err))
+	})
+
+	t.Run("Service specific type has kind of its status", func(t *testing.T) {
+		problemType := ProblemType{Type: "/problems/test/basket-gone", Title: "Basket gone", Status: 404}
+
+		err := fmt.Errorf("error: %w", NewProblem(problemType, cause))
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Unclassified error", func(t *testing.T) {
+		assert.Equal(t, ErrInternal, KindOf(cause))
+		assert.Equal(t, 500, GetHTTPStatus(cause))
+	})
+
+	testCases := []struct {
+		name       string
+		in         error
+		httpStatus int
+		kind       Kind
+	}{
+		{name: "Unauthorized", in: NewUnauthorizedError(cause), httpStatus: 401, kind: ErrUnauthorized},
+		{name: "Forbidden", in: NewAuthenticationError(cause), httpStatus: 403, kind: ErrForbidden},
+		{name: "Conflict", in: NewConflictError(cause), httpStatus: 409, kind: ErrConflict},
+		{name: "Rate limited", in: NewRateLimitedError(cause), httpStatus: 429, kind: ErrRateLimited},
+		{name: "Upstream", in: NewUpstreamError(cause), httpStatus: 502, kind: ErrUpstream},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.httpStatus, GetHTTPStatus(tc.in))
+			assert.True(t, errors.Is(tc.in, tc.kind))
+			assert.Equal(t, cause, errors.Unwrap(tc.in))
+		})
+	}
+}
+
+func TestClassifyUpstream(t *testing.T) {
+	cause := fmt.Errorf("remote failed")
+
+	testCases := []struct {
+		httpStatus int
+		kind       Kind
+	}{
+		{httpStatus: 400, kind: ErrUpstream},
+		{httpStatus: 401, kind: ErrUpstream},
+		{httpStatus: 404, kind: ErrNotFound},
+		{httpStatus: 409, kind: ErrConflict},
+		{httpStatus: 422, kind: ErrUpstream},
+		{httpStatus: 429, kind: ErrRateLimited},
+		{httpStatus: 500, kind: ErrUpstream},
+		{httpStatus: 0, kind: ErrUpstream},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", tc.httpStatus), func(t *testing.T) {
+			err := ClassifyUpstream(tc.httpStatus, cause)
+
+			assert.Equal(t, tc.kind, KindOf(err))
+			assert.True(t, errors.Is(err, cause))
+		})
+	}
+}
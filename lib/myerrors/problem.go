@@ -0,0 +1,113 @@
+package myerrors
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ProblemType identifies a class of errors in a stable, machine-readable way (RFC 7807).
+// Clients act on Type; it never changes once published.
+type ProblemType struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+}
+
+// FieldError tells why a single input field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Problem is the application/problem+json representation of an error.
+// It only contains what is safe to show to a client.
+type Problem struct {
+	Type          string       `json:"type"`
+	Title         string       `json:"title"`
+	Status        int          `json:"status"`
+	Detail        string       `json:"detail,omitempty"`
+	TraceID       string       `json:"traceID,omitempty"`
+	RequestID     string       `json:"requestID,omitempty"`
+	InvalidParams []FieldError `json:"invalid-params,omitempty"`
+}
+
+const commonProblems = "common"
+
+// Problem types shared by all services
+var (
+	ProblemInvalidInput         = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/invalid-input", Title: "Invalid input", Status: http.StatusBadRequest})
+	ProblemValidation           = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/validation-failed", Title: "One or more fields are invalid", Status: http.StatusBadRequest})
+	ProblemUnauthorized         = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/unauthorized", Title: "Missing or invalid credentials", Status: http.StatusUnauthorized})
+	ProblemForbidden            = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/forbidden", Title: "Not allowed", Status: http.StatusForbidden})
+	ProblemNotFound             = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/not-found", Title: "Resource not found", Status: http.StatusNotFound})
+	ProblemConflict             = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/conflict", Title: "Conflicts with the current state", Status: http.StatusConflict})
+	ProblemUnsupportedMediaType = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/unsupported-media-type", Title: "Unsupported media type", Status: http.StatusUnsupportedMediaType})
+	ProblemRateLimited          = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/rate-limited", Title: "Too many requests", Status: http.StatusTooManyRequests})
+	ProblemInternal             = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/internal", Title: "Internal error", Status: http.StatusInternalServerError})
+	ProblemNotImplemented       = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/not-implemented", Title: "Not implemented", Status: http.StatusNotImplemented})
+	ProblemUpstream             = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/upstream", Title: "Remote system failed", Status: http.StatusBadGateway})
+	ProblemUnavailable          = RegisterProblemType(commonProblems, ProblemType{Type: "/problems/unavailable", Title: "Temporarily unavailable", Status: http.StatusServiceUnavailable})
+)
+
+var (
+	catalogueMutex sync.Mutex
+	catalogue      = map[string][]ProblemType{}
+)
+
+// RegisterProblemType adds a problem type to the catalogue of the given service
+func RegisterProblemType(service string, problemType ProblemType) ProblemType {
+	catalogueMutex.Lock()
+	defer catalogueMutex.Unlock()
+
+	catalogue[service] = append(catalogue[service], problemType)
+	return problemType
+}
+
+// ProblemCatalogue returns the registered problem types per service, sorted on type
+func ProblemCatalogue() map[string][]ProblemType {
+	catalogueMutex.Lock()
+	defer catalogueMutex.Unlock()
+
+	result := map[string][]ProblemType{}
+	for service, problemTypes := range catalogue {
+		sorted := append([]ProblemType{}, problemTypes...)
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].Type < sorted[j].Type
+		})
+		result[service] = sorted
+	}
+	return result
+}
+
+// ToProblem converts an error into its client representation.
+// Details of server-side errors are not exposed: they could reveal internals.
+func ToProblem(err error) Problem {
+	var myError *httpError
+	if !errors.As(err, &myError) {
+		problemType := KindOf(err).problemType()
+		problem := Problem{
+			Type:   problemType.Type,
+			Title:  problemType.Title,
+			Status: problemType.Status,
+		}
+		if problem.Status < http.StatusInternalServerError {
+			problem.Detail = err.Error()
+		}
+		return problem
+	}
+
+	problem := Problem{
+		Type:          myError.problemType.Type,
+		Title:         myError.problemType.Title,
+		Status:        myError.httpCode,
+		InvalidParams: myError.fieldErrors,
+	}
+	if myError.detail != "" {
+		problem.Detail = myError.detail
+	} else if myError.httpCode < http.StatusInternalServerError {
+		problem.Detail = myError.err.Error()
+	}
+	return problem
+}
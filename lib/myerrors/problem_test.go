@@ -0,0 +1,92 @@
+package myerrors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToProblem(t *testing.T) {
+	t.Run("Client error exposes detail", func(t *testing.T) {
+		problem := ToProblem(NewNotFoundError(fmt.Errorf("basket 123 not found")))
+
+		assert.Equal(t, Problem{
+			Type:   "/problems/not-found",
+			Title:  "Resource not found",
+			Status: 404,
+			Detail: "basket 123 not found",
+		}, problem)
+	})
+
+	t.Run("Server error hides detail", func(t *testing.T) {
+		problem := ToProblem(NewInternalError(fmt.Errorf("datastore: connection refused")))
+
+		assert.Equal(t, Problem{
+			Type:   "/problems/internal",
+			Title:  "Internal error",
+			Status: 500,
+		}, problem)
+	})
+
+	t.Run("Unclassified error hides detail", func(t *testing.T) {
+		problem := ToProblem(fmt.Errorf("nil pointer"))
+
+		assert.Equal(t, Problem{
+			Type:   "/problems/internal",
+			Title:  "Internal error",
+			Status: 500,
+		}, problem)
+	})
+
+	t.Run("Validation error lists fields", func(t *testing.T) {
+		err := NewValidationError(FieldError{Field: "basketUID", Message: "is mandatory"}, FieldError{Field: "quantity", Message: "must be positive"})
+
+		problem := ToProblem(err)
+
+		assert.Equal(t, 400, GetHTTPStatus(err))
+		assert.Equal(t, Problem{
+			Type:   "/problems/validation-failed",
+			Title:  "One or more fields are invalid",
+			Status: 400,
+			Detail: "basketUID: is mandatory, quantity: must be positive",
+			InvalidParams: []FieldError{
+				{Field: "basketUID", Message: "is mandatory"},
+				{Field: "quantity", Message: "must be positive"},
+			},
+		}, problem)
+	})
+
+	t.Run("Curated detail replaces the error", func(t *testing.T) {
+		problem := ToProblem(NewInvalidInputError(fmt.Errorf("provider said: invalid merchant account")).WithDetail("Request rejected"))
+
+		assert.Equal(t, Problem{
+			Type:   "/problems/invalid-input",
+			Title:  "Invalid input",
+			Status: 400,
+			Detail: "Request rejected",
+		}, problem)
+	})
+
+	t.Run("Service specific type", func(t *testing.T) {
+		problemType := ProblemType{Type: "/problems/test/thing-gone", Title: "Thing gone", Status: 410}
+
+		problem := ToProblem(NewProblemf(problemType, "thing %d gone", 7))
+
+		assert.Equal(t, Problem{
+			Type:   "/problems/test/thing-gone",
+			Title:  "Thing gone",
+			Status: 410,
+			Detail: "thing 7 gone",
+		}, problem)
+	})
+}
+
+func TestProblemCatalogue(t *testing.T) {
+	problemType := RegisterProblemType("test", ProblemType{Type: "/problems/test/registered", Title: "Registered", Status: 409})
+
+	catalogue := ProblemCatalogue()
+
+	assert.Contains(t, catalogue["test"], problemType)
+	assert.Contains(t, catalogue["common"], ProblemNotFound)
+}
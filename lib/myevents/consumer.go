@@ -0,0 +1,72 @@
+package myevents
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MarcGrol/shopbackend/lib/mystore"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+// ProcessedEnvelope is an entry in the ledger of envelopes that a consumer has already processed
+type ProcessedEnvelope struct {
+	UID           string
+	ConsumerName  string
+	EnvelopeUID   string
+	EventTypeName string
+	ProcessedAt   time.Time
+}
+
+// IdempotentConsumer makes sure an envelope is handled at most once per consumer, even when pubsub redelivers it.
+type IdempotentConsumer struct {
+	consumerName string
+	ledger       mystore.Store[ProcessedEnvelope]
+	nower        mytime.Nower
+}
+
+func NewIdempotentConsumer(consumerName string, ledger mystore.Store[ProcessedEnvelope], nower mytime.Nower) *IdempotentConsumer {
+	return &IdempotentConsumer{
+		consumerName: consumerName,
+		ledger:       ledger,
+		nower:        nower,
+	}
+}
+
+// Consume runs the handler and records the envelope in the ledger within the same transaction.
+// Envelopes that are already in the ledger are skipped.
+func (ic *IdempotentConsumer) Consume(c context.Context, envelope EventEnvelope, handler func(c context.Context) error) error {
+	ledgerUID := ic.consumerName + "_" + envelope.UID
+
+	return ic.ledger.RunInTransaction(c, func(c context.Context) error {
+		processed, exists, err := ic.ledger.Get(c, ledgerUID)
+		if err != nil {
+			return fmt.Errorf("error fetching ledger entry %s: %w", ledgerUID, err)
+		}
+
+		if exists {
+			log.Printf("Consumer %s already processed envelope %s (%s) at %s: skip",
+				ic.consumerName, envelope.UID, envelope.String(), processed.ProcessedAt)
+			return nil
+		}
+
+		err = handler(c)
+		if err != nil {
+			return err
+		}
+
+		err = ic.ledger.Put(c, ledgerUID, ProcessedEnvelope{
+			UID:           ledgerUID,
+			ConsumerName:  ic.consumerName,
+			EnvelopeUID:   envelope.UID,
+			EventTypeName: envelope.EventTypeName,
+			ProcessedAt:   ic.nower.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("error storing ledger entry %s: %w", ledgerUID, err)
+		}
+
+		return nil
+	})
+}
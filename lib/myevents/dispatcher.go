@@ -0,0 +1,75 @@
+package myevents
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"log"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+)
+
+// unknownEvents counts envelopes per consumer and event-type that had no registered handler
+var unknownEvents = expvar.NewMap("myevents_unknown_events")
+
+// Handler handles a single typed event
+type Handler[E Event] func(c context.Context, topic string, event E) error
+
+type envelopeHandler func(c context.Context, envelope EventEnvelope) error
+
+// Dispatcher decodes envelopes and dispatches them to the handlers registered for their event-type.
+type Dispatcher struct {
+	consumerName string
+	handlers     map[string][]envelopeHandler
+}
+
+func NewDispatcher(consumerName string) *Dispatcher {
+	return &Dispatcher{
+		consumerName: consumerName,
+		handlers:     map[string][]envelopeHandler{},
+	}
+}
+
+// Register adds a handler for the event-type of E. Multiple handlers can be registered for the same event-type:
+// they are called in order of registration.
+func Register[E Event](d *Dispatcher, handler Handler[E]) {
+	var zero E
+	eventTypeName := zero.GetEventTypeName()
+	eventVersion := zero.GetEventVersion()
+
+	d.handlers[eventTypeName] = append(d.handlers[eventTypeName], func(c context.Context, envelope EventEnvelope) error {
+		payload, err := upcast(envelope, eventVersion)
+		if err != nil {
+			return err
+		}
+
+		var event E
+		err = json.Unmarshal(payload, &event)
+		if err != nil {
+			return myerrors.NewInvalidInputError(err)
+		}
+		return handler(c, envelope.Topic, event)
+	})
+}
+
+// Dispatch calls all handlers registered for the event-type of the envelope.
+// Unknown event-types are acknowledged and ignored, so they do not get redelivered forever.
+func (d *Dispatcher) Dispatch(c context.Context, envelope EventEnvelope) error {
+	c = envelope.ContextWithTrace(c)
+
+	handlers, found := d.handlers[envelope.EventTypeName]
+	if !found {
+		unknownEvents.Add(d.consumerName+"."+envelope.EventTypeName, 1)
+		log.Printf("Consumer %s has no handler for event %s: ignore", d.consumerName, envelope.String())
+		return nil
+	}
+
+	for _, handler := range handlers {
+		err := handler(c, envelope)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package myevents
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type somethingHappened struct {
+	UID  string
+	Name string
+}
+
+func (e somethingHappened) GetEventTypeName() string {
+	return "something.happened"
+}
+
+func (e somethingHappened) GetAggregateName() string {
+	return e.UID
+}
+
+func (e somethingHappened) GetEventVersion() int {
+	return 1
+}
+
+func TestDispatcher(t *testing.T) {
+	c := context.TODO()
+
+	t.Run("Dispatch to all handlers of event", func(t *testing.T) {
+		received := []string{}
+		d := NewDispatcher("test")
+		Register(d, func(c context.Context, topic string, event somethingHappened) error {
+			received = append(received, "first:"+topic+":"+event.Name)
+			return nil
+		})
+		Register(d, func(c context.Context, topic string, event somethingHappened) error {
+			received = append(received, "second:"+topic+":"+event.Name)
+			return nil
+		})
+
+		err := d.Dispatch(c, EventEnvelope{
+			Topic:         "something",
+			EventTypeName: "something.happened",
+			EventPayload:  `{"UID":"123","Name":"Marc"}`,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"first:something:Marc", "second:something:Marc"}, received)
+	})
+
+	t.Run("Stop at first failing handler", func(t *testing.T) {
+		called := false
+		d := NewDispatcher("test")
+		Register(d, func(c context.Context, topic string, event somethingHappened) error {
+			return fmt.Errorf("error handling event")
+		})
+		Register(d, func(c context.Context, topic string, event somethingHappened) error {
+			called = true
+			return nil
+		})
+
+		err := d.Dispatch(c, EventEnvelope{
+			EventTypeName: "something.happened",
+			EventPayload:  `{"UID":"123"}`,
+		})
+		assert.Error(t, err)
+		assert.False(t, called)
+	})
+
+	t.Run("Invalid payload", func(t *testing.T) {
+		d := NewDispatcher("test")
+		Register(d, func(c context.Context, topic string, event somethingHappened) error {
+			return nil
+		})
+
+		err := d.Dispatch(c, EventEnvelope{
+			EventTypeName: "something.happened",
+			EventPayload:  `{`,
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Unknown event is acknowledged and counted", func(t *testing.T) {
+		d := NewDispatcher("test")
+		// the counter is process-global, so it keeps counting across repeated test runs
+		before := unknownEventCount("test.something.unknown")
+
+		err := d.Dispatch(c, EventEnvelope{
+			EventTypeName: "something.unknown",
+			EventPayload:  `{}`,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, before+1, unknownEventCount("test.something.unknown"))
+	})
+}
+
+func unknownEventCount(key string) int64 {
+	counter, ok := unknownEvents.Get(key).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return counter.Value()
+}
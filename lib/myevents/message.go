@@ -21,12 +21,12 @@ func ParseEventEnvelope(r io.Reader) (EventEnvelope, error) {
 	msg := PushRequest{}
 	err := json.NewDecoder(r).Decode(&msg)
 	if err != nil {
-		return EventEnvelope{}, fmt.Errorf("error parsing push-request:%s", err)
+		return EventEnvelope{}, fmt.Errorf("error parsing push-request:%w", err)
 	}
 	envlp := EventEnvelope{}
 	err = json.Unmarshal(msg.Message.Data, &envlp)
 	if err != nil {
-		return EventEnvelope{}, fmt.Errorf("error parsing envelope:%s", err)
+		return EventEnvelope{}, fmt.Errorf("error parsing envelope:%w", err)
 	}
 
 	return envlp, nil
@@ -1,6 +1,23 @@
 package myevents
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+)
+
+// Names of the pubsub-attributes that describe the envelope without having to decode the payload
+const (
+	AttributeUID           = "uid"
+	AttributeTopic         = "topic"
+	AttributeAggregateUID  = "aggregateUID"
+	AttributeEventTypeName = "eventTypeName"
+	AttributeEventVersion  = "eventVersion"
+	AttributeCreatedAt     = "createdAt"
+	AttributeTraceContext  = "traceContext"
+)
 
 type EventEnvelope struct {
 	UID           string
@@ -8,15 +25,62 @@ type EventEnvelope struct {
 	Topic         string
 	AggregateUID  string
 	EventTypeName string
+	EventVersion  int
 	EventPayload  string `datastore:",noindex"`
+	TraceContext  string `datastore:",noindex"`
 	Published     bool
+	// PublishAfter holds back publication of scheduled events until this moment
+	PublishAfter time.Time `json:",omitzero"`
 }
 
 func (e EventEnvelope) String() string {
 	return e.Topic + "." + e.EventTypeName + "." + e.AggregateUID
 }
 
+// Attributes returns the message-attributes to publish along with the envelope.
+// Subscriptions can use them to filter and consumers to correlate.
+func (e EventEnvelope) Attributes() map[string]string {
+	attributes := map[string]string{
+		AttributeUID:           e.UID,
+		AttributeTopic:         e.Topic,
+		AttributeAggregateUID:  e.AggregateUID,
+		AttributeEventTypeName: e.EventTypeName,
+		AttributeEventVersion:  fmt.Sprintf("%d", e.GetEventVersion()),
+		AttributeCreatedAt:     e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		AttributeTraceContext:  e.TraceContext,
+	}
+
+	// empty values have no meaning for filtering
+	for key, value := range attributes {
+		if value == "" {
+			delete(attributes, key)
+		}
+	}
+
+	return attributes
+}
+
+// GetEventVersion returns the schema-version of the payload.
+// Envelopes stored before versioning was introduced have no version and are considered version 1.
+func (e EventEnvelope) GetEventVersion() int {
+	if e.EventVersion == 0 {
+		return 1
+	}
+	return e.EventVersion
+}
+
+// ContextWithTrace continues the trace of the publisher on the consumer side
+func (e EventEnvelope) ContextWithTrace(c context.Context) context.Context {
+	if e.TraceContext == "" {
+		return c
+	}
+	return mycontext.WithTrace(c, e.TraceContext)
+}
+
 type Event interface {
 	GetEventTypeName() string
 	GetAggregateName() string
+	// GetEventVersion returns the schema-version of the event: bump it on every incompatible change, register an upcaster
+	// and replay a fixture of the previous version with myeventstest.ReplayFixtures
+	GetEventVersion() int
 }
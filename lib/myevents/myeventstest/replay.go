@@ -0,0 +1,54 @@
+// Package myeventstest offers helpers for tests of event producers and consumers
+package myeventstest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+)
+
+// ReplayCase is an envelope as stored by an older version of the producer and the event it must decode into now
+type ReplayCase[E myevents.Event] struct {
+	Fixture  string
+	Expected E
+}
+
+// ReplayFixtures dispatches every stored envelope to a handler of the current version of E and verifies the
+// decoded event. Add a fixture of the previous version when the version of an event is bumped.
+func ReplayFixtures[E myevents.Event](t *testing.T, cases ...ReplayCase[E]) {
+	t.Helper()
+
+	for _, tc := range cases {
+		t.Run(tc.Fixture, func(t *testing.T) {
+			data, err := os.ReadFile(tc.Fixture)
+			if err != nil {
+				t.Fatalf("Error reading fixture: %s", err)
+			}
+
+			envelope := myevents.EventEnvelope{}
+			err = json.Unmarshal(data, &envelope)
+			if err != nil {
+				t.Fatalf("Error decoding fixture: %s", err)
+			}
+
+			received := []E{}
+			d := myevents.NewDispatcher("replay")
+			myevents.Register(d, func(c context.Context, topic string, event E) error {
+				received = append(received, event)
+				return nil
+			})
+
+			err = d.Dispatch(context.TODO(), envelope)
+			if err != nil {
+				t.Fatalf("Error replaying fixture: %s", err)
+			}
+			if !reflect.DeepEqual([]E{tc.Expected}, received) {
+				t.Errorf("Expected %+v, got %+v", []E{tc.Expected}, received)
+			}
+		})
+	}
+}
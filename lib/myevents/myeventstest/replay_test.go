@@ -0,0 +1,48 @@
+package myeventstest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+)
+
+// orderPlaced is at version 2: Currency was added, defaulting to EUR
+type orderPlaced struct {
+	UID           string
+	AmountInCents int64
+	Currency      string
+}
+
+func (e orderPlaced) GetEventTypeName() string {
+	return "order.placed"
+}
+
+func (e orderPlaced) GetAggregateName() string {
+	return e.UID
+}
+
+func (e orderPlaced) GetEventVersion() int {
+	return 2
+}
+
+func init() {
+	myevents.RegisterUpcaster("order.placed", 1, func(payload []byte) ([]byte, error) {
+		v1 := map[string]interface{}{}
+		err := json.Unmarshal(payload, &v1)
+		if err != nil {
+			return nil, err
+		}
+		v1["Currency"] = "EUR"
+		return json.Marshal(v1)
+	})
+}
+
+func TestReplayFixtures(t *testing.T) {
+	expected := orderPlaced{UID: "123", AmountInCents: 100, Currency: "EUR"}
+
+	ReplayFixtures(t,
+		ReplayCase[orderPlaced]{Fixture: "testdata/order.placed.v1.json", Expected: expected},
+		ReplayCase[orderPlaced]{Fixture: "testdata/order.placed.v2.json", Expected: expected},
+	)
+}
@@ -0,0 +1,52 @@
+package myevents
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// EventType describes an event that is published on a topic
+type EventType struct {
+	Topic         string
+	EventTypeName string
+	EventVersion  int
+	PayloadType   reflect.Type
+}
+
+var (
+	eventTypesMutex sync.RWMutex
+	eventTypes      = map[string]EventType{}
+)
+
+// RegisterEventType announces that events of type E are published on the given topic.
+// Typically called from the init() of the package that defines the event.
+func RegisterEventType[E Event](topic string) {
+	var zero E
+
+	eventTypesMutex.Lock()
+	defer eventTypesMutex.Unlock()
+
+	eventTypes[zero.GetEventTypeName()] = EventType{
+		Topic:         topic,
+		EventTypeName: zero.GetEventTypeName(),
+		EventVersion:  zero.GetEventVersion(),
+		PayloadType:   reflect.TypeOf(zero),
+	}
+}
+
+// RegisteredEventTypes returns all registered event types ordered by name
+func RegisteredEventTypes() []EventType {
+	eventTypesMutex.RLock()
+	defer eventTypesMutex.RUnlock()
+
+	result := make([]EventType, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		result = append(result, eventType)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].EventTypeName < result[j].EventTypeName
+	})
+
+	return result
+}
@@ -0,0 +1,17 @@
+package myevents
+
+import "context"
+
+type ctxReplayKey struct{}
+
+// WithReplay marks the context as belonging to the replay of a stored event
+func WithReplay(c context.Context) context.Context {
+	return context.WithValue(c, ctxReplayKey{}, true)
+}
+
+// IsReplay tells handlers that the event is replayed: projections should be recomputed,
+// but side effects such as publishing new events should be skipped.
+func IsReplay(c context.Context) bool {
+	replay, ok := c.Value(ctxReplayKey{}).(bool)
+	return ok && replay
+}
@@ -0,0 +1,61 @@
+package myevents
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+)
+
+// Upcaster transforms the payload of an event from one version to the next
+type Upcaster func(payload []byte) ([]byte, error)
+
+var (
+	upcastersMutex sync.RWMutex
+	upcasters      = map[string]map[int]Upcaster{}
+)
+
+// RegisterUpcaster registers the transformation of the payload of an event from fromVersion to fromVersion+1.
+// Typically called from the init() of the package that defines the event.
+func RegisterUpcaster(eventTypeName string, fromVersion int, upcaster Upcaster) {
+	upcastersMutex.Lock()
+	defer upcastersMutex.Unlock()
+
+	_, found := upcasters[eventTypeName]
+	if !found {
+		upcasters[eventTypeName] = map[int]Upcaster{}
+	}
+	upcasters[eventTypeName][fromVersion] = upcaster
+}
+
+// upcast returns the payload of the envelope transformed to the requested version
+func upcast(envelope EventEnvelope, toVersion int) ([]byte, error) {
+	payload := []byte(envelope.EventPayload)
+	version := envelope.GetEventVersion()
+
+	if version > toVersion {
+		// Consumer is older than the producer: retry later, hopefully after the consumer has been upgraded
+		return nil, fmt.Errorf("event %s has version %d, but consumer only supports up to version %d",
+			envelope.EventTypeName, version, toVersion)
+	}
+
+	upcastersMutex.RLock()
+	defer upcastersMutex.RUnlock()
+
+	for ; version < toVersion; version++ {
+		upcaster, found := upcasters[envelope.EventTypeName][version]
+		if !found {
+			return nil, myerrors.NewNotImplementedError(fmt.Errorf("no upcaster for event %s from version %d to %d",
+				envelope.EventTypeName, version, version+1))
+		}
+
+		var err error
+		payload, err = upcaster(payload)
+		if err != nil {
+			return nil, myerrors.NewInvalidInputError(fmt.Errorf("error upcasting event %s from version %d to %d: %s",
+				envelope.EventTypeName, version, version+1, err))
+		}
+	}
+
+	return payload, nil
+}
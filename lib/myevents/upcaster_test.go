@@ -0,0 +1,122 @@
+package myevents
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// personRegistered is at version 3:
+// - v1 -> v2: Name was split into FirstName and LastName
+// - v2 -> v3: Country was added, defaulting to NL
+type personRegistered struct {
+	UID       string
+	FirstName string
+	LastName  string
+	Country   string
+}
+
+func (e personRegistered) GetEventTypeName() string {
+	return "person.registered"
+}
+
+func (e personRegistered) GetAggregateName() string {
+	return e.UID
+}
+
+func (e personRegistered) GetEventVersion() int {
+	return 3
+}
+
+func init() {
+	RegisterUpcaster("person.registered", 1, func(payload []byte) ([]byte, error) {
+		v1 := struct {
+			UID  string
+			Name string
+		}{}
+		err := json.Unmarshal(payload, &v1)
+		if err != nil {
+			return nil, err
+		}
+		firstName, lastName, _ := strings.Cut(v1.Name, " ")
+		return json.Marshal(map[string]string{
+			"UID":       v1.UID,
+			"FirstName": firstName,
+			"LastName":  lastName,
+		})
+	})
+	RegisterUpcaster("person.registered", 2, func(payload []byte) ([]byte, error) {
+		v2 := map[string]string{}
+		err := json.Unmarshal(payload, &v2)
+		if err != nil {
+			return nil, err
+		}
+		v2["Country"] = "NL"
+		return json.Marshal(v2)
+	})
+}
+
+func TestUpcasting(t *testing.T) {
+	c := context.TODO()
+
+	expected := personRegistered{
+		UID:       "123",
+		FirstName: "Marc",
+		LastName:  "Grol",
+		Country:   "NL",
+	}
+
+	for _, fixture := range []string{
+		"testdata/person.registered.v1.json",
+		"testdata/person.registered.v2.json",
+		"testdata/person.registered.v3.json",
+	} {
+		t.Run("Replay "+fixture, func(t *testing.T) {
+			envelope := readEnvelope(t, fixture)
+
+			received := []personRegistered{}
+			d := NewDispatcher("test")
+			Register(d, func(c context.Context, topic string, event personRegistered) error {
+				received = append(received, event)
+				return nil
+			})
+
+			err := d.Dispatch(c, envelope)
+			assert.NoError(t, err)
+			assert.Equal(t, []personRegistered{expected}, received)
+		})
+	}
+
+	t.Run("Newer version than consumer supports", func(t *testing.T) {
+		envelope := readEnvelope(t, "testdata/person.registered.v3.json")
+		envelope.EventVersion = 4
+
+		d := NewDispatcher("test")
+		Register(d, func(c context.Context, topic string, event personRegistered) error {
+			return nil
+		})
+
+		err := d.Dispatch(c, envelope)
+		assert.Error(t, err)
+	})
+
+	t.Run("Missing upcaster", func(t *testing.T) {
+		_, err := upcast(EventEnvelope{EventTypeName: "unknown.event", EventVersion: 1}, 2)
+		assert.Error(t, err)
+	})
+}
+
+func readEnvelope(t *testing.T, filename string) EventEnvelope {
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+
+	envelope := EventEnvelope{}
+	err = json.Unmarshal(data, &envelope)
+	assert.NoError(t, err)
+
+	return envelope
+}
@@ -0,0 +1,37 @@
+package myeventstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+)
+
+// StoredEvent is an entry in the event log of an aggregate
+type StoredEvent struct {
+	UID          string
+	AggregateUID string
+	Sequence     int64
+	Envelope     myevents.EventEnvelope
+}
+
+// AggregateHead keeps track of the last sequence used within the event log of an aggregate
+type AggregateHead struct {
+	UID          string
+	LastSequence int64
+}
+
+// Criteria selects events across aggregates: empty fields do not restrict the selection
+type Criteria struct {
+	Topic         string
+	EventTypeName string
+	From          time.Time
+	Until         time.Time
+}
+
+//go:generate mockgen -source=api.go -package myeventstore -destination eventstore_mock.go EventStore
+type EventStore interface {
+	Append(c context.Context, envelope myevents.EventEnvelope) error
+	ListByAggregate(c context.Context, aggregateUID string) ([]StoredEvent, error)
+	Search(c context.Context, criteria Criteria) ([]StoredEvent, error)
+}
@@ -0,0 +1,116 @@
+package myeventstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gorilla/mux"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/mylog"
+	"github.com/MarcGrol/shopbackend/lib/mystore"
+)
+
+type eventStore struct {
+	events mystore.Store[StoredEvent]
+	heads  mystore.Store[AggregateHead]
+	logger mylog.Logger
+}
+
+func New(c context.Context) (*eventStore, func(), error) {
+	events, eventsCleanup, err := mystore.New[StoredEvent](c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	heads, headsCleanup, err := mystore.New[AggregateHead](c)
+	if err != nil {
+		eventsCleanup()
+		return nil, nil, err
+	}
+
+	return &eventStore{
+		events: events,
+		heads:  heads,
+		logger: mylog.New("eventstore"),
+	}, func() {
+		headsCleanup()
+		eventsCleanup()
+	}, nil
+}
+
+func (s *eventStore) RegisterEndpoints(c context.Context, router *mux.Router) {
+	router.HandleFunc("/api/events/{aggregateUID}", s.listByAggregate()).Methods("GET")
+}
+
+// Append adds the envelope to the event log of its aggregate. Appending the same envelope twice has no effect.
+// When called within a transaction, the envelope is appended as part of that transaction.
+func (s *eventStore) Append(c context.Context, envelope myevents.EventEnvelope) error {
+	return s.events.RunInTransaction(c, func(c context.Context) error {
+		_, exists, err := s.events.Get(c, envelope.UID)
+		if err != nil {
+			return fmt.Errorf("error fetching event %s: %w", envelope.UID, err)
+		}
+		if exists {
+			return nil
+		}
+
+		head, _, err := s.heads.Get(c, envelope.AggregateUID)
+		if err != nil {
+			return fmt.Errorf("error fetching head of aggregate %s: %w", envelope.AggregateUID, err)
+		}
+		head.UID = envelope.AggregateUID
+		head.LastSequence++
+
+		err = s.events.Put(c, envelope.UID, StoredEvent{
+			UID:          envelope.UID,
+			AggregateUID: envelope.AggregateUID,
+			Sequence:     head.LastSequence,
+			Envelope:     envelope,
+		})
+		if err != nil {
+			return fmt.Errorf("error storing event %s: %w", envelope.UID, err)
+		}
+
+		err = s.heads.Put(c, head.UID, head)
+		if err != nil {
+			return fmt.Errorf("error storing head of aggregate %s: %w", envelope.AggregateUID, err)
+		}
+
+		return nil
+	})
+}
+
+// ListByAggregate returns the event log of an aggregate in order of sequence
+func (s *eventStore) ListByAggregate(c context.Context, aggregateUID string) ([]StoredEvent, error) {
+	events, err := s.events.Query(c, []mystore.Filter{{Field: "AggregateUID", Compare: "=", Value: aggregateUID}}, "Sequence")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching events of aggregate %s: %w", aggregateUID, err)
+	}
+
+	return events, nil
+}
+
+// Search returns the events that match the criteria in order of creation
+func (s *eventStore) Search(c context.Context, criteria Criteria) ([]StoredEvent, error) {
+	filters := []mystore.Filter{}
+	if criteria.Topic != "" {
+		filters = append(filters, mystore.Filter{Field: "Envelope.Topic", Compare: "=", Value: criteria.Topic})
+	}
+	if criteria.EventTypeName != "" {
+		filters = append(filters, mystore.Filter{Field: "Envelope.EventTypeName", Compare: "=", Value: criteria.EventTypeName})
+	}
+	if !criteria.From.IsZero() {
+		filters = append(filters, mystore.Filter{Field: "Envelope.CreatedAt", Compare: ">=", Value: criteria.From})
+	}
+	if !criteria.Until.IsZero() {
+		filters = append(filters, mystore.Filter{Field: "Envelope.CreatedAt", Compare: "<", Value: criteria.Until})
+	}
+
+	events, err := s.events.Query(c, filters, "Envelope.CreatedAt")
+	if err != nil {
+		return nil, fmt.Errorf("error searching events: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api.go -package myeventstore -destination eventstore_mock.go EventStore
+//
+
+// Package myeventstore is a generated GoMock package.
+package myeventstore
+
+import (
+	context "context"
+	reflect "reflect"
+
+	myevents "github.com/MarcGrol/shopbackend/lib/myevents"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEventStore is a mock of EventStore interface.
+type MockEventStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockEventStoreMockRecorder is the mock recorder for MockEventStore.
+type MockEventStoreMockRecorder struct {
+	mock *MockEventStore
+}
+
+// NewMockEventStore creates a new mock instance.
+func NewMockEventStore(ctrl *gomock.Controller) *MockEventStore {
+	mock := &MockEventStore{ctrl: ctrl}
+	mock.recorder = &MockEventStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventStore) EXPECT() *MockEventStoreMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockEventStore) Append(c context.Context, envelope myevents.EventEnvelope) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", c, envelope)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockEventStoreMockRecorder) Append(c, envelope any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockEventStore)(nil).Append), c, envelope)
+}
+
+// ListByAggregate mocks base method.
+func (m *MockEventStore) ListByAggregate(c context.Context, aggregateUID string) ([]StoredEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByAggregate", c, aggregateUID)
+	ret0, _ := ret[0].([]StoredEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByAggregate indicates an expected call of ListByAggregate.
+func (mr *MockEventStoreMockRecorder) ListByAggregate(c, aggregateUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByAggregate", reflect.TypeOf((*MockEventStore)(nil).ListByAggregate), c, aggregateUID)
+}
+
+// Search mocks base method.
+func (m *MockEventStore) Search(c context.Context, criteria Criteria) ([]StoredEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", c, criteria)
+	ret0, _ := ret[0].([]StoredEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockEventStoreMockRecorder) Search(c, criteria any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockEventStore)(nil).Search), c, criteria)
+}
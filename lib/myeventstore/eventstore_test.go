@@ -0,0 +1,49 @@
+package myeventstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+)
+
+func TestEventStore(t *testing.T) {
+	c := context.TODO()
+	s, cleanup, err := New(c)
+	assert.NoError(t, err)
+	defer cleanup()
+
+	t.Run("Append assigns sequence per aggregate", func(t *testing.T) {
+		for _, envelope := range []myevents.EventEnvelope{
+			{UID: "1", AggregateUID: "basket_1", EventTypeName: "basket.created"},
+			{UID: "2", AggregateUID: "basket_2", EventTypeName: "basket.created"},
+			{UID: "3", AggregateUID: "basket_1", EventTypeName: "checkout.started"},
+			{UID: "4", AggregateUID: "basket_1", EventTypeName: "checkout.completed"},
+		} {
+			err := s.Append(c, envelope)
+			assert.NoError(t, err)
+		}
+
+		events, err := s.ListByAggregate(c, "basket_1")
+		assert.NoError(t, err)
+		assert.Len(t, events, 3)
+		assert.Equal(t, []int64{1, 2, 3}, []int64{events[0].Sequence, events[1].Sequence, events[2].Sequence})
+		assert.Equal(t, "checkout.completed", events[2].Envelope.EventTypeName)
+
+		events, err = s.ListByAggregate(c, "basket_2")
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, int64(1), events[0].Sequence)
+	})
+
+	t.Run("Append same envelope twice", func(t *testing.T) {
+		err := s.Append(c, myevents.EventEnvelope{UID: "4", AggregateUID: "basket_1", EventTypeName: "checkout.completed"})
+		assert.NoError(t, err)
+
+		events, err := s.ListByAggregate(c, "basket_1")
+		assert.NoError(t, err)
+		assert.Len(t, events, 3)
+	})
+}
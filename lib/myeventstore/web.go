@@ -0,0 +1,36 @@
+package myeventstore
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myhttp"
+)
+
+type AggregateEvents struct {
+	AggregateUID string
+	Events       []StoredEvent
+}
+
+func (s *eventStore) listByAggregate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(s.logger)
+
+		aggregateUID := mux.Vars(r)["aggregateUID"]
+
+		events, err := s.ListByAggregate(c, aggregateUID)
+		if err != nil {
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
+			return
+		}
+
+		responseWriter.Write(c, w, http.StatusOK, AggregateEvents{
+			AggregateUID: aggregateUID,
+			Events:       events,
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package myhealth
+
+import "context"
+
+// Checker is implemented by the libraries that depend on infrastructure, like the store, pubsub, queue and vault
+type Checker interface {
+	// HealthCheck returns an error when the dependency can not be used
+	HealthCheck(c context.Context) error
+}
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusFailing  Status = "failing"
+	StatusDraining Status = "draining"
+)
+
+// Report is the outcome of a readiness check, with the status per dependency
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Status `json:"checks,omitempty"`
+}
@@ -0,0 +1,98 @@
+package myhealth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MarcGrol/shopbackend/lib/mylog"
+)
+
+const defaultCheckTimeout = 2 * time.Second
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Health decides whether this instance is ready to receive traffic
+type Health struct {
+	sync.Mutex
+	checkers     []namedChecker
+	draining     bool
+	checkTimeout time.Duration
+	logger       mylog.Logger
+}
+
+func New() *Health {
+	return &Health{
+		checkers:     []namedChecker{},
+		checkTimeout: defaultCheckTimeout,
+		logger:       mylog.New("health"),
+	}
+}
+
+// Register adds a dependency that must be healthy before this instance is ready
+func (h *Health) Register(name string, checker Checker) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.checkers = append(h.checkers, namedChecker{name: name, checker: checker})
+}
+
+// Drain marks the instance as not ready, so the load-balancer stops sending new requests during shutdown
+func (h *Health) Drain() {
+	h.Lock()
+	defer h.Unlock()
+
+	h.draining = true
+}
+
+func (h *Health) isDraining() bool {
+	h.Lock()
+	defer h.Unlock()
+
+	return h.draining
+}
+
+// CheckReadiness runs all checks in parallel: a slow dependency is reported as failing after the check-timeout
+func (h *Health) CheckReadiness(c context.Context) Report {
+	if h.isDraining() {
+		return Report{Status: StatusDraining}
+	}
+
+	h.Lock()
+	checkers := append([]namedChecker{}, h.checkers...)
+	h.Unlock()
+
+	c, cancel := context.WithTimeout(c, h.checkTimeout)
+	defer cancel()
+
+	errs := make([]error, len(checkers))
+	wg := sync.WaitGroup{}
+	for idx, nc := range checkers {
+		wg.Add(1)
+		go func(idx int, checker Checker) {
+			defer wg.Done()
+			errs[idx] = checker.HealthCheck(c)
+		}(idx, nc.checker)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: map[string]Status{},
+	}
+	for idx, nc := range checkers {
+		if errs[idx] != nil {
+			// the error itself is only logged: the readiness endpoint is public
+			h.logger.Log(c, nc.name, mylog.SeverityWarn, "Dependency %s is not healthy: %s", nc.name, errs[idx])
+			report.Status = StatusFailing
+			report.Checks[nc.name] = StatusFailing
+			continue
+		}
+		report.Checks[nc.name] = StatusOK
+	}
+
+	return report
+}
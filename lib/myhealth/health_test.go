@@ -0,0 +1,123 @@
+package myhealth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type checkerFunc func(c context.Context) error
+
+func (f checkerFunc) HealthCheck(c context.Context) error {
+	return f(c)
+}
+
+func healthy() Checker {
+	return checkerFunc(func(c context.Context) error { return nil })
+}
+
+func failing() Checker {
+	return checkerFunc(func(c context.Context) error { return fmt.Errorf("connection refused") })
+}
+
+func TestReadiness(t *testing.T) {
+	c := context.TODO()
+
+	t.Run("All dependencies healthy", func(t *testing.T) {
+		// setup
+		sut := New()
+		sut.Register("store", healthy())
+		sut.Register("queue", healthy())
+
+		// when
+		report := sut.CheckReadiness(c)
+
+		// then
+		assert.Equal(t, Report{Status: StatusOK, Checks: map[string]Status{"store": StatusOK, "queue": StatusOK}}, report)
+	})
+
+	t.Run("Failing dependency", func(t *testing.T) {
+		// setup
+		sut := New()
+		sut.Register("store", healthy())
+		sut.Register("pubsub", failing())
+
+		// when
+		report := sut.CheckReadiness(c)
+
+		// then
+		assert.Equal(t, Report{Status: StatusFailing, Checks: map[string]Status{"store": StatusOK, "pubsub": StatusFailing}}, report)
+	})
+
+	t.Run("Slow dependency", func(t *testing.T) {
+		// setup
+		sut := New()
+		sut.checkTimeout = 10 * time.Millisecond
+		sut.Register("vault", checkerFunc(func(c context.Context) error {
+			<-c.Done()
+			return c.Err()
+		}))
+
+		// when
+		report := sut.CheckReadiness(c)
+
+		// then
+		assert.Equal(t, StatusFailing, report.Status)
+	})
+
+	t.Run("Draining", func(t *testing.T) {
+		// setup
+		sut := New()
+		sut.Register("store", healthy())
+
+		// when
+		sut.Drain()
+
+		// then
+		assert.Equal(t, Report{Status: StatusDraining}, sut.CheckReadiness(c))
+	})
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	c := context.TODO()
+
+	testCases := []struct {
+		name       string
+		path       string
+		checker    Checker
+		httpStatus int
+		status     Status
+	}{
+		{name: "Liveness ignores dependencies", path: "/healthz", checker: failing(), httpStatus: 200, status: StatusOK},
+		{name: "Ready", path: "/readyz", checker: healthy(), httpStatus: 200, status: StatusOK},
+		{name: "Not ready", path: "/readyz", checker: failing(), httpStatus: 503, status: StatusFailing},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// setup
+			router := mux.NewRouter()
+			sut := New()
+			sut.Register("store", tc.checker)
+			sut.RegisterEndpoints(c, router)
+
+			// when
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			// then
+			assert.Equal(t, tc.httpStatus, response.Code)
+			report := Report{}
+			err := json.Unmarshal(response.Body.Bytes(), &report)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.status, report.Status)
+			assert.NotContains(t, response.Body.String(), "connection refused")
+		})
+	}
+}
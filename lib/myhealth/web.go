@@ -0,0 +1,43 @@
+package myhealth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myhttp"
+)
+
+func (h *Health) RegisterEndpoints(c context.Context, router *mux.Router) {
+	// Liveness: the process is able to serve requests
+	router.HandleFunc("/healthz", h.liveness()).Methods("GET")
+
+	// Readiness: all dependencies can be reached and the instance is not shutting down
+	router.HandleFunc("/readyz", h.readiness()).Methods("GET")
+}
+
+func (h *Health) liveness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(h.logger)
+
+		responseWriter.Write(c, w, http.StatusOK, Report{Status: StatusOK})
+	}
+}
+
+func (h *Health) readiness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(h.logger)
+
+		report := h.CheckReadiness(c)
+		if report.Status != StatusOK {
+			responseWriter.Write(c, w, http.StatusServiceUnavailable, report)
+			return
+		}
+
+		responseWriter.Write(c, w, http.StatusOK, report)
+	}
+}
@@ -0,0 +1,137 @@
+package myhttp
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/mylog"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+	"github.com/MarcGrol/shopbackend/lib/myuuid"
+)
+
+// Middleware decorates a handler with behaviour that applies to every request
+type Middleware func(next http.Handler) http.Handler
+
+// Chain wraps handler in the middlewares, the first middleware being the outermost one
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for idx := len(middlewares) - 1; idx >= 0; idx-- {
+		handler = middlewares[idx](handler)
+	}
+	return handler
+}
+
+// Standard is the middleware stack that every request passes through:
+// request-id first, so the access log and the recovered error can refer to it.
+func Standard(logger mylog.Logger, nower mytime.Nower, uuider myuuid.UUIDer) []Middleware {
+	return []Middleware{
+		RequestContext(uuider),
+		AccessLog(logger, nower),
+		Recovery(logger),
+	}
+}
+
+// maxRequestIDLength prevents a caller from flooding our logs via the request-id header
+const maxRequestIDLength = 128
+
+// RequestContext puts the trace and the request-id in the request context.
+// The request-id of the caller is continued when present, otherwise a new one is generated.
+// The request-id is returned in the response header.
+func RequestContext(uuider myuuid.UUIDer) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(mycontext.RequestIDHeader)
+			if requestID == "" || len(requestID) > maxRequestIDLength {
+				requestID = uuider.Create()
+			}
+
+			c := mycontext.WithRequestID(r.Context(), requestID)
+			c = mycontext.WithTrace(c, mycontext.TraceFromHTTPRequest(r))
+
+			w.Header().Set(mycontext.RequestIDHeader, requestID)
+			next.ServeHTTP(w, r.WithContext(c))
+		})
+	}
+}
+
+// AccessLog logs every request with its http-status and latency
+func AccessLog(logger mylog.Logger, nower mytime.Nower) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := nower.Now()
+			recorder := &statusRecorder{ResponseWriter: w}
+
+			next.ServeHTTP(recorder, r)
+
+			httpStatus := recorder.status()
+			severity := mylog.SeverityInfo
+			if httpStatus >= http.StatusInternalServerError {
+				severity = mylog.SeverityError
+			}
+			logger.Log(r.Context(), "", severity, "%s %s: http-status:%d, bytes:%d, latency:%s",
+				r.Method, r.URL.Path, httpStatus, recorder.bytesWritten, nower.Now().Sub(started))
+		})
+	}
+}
+
+// Recovery turns a panic in a handler into a 500 problem response, instead of a dropped connection
+func Recovery(logger mylog.Logger) Middleware {
+	writer := NewWriter(logger)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					// deliberate abort: let the http-server drop the connection
+					panic(recovered)
+				}
+
+				c := mycontext.ContextFromHTTPRequest(r)
+				logger.Log(c, "", mylog.SeverityError, "Panic handling %s %s: %v\n%s", r.Method, r.URL.Path, recovered, debug.Stack())
+				writer.WriteError(c, w, myerrors.NewInternalError(fmt.Errorf("panic: %v", recovered)))
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	httpStatus   int
+	bytesWritten int
+}
+
+func (r *statusRecorder) WriteHeader(httpStatus int) {
+	if r.httpStatus == 0 {
+		r.httpStatus = httpStatus
+	}
+	r.ResponseWriter.WriteHeader(httpStatus)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.httpStatus == 0 {
+		r.httpStatus = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(data)
+	r.bytesWritten += n
+	return n, err
+}
+
+func (r *statusRecorder) status() int {
+	if r.httpStatus == 0 {
+		return http.StatusOK
+	}
+	return r.httpStatus
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
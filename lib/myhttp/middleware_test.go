@@ -0,0 +1,126 @@
+package myhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/mylog"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+	"github.com/MarcGrol/shopbackend/lib/myuuid"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Run("Request-id is generated and available in context", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		uuider := myuuid.NewMockUUIDer(ctrl)
+		uuider.EXPECT().Create().Return("req-123")
+		var requestID string
+		sut := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID = mycontext.RequestIDFromContext(mycontext.ContextFromHTTPRequest(r))
+		}), RequestContext(uuider))
+
+		// when
+		response := httptest.NewRecorder()
+		sut.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/api/basket", nil))
+
+		// then
+		assert.Equal(t, "req-123", requestID)
+		assert.Equal(t, "req-123", response.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("Request-id of caller is continued", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		var requestID string
+		var trace string
+		sut := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := mycontext.ContextFromHTTPRequest(r)
+			requestID = mycontext.RequestIDFromContext(c)
+			trace = mycontext.TraceFromContext(c)
+		}), RequestContext(myuuid.NewMockUUIDer(ctrl)))
+
+		// when
+		request := httptest.NewRequest(http.MethodGet, "/api/basket", nil)
+		request.Header.Set("X-Request-ID", "caller-456")
+		request.Header.Set("X-Cloud-Trace-Context", "abc/1;o=1")
+		sut.ServeHTTP(httptest.NewRecorder(), request)
+
+		// then
+		assert.Equal(t, "caller-456", requestID)
+		assert.Contains(t, trace, "/traces/abc")
+	})
+
+	t.Run("Context is cancelled with the request", func(t *testing.T) {
+		// setup
+		var c context.Context
+		sut := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c = mycontext.ContextFromHTTPRequest(r)
+		})
+
+		// when
+		requestContext, cancel := context.WithCancel(context.Background())
+		sut.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/basket", nil).WithContext(requestContext))
+		cancel()
+
+		// then
+		assert.Error(t, c.Err())
+	})
+
+	t.Run("Panic becomes problem response", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		uuider := myuuid.NewMockUUIDer(ctrl)
+		uuider.EXPECT().Create().Return("req-123")
+		sut := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("nil pointer dereference")
+		}), Standard(mylog.New("test"), mytime.RealNower{}, uuider)...)
+
+		// when
+		response := httptest.NewRecorder()
+		sut.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/api/basket", nil))
+
+		// then
+		assert.Equal(t, 500, response.Code)
+		assert.Equal(t, "application/problem+json", response.Header().Get("Content-Type"))
+		problem := myerrors.Problem{}
+		err := json.Unmarshal(response.Body.Bytes(), &problem)
+		assert.NoError(t, err)
+		assert.Equal(t, "/problems/internal", problem.Type)
+		assert.Equal(t, "req-123", problem.RequestID)
+		assert.NotContains(t, response.Body.String(), "nil pointer")
+	})
+
+	t.Run("Access log records status and latency", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		logger := mylog.NewMockLogger(ctrl)
+		nower := mytime.NewMockNower(ctrl)
+		sut := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}), AccessLog(logger, nower))
+
+		// given
+		gomock.InOrder(
+			nower.EXPECT().Now().Return(mytime.ExampleTime),
+			nower.EXPECT().Now().Return(mytime.ExampleTime.Add(25*time.Millisecond)),
+		)
+		logger.EXPECT().Log(gomock.Any(), "", mylog.SeverityInfo, gomock.Any(), http.MethodGet, "/api/basket/123", http.StatusNotFound, 0, 25*time.Millisecond)
+
+		// when
+		response := httptest.NewRecorder()
+		sut.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/api/basket/123", nil))
+
+		// then
+		assert.Equal(t, 404, response.Code)
+	})
+}
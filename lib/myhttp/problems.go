@@ -0,0 +1,22 @@
+package myhttp
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/mylog"
+)
+
+// RegisterProblemCatalogue exposes the problem types that the services can return, per service
+func RegisterProblemCatalogue(router *mux.Router) {
+	responseWriter := NewWriter(mylog.New("problems"))
+
+	router.HandleFunc("/api/problems", func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+
+		responseWriter.Write(c, w, http.StatusOK, myerrors.ProblemCatalogue())
+	}).Methods("GET")
+}
@@ -0,0 +1,32 @@
+package myhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+)
+
+func TestProblemCatalogue(t *testing.T) {
+	// setup
+	router := mux.NewRouter()
+	RegisterProblemCatalogue(router)
+
+	// when
+	request, err := http.NewRequest(http.MethodGet, "/api/problems", nil)
+	assert.NoError(t, err)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	// then
+	assert.Equal(t, 200, response.Code)
+	catalogue := map[string][]myerrors.ProblemType{}
+	err = json.Unmarshal(response.Body.Bytes(), &catalogue)
+	assert.NoError(t, err)
+	assert.Contains(t, catalogue["common"], myerrors.ProblemNotFound)
+}
@@ -6,20 +6,17 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
 	"github.com/MarcGrol/shopbackend/lib/mylog"
 )
 
 type ResponseWriter interface {
-	WriteError(c context.Context, w http.ResponseWriter, errorCode int, err error)
+	// WriteError renders err as application/problem+json (RFC 7807)
+	WriteError(c context.Context, w http.ResponseWriter, err error)
 	Write(c context.Context, w http.ResponseWriter, httpStatus int, resp interface{})
 }
 
-type errorResponse struct {
-	ErrorCode int
-	Message   string
-}
-
 type SuccessResponse struct {
 	Message string
 }
@@ -34,24 +31,25 @@ type responseWriter struct {
 	logger mylog.Logger
 }
 
-func (rw responseWriter) WriteError(c context.Context, w http.ResponseWriter, errorCode int, err error) {
-	httpStatus := myerrors.GetHTTPStatus(err)
-	rw.logger.Log(c, "", mylog.SeverityWarn, "Error response: http-status:%d, error-code:%d, error-msg:%s", httpStatus, errorCode, err)
-	rw.write(w, httpStatus, errorResponse{
-		ErrorCode: errorCode,
-		Message:   err.Error(),
-	})
+func (rw responseWriter) WriteError(c context.Context, w http.ResponseWriter, err error) {
+	problem := myerrors.ToProblem(err)
+	problem.TraceID = mycontext.TraceFromContext(c)
+	problem.RequestID = mycontext.RequestIDFromContext(c)
+
+	// the full error is only logged: the trace-id and request-id link the response to it
+	rw.logger.Log(c, "", mylog.SeverityWarn, "Error response: http-status:%d, type:%s, error-msg:%s", problem.Status, problem.Type, err)
+	rw.write(w, problem.Status, "application/problem+json", problem)
 }
 
 func (rw responseWriter) Write(c context.Context, w http.ResponseWriter, httpStatus int, resp interface{}) {
 	rw.logger.Log(c, "", mylog.SeverityInfo, "Success response: http-status:%d", httpStatus)
-	rw.write(w, httpStatus, resp)
+	rw.write(w, httpStatus, "application/json", resp)
 }
 
-func (rw responseWriter) write(w http.ResponseWriter, httpStatus int, resp interface{}) {
+func (rw responseWriter) write(w http.ResponseWriter, httpStatus int, contentType string, resp interface{}) {
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(httpStatus)
 	encoder := json.NewEncoder(w)
-	w.Header().Set("Content-Type", "application/json")
 	encoder.SetIndent("", "\t")
 	err := encoder.Encode(resp)
 	if err != nil {
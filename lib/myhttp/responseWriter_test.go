@@ -0,0 +1,47 @@
+package myhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/mylog"
+)
+
+func TestWriteError(t *testing.T) {
+	c := mycontext.WithTrace(context.TODO(), "trace-123")
+	sut := NewWriter(mylog.New("test"))
+
+	t.Run("Problem json", func(t *testing.T) {
+		// when
+		response := httptest.NewRecorder()
+		sut.WriteError(c, response, myerrors.NewValidationError(myerrors.FieldError{Field: "basketUID", Message: "is mandatory"}))
+
+		// then
+		assert.Equal(t, 400, response.Code)
+		assert.Equal(t, "application/problem+json", response.Header().Get("Content-Type"))
+		problem := myerrors.Problem{}
+		err := json.Unmarshal(response.Body.Bytes(), &problem)
+		assert.NoError(t, err)
+		assert.Equal(t, "/problems/validation-failed", problem.Type)
+		assert.Equal(t, "trace-123", problem.TraceID)
+		assert.Equal(t, []myerrors.FieldError{{Field: "basketUID", Message: "is mandatory"}}, problem.InvalidParams)
+	})
+
+	t.Run("Internal details are not exposed", func(t *testing.T) {
+		// when
+		response := httptest.NewRecorder()
+		sut.WriteError(c, response, myerrors.NewInternalError(fmt.Errorf("datastore password=secret rejected")))
+
+		// then
+		assert.Equal(t, 500, response.Code)
+		assert.NotContains(t, response.Body.String(), "secret")
+		assert.Contains(t, response.Body.String(), "trace-123")
+	})
+}
@@ -9,5 +9,5 @@ type HTTPSender interface {
 }
 
 func New() HTTPSender {
-	return newJSONHTTPClient()
+	return newJSONHTTPClient(NewClient(DefaultConfig()))
 }
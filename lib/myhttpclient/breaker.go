@@ -0,0 +1,98 @@
+package myhttpclient
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+type breakerState struct {
+	consecutiveFailures int
+	openUntil           time.Time
+	// after the cooldown a single trial request decides whether the circuit closes again
+	trialInProgress bool
+}
+
+// circuitBreakers keeps a circuit per host, so one failing provider does not affect calls to the others
+type circuitBreakers struct {
+	sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	nower     mytime.Nower
+	hosts     map[string]*breakerState
+}
+
+func newCircuitBreakers(threshold int, cooldown time.Duration, nower mytime.Nower) *circuitBreakers {
+	if nower == nil {
+		nower = mytime.RealNower{}
+	}
+	return &circuitBreakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		nower:     nower,
+		hosts:     map[string]*breakerState{},
+	}
+}
+
+func (b *circuitBreakers) allow(host string) error {
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	state, exists := b.hosts[host]
+	if !exists || state.consecutiveFailures < b.threshold {
+		return nil
+	}
+
+	if b.nower.Now().Before(state.openUntil) || state.trialInProgress {
+		return fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+	}
+
+	state.trialInProgress = true
+	return nil
+}
+
+func (b *circuitBreakers) record(host string, success bool) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if success {
+		delete(b.hosts, host)
+		return
+	}
+
+	state, exists := b.hosts[host]
+	if !exists {
+		state = &breakerState{}
+		b.hosts[host] = state
+	}
+	state.consecutiveFailures++
+	state.trialInProgress = false
+	if state.consecutiveFailures >= b.threshold {
+		state.openUntil = b.nower.Now().Add(b.cooldown)
+	}
+}
+
+// release ends a trial without a verdict, so a next request can try again
+func (b *circuitBreakers) release(host string) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	state, exists := b.hosts[host]
+	if exists {
+		state.trialInProgress = false
+	}
+}
@@ -0,0 +1,228 @@
+package myhttpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+// ErrCircuitOpen is returned without calling the remote host, because its recent calls kept failing
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type Config struct {
+	// Timeout applies to a single attempt
+	Timeout     time.Duration
+	MaxAttempts int
+	// BaseBackoff is doubled after every attempt up to MaxBackoff; the actual wait is a random part of it
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// BreakerThreshold is the number of consecutive failures that opens the circuit of a host for BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// Debug dumps requests and responses, with credentials redacted
+	Debug bool
+	// Transport defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	Nower     mytime.Nower
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          5 * time.Second,
+		MaxAttempts:      3,
+		BaseBackoff:      100 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+		Debug:            os.Getenv("HTTP_CLIENT_DEBUG") == "true",
+		Nower:            mytime.RealNower{},
+	}
+}
+
+type Request struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+	// Idempotent allows retrying a request with a non-idempotent method, like a POST with an idempotency-key
+	Idempotent bool
+}
+
+func (r *Request) SetBasicAuth(username string, password string) {
+	httpReq := http.Request{Header: http.Header{}}
+	httpReq.SetBasicAuth(username, password)
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+	r.Header.Set("Authorization", httpReq.Header.Get("Authorization"))
+}
+
+func (r Request) isIdempotent() bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return r.Idempotent
+	}
+}
+
+// Client is the shared base for outbound http-calls: it retries idempotent requests on 429, 5xx and network errors
+// with jittered exponential backoff, and stops calling hosts that keep failing.
+type Client struct {
+	config     Config
+	httpClient *http.Client
+	breakers   *circuitBreakers
+	sleep      func(c context.Context, d time.Duration) error
+}
+
+func NewClient(config Config) *Client {
+	transport := config.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+
+	return &Client{
+		config: config,
+		httpClient: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: transport,
+		},
+		breakers: newCircuitBreakers(config.BreakerThreshold, config.BreakerCooldown, config.Nower),
+		sleep:    sleepWithContext,
+	}
+}
+
+// Do returns the http-status and body of the last attempt; an error is only returned when no response was received
+func (cl *Client) Do(c context.Context, req Request) (int, []byte, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return 0, []byte{}, fmt.Errorf("error parsing url %s: %w", req.URL, err)
+	}
+	host := u.Host
+
+	for attempt := 1; ; attempt++ {
+		err = cl.breakers.allow(host)
+		if err != nil {
+			return 0, []byte{}, fmt.Errorf("error sending %s %s: %w", req.Method, req.URL, err)
+		}
+
+		httpStatus, respPayload, retryAfter, err := cl.send(c, req, attempt)
+		if c.Err() == nil {
+			cl.breakers.record(host, err == nil && httpStatus < http.StatusInternalServerError)
+		} else {
+			// a cancelled or expired caller says nothing about the health of the host
+			cl.breakers.release(host)
+		}
+
+		retryable := err != nil || httpStatus == http.StatusTooManyRequests || httpStatus >= http.StatusInternalServerError
+		if !retryable || !req.isIdempotent() || attempt >= cl.config.MaxAttempts || c.Err() != nil {
+			return httpStatus, respPayload, err
+		}
+		if retryAfter > cl.budget(c) {
+			// the host asks for more patience than we have: let the caller handle the 429
+			return httpStatus, respPayload, err
+		}
+
+		sleepErr := cl.sleep(c, cl.backoff(attempt, retryAfter))
+		if sleepErr != nil {
+			if err == nil {
+				return httpStatus, respPayload, nil
+			}
+			return 0, []byte{}, err
+		}
+	}
+}
+
+func (cl *Client) send(c context.Context, req Request, attempt int) (int, []byte, time.Duration, error) {
+	httpReq, err := http.NewRequestWithContext(c, req.Method, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, []byte{}, 0, fmt.Errorf("error creating http request for %s %s: %w", req.Method, req.URL, err)
+	}
+	for name, values := range req.Header {
+		httpReq.Header[name] = values
+	}
+
+	if cl.config.Debug {
+		log.Printf("HTTP-req:\n%s", dumpRequest(httpReq, req.Body))
+	}
+
+	httpResp, err := cl.httpClient.Do(httpReq)
+	if err != nil {
+		log.Printf("HTTP request: %s %s (attempt %d) -> %s", req.Method, req.URL, attempt, err)
+		return 0, []byte{}, 0, fmt.Errorf("error sending %s %s: %w", req.Method, req.URL, err)
+	}
+	defer httpResp.Body.Close()
+
+	respPayload, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return 0, []byte{}, 0, fmt.Errorf("error reading response %s %s: %w", req.Method, req.URL, err)
+	}
+
+	log.Printf("HTTP request: %s %s (attempt %d) -> %d", req.Method, req.URL, attempt, httpResp.StatusCode)
+
+	if cl.config.Debug {
+		log.Printf("HTTP-resp:\n%s", dumpResponse(httpResp, respPayload))
+	}
+
+	return httpResp.StatusCode, respPayload, parseRetryAfter(httpResp.Header.Get("Retry-After")), nil
+}
+
+// budget is the longest we are willing to wait before a next attempt
+func (cl *Client) budget(c context.Context) time.Duration {
+	budget := cl.config.MaxBackoff
+	if deadline, ok := c.Deadline(); ok {
+		budget = min(budget, time.Until(deadline))
+	}
+	return budget
+}
+
+// backoff uses "full jitter": a random wait up to the exponential backoff, so clients don't retry in lockstep.
+// A Retry-After of the host is followed as is.
+func (cl *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	ceiling := cl.config.BaseBackoff << (attempt - 1)
+	if ceiling <= 0 || ceiling > cl.config.MaxBackoff {
+		ceiling = cl.config.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func sleepWithContext(c context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-c.Done():
+		return c.Err()
+	}
+}
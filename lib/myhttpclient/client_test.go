@@ -0,0 +1,300 @@
+package myhttpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+func newTestClient(config Config) (*Client, *[]time.Duration) {
+	sleeps := []time.Duration{}
+	client := NewClient(config)
+	client.sleep = func(c context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return c.Err()
+	}
+	return client, &sleeps
+}
+
+func respondWith(statuses ...int) (*httptest.Server, *int32) {
+	calls := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idx := atomic.AddInt32(&calls, 1) - 1
+		status := statuses[len(statuses)-1]
+		if int(idx) < len(statuses) {
+			status = statuses[idx]
+		}
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "60")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"status":"done"}`))
+	}))
+	return ts, &calls
+}
+
+func TestRetries(t *testing.T) {
+	c := context.TODO()
+
+	t.Run("Idempotent request is retried on server error", func(t *testing.T) {
+		// setup
+		ts, calls := respondWith(503, 502, 200)
+		defer ts.Close()
+		sut, sleeps := newTestClient(DefaultConfig())
+
+		// when
+		httpStatus, body, err := sut.Do(c, Request{Method: http.MethodGet, URL: ts.URL + "/api/payments/123"})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, 200, httpStatus)
+		assert.Equal(t, `{"status":"done"}`, string(body))
+		assert.Equal(t, int32(3), *calls)
+		assert.Len(t, *sleeps, 2)
+		assert.LessOrEqual(t, (*sleeps)[0], 100*time.Millisecond)
+		assert.LessOrEqual(t, (*sleeps)[1], 200*time.Millisecond)
+	})
+
+	t.Run("Last response is returned when attempts are exhausted", func(t *testing.T) {
+		// setup
+		ts, calls := respondWith(500)
+		defer ts.Close()
+		sut, _ := newTestClient(DefaultConfig())
+
+		// when
+		httpStatus, _, err := sut.Do(c, Request{Method: http.MethodDelete, URL: ts.URL})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, 500, httpStatus)
+		assert.Equal(t, int32(3), *calls)
+	})
+
+	t.Run("Post is not retried", func(t *testing.T) {
+		// setup
+		ts, calls := respondWith(503, 200)
+		defer ts.Close()
+		sut, _ := newTestClient(DefaultConfig())
+
+		// when
+		httpStatus, _, err := sut.Do(c, Request{Method: http.MethodPost, URL: ts.URL, Body: []byte(`{}`)})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, 503, httpStatus)
+		assert.Equal(t, int32(1), *calls)
+	})
+
+	t.Run("Post with idempotency-key is retried", func(t *testing.T) {
+		// setup
+		ts, calls := respondWith(503, 200)
+		defer ts.Close()
+		sut, _ := newTestClient(DefaultConfig())
+
+		// when
+		httpStatus, _, err := sut.Do(c, Request{Method: http.MethodPost, URL: ts.URL, Body: []byte(`{}`), Idempotent: true})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, 200, httpStatus)
+		assert.Equal(t, int32(2), *calls)
+	})
+
+	t.Run("Client errors are not retried", func(t *testing.T) {
+		// setup
+		ts, calls := respondWith(404, 200)
+		defer ts.Close()
+		sut, _ := newTestClient(DefaultConfig())
+
+		// when
+		httpStatus, _, err := sut.Do(c, Request{Method: http.MethodGet, URL: ts.URL})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, 404, httpStatus)
+		assert.Equal(t, int32(1), *calls)
+	})
+
+	t.Run("Retry-After is honoured", func(t *testing.T) {
+		// setup
+		ts, _ := respondWith(429, 200)
+		defer ts.Close()
+		config := DefaultConfig()
+		config.MaxBackoff = 2 * time.Minute
+		sut, sleeps := newTestClient(config)
+
+		// when
+		httpStatus, _, err := sut.Do(c, Request{Method: http.MethodGet, URL: ts.URL})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, 200, httpStatus)
+		assert.Equal(t, []time.Duration{60 * time.Second}, *sleeps)
+	})
+
+	t.Run("Retry-After beyond the max backoff returns the 429", func(t *testing.T) {
+		// setup
+		ts, calls := respondWith(429, 200)
+		defer ts.Close()
+		sut, sleeps := newTestClient(DefaultConfig())
+
+		// when
+		httpStatus, _, err := sut.Do(c, Request{Method: http.MethodGet, URL: ts.URL})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, 429, httpStatus)
+		assert.Equal(t, int32(1), *calls)
+		assert.Empty(t, *sleeps)
+	})
+
+	t.Run("Retry-After beyond the deadline returns the 429", func(t *testing.T) {
+		// setup
+		ts, calls := respondWith(429, 200)
+		defer ts.Close()
+		config := DefaultConfig()
+		config.MaxBackoff = 2 * time.Minute
+		sut, sleeps := newTestClient(config)
+		ctx, cancel := context.WithTimeout(c, 30*time.Second)
+		defer cancel()
+
+		// when
+		httpStatus, _, err := sut.Do(ctx, Request{Method: http.MethodGet, URL: ts.URL})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, 429, httpStatus)
+		assert.Equal(t, int32(1), *calls)
+		assert.Empty(t, *sleeps)
+	})
+
+	t.Run("Request carries the context", func(t *testing.T) {
+		// setup
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer ts.Close()
+		sut, sleeps := newTestClient(DefaultConfig())
+		ctx, cancel := context.WithTimeout(c, 20*time.Millisecond)
+		defer cancel()
+
+		// when
+		_, _, err := sut.Do(ctx, Request{Method: http.MethodGet, URL: ts.URL})
+
+		// then
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Empty(t, *sleeps)
+	})
+
+	t.Run("Headers and body are sent", func(t *testing.T) {
+		// setup
+		var username, password, body string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, _ = r.BasicAuth()
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+		}))
+		defer ts.Close()
+		sut, _ := newTestClient(DefaultConfig())
+		req := Request{Method: http.MethodPost, URL: ts.URL, Body: []byte("grant_type=refresh_token")}
+		req.SetBasicAuth("123", "456")
+
+		// when
+		_, _, err := sut.Do(c, req)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, "123", username)
+		assert.Equal(t, "456", password)
+		assert.Equal(t, "grant_type=refresh_token", body)
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	c := context.TODO()
+
+	// setup
+	ctrl := gomock.NewController(t)
+	nower := mytime.NewMockNower(ctrl)
+	nower.EXPECT().Now().Return(mytime.ExampleTime).AnyTimes()
+	ts, calls := respondWith(500, 500, 200)
+	defer ts.Close()
+	config := DefaultConfig()
+	config.MaxAttempts = 1
+	config.BreakerThreshold = 2
+	config.Nower = nower
+	sut, _ := newTestClient(config)
+
+	// given
+	for i := 0; i < 2; i++ {
+		httpStatus, _, err := sut.Do(c, Request{Method: http.MethodGet, URL: ts.URL})
+		assert.NoError(t, err)
+		assert.Equal(t, 500, httpStatus)
+	}
+
+	// when
+	_, _, err := sut.Do(c, Request{Method: http.MethodGet, URL: ts.URL})
+
+	// then
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, int32(2), *calls)
+
+	t.Run("Cancelled caller does not count as a failure", func(t *testing.T) {
+		// setup
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer slow.Close()
+
+		// given
+		for i := 0; i < 2; i++ {
+			ctx, cancel := context.WithTimeout(c, 10*time.Millisecond)
+			_, _, err := sut.Do(ctx, Request{Method: http.MethodGet, URL: slow.URL})
+			cancel()
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		}
+
+		// then
+		assert.NoError(t, sut.breakers.allow(slow.Listener.Addr().String()))
+	})
+
+	t.Run("Cancelled trial after cooldown does not keep the circuit open", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		later := mytime.NewMockNower(ctrl)
+		later.EXPECT().Now().Return(mytime.ExampleTime.Add(time.Minute)).AnyTimes()
+		sut.breakers.nower = later
+
+		// given
+		ctx, cancel := context.WithCancel(c)
+		cancel()
+
+		// when
+		_, _, err := sut.Do(ctx, Request{Method: http.MethodGet, URL: ts.URL})
+
+		// then
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NoError(t, sut.breakers.allow(ts.Listener.Addr().String()))
+		sut.breakers.release(ts.Listener.Addr().String())
+	})
+
+	t.Run("Trial after cooldown closes the circuit", func(t *testing.T) {
+		// when
+		httpStatus, _, err := sut.Do(c, Request{Method: http.MethodGet, URL: ts.URL})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, 200, httpStatus)
+		assert.NoError(t, sut.breakers.allow(ts.Listener.Addr().String()))
+	})
+}
@@ -0,0 +1,38 @@
+// Package myhttpclienttest offers helpers for tests that talk to remote services via myhttpclient
+package myhttpclienttest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MarcGrol/shopbackend/lib/myhttpclient"
+)
+
+// NewRecorder replays testdata/cassettes/<name>.json and fails the test on any request that is not in there.
+// Run the test with HTTP_RECORD=true to record the cassette against the real service once.
+func NewRecorder(t testing.TB, name string) *myhttpclient.Recorder {
+	t.Helper()
+
+	mode := myhttpclient.RecordModeReplay
+	if os.Getenv("HTTP_RECORD") == "true" {
+		mode = myhttpclient.RecordModeRecord
+	}
+
+	r, err := myhttpclient.NewRecorder(filepath.Join("testdata", "cassettes", name+".json"), mode, nil)
+	if err != nil {
+		t.Fatalf("Error creating recorder: %s", err)
+	}
+
+	t.Cleanup(func() {
+		err := r.Stop()
+		if err != nil {
+			t.Errorf("Error stopping recorder: %s", err)
+		}
+		for _, request := range r.Unmatched() {
+			t.Errorf("No recorded interaction for %s", request)
+		}
+	})
+
+	return r
+}
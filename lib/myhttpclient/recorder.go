@@ -0,0 +1,280 @@
+package myhttpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type RecordMode string
+
+const (
+	// RecordModeReplay serves responses from the cassette and never touches the network
+	RecordModeReplay RecordMode = "replay"
+	// RecordModeRecord sends requests for real and stores the sanitized interactions in the cassette
+	RecordModeRecord RecordMode = "record"
+)
+
+// Cassette is the file format of recorded interactions
+type Cassette struct {
+	// Comment describes where the interactions come from, like a hand-written fixture that still awaits a real recording
+	Comment      string        `json:"comment,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records interactions with a remote service into a cassette,
+// or replays them from it. Requests are matched on method, path and body; the host is ignored.
+// Credentials are redacted before an interaction is stored, and request bodies are redacted the same way before matching.
+type Recorder struct {
+	sync.Mutex
+	filename  string
+	mode      RecordMode
+	transport http.RoundTripper
+	cassette  Cassette
+	used      []bool
+	unmatched []string
+}
+
+// NewRecorder loads the cassette when replaying. When recording, the real transport is used and the cassette is written on Stop.
+func NewRecorder(filename string, mode RecordMode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	r := &Recorder{
+		filename:  filename,
+		mode:      mode,
+		transport: transport,
+	}
+
+	if mode == RecordModeReplay {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("error reading cassette %s: %w", filename, err)
+		}
+		err = json.Unmarshal(data, &r.cassette)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing cassette %s: %w", filename, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// CredentialFromEnv returns the real credential from the environment when recording, and a placeholder when replaying,
+// so tests never need real credentials
+func (r *Recorder) CredentialFromEnv(name string) string {
+	if r.mode == RecordModeRecord {
+		return os.Getenv(name)
+	}
+	return "replayed_" + strings.ToLower(name)
+}
+
+// HTTPClient returns a client that sends all its requests through the recorder
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{
+		Transport: r,
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	recordedReq := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  redactQuery(req.URL.Query()),
+		Header: recordableHeaders(req.Header),
+		Body:   recordableBody(req.Header.Get("Content-Type"), body, isSensitive),
+	}
+
+	if r.mode == RecordModeRecord {
+		return r.record(req, recordedReq)
+	}
+
+	return r.replay(req, recordedReq)
+}
+
+func (r *Recorder) record(req *http.Request, recordedReq RecordedRequest) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response of %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	header := recordableHeaders(resp.Header)
+	// the body changes by redaction
+	header.Del("Content-Length")
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recordedReq,
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: header,
+			Body:   recordableBody(resp.Header.Get("Content-Type"), respBody, isCredential),
+		},
+	})
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recordedReq RecordedRequest) (*http.Response, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	for idx, interaction := range r.cassette.Interactions {
+		if r.used[idx] || !interaction.Request.matches(recordedReq) {
+			continue
+		}
+		r.used[idx] = true
+
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	description := fmt.Sprintf("%s %s %s", recordedReq.Method, recordedReq.Path, recordedReq.Body)
+	r.unmatched = append(r.unmatched, description)
+
+	return nil, fmt.Errorf("no recorded interaction in %s for %s", r.filename, description)
+}
+
+func (rr RecordedRequest) matches(other RecordedRequest) bool {
+	return rr.Method == other.Method && rr.Path == other.Path && rr.Body == other.Body
+}
+
+// Unmatched returns the requests that could not be replayed
+func (r *Recorder) Unmatched() []string {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]string{}, r.unmatched...)
+}
+
+// Stop writes the cassette when recording
+func (r *Recorder) Stop() error {
+	if r.mode != RecordModeRecord {
+		return nil
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	data, err := json.MarshalIndent(r.cassette, "", "\t")
+	if err != nil {
+		return fmt.Errorf("error marshalling cassette %s: %w", r.filename, err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(r.filename), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating directory of cassette %s: %w", r.filename, err)
+	}
+
+	err = os.WriteFile(r.filename, data, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing cassette %s: %w", r.filename, err)
+	}
+
+	return nil
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return []byte{}, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body of %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// recordableBody redacts the sensitive fields of json and form-encoded bodies. Responses are redacted with isCredential
+// only: their generic fields, like the error code of a provider, matter for how we handle them.
+// Bodies of other content-types are kept as they are, because replaying them must give the same result.
+func recordableBody(contentType string, body []byte, sensitive func(name string) bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	result, ok := redactFields(contentType, body, sensitive)
+	if !ok {
+		return string(body)
+	}
+	return result
+}
+
+// recordableHeaders leaves out the user-agents, since these describe the machine the cassette was recorded on
+func recordableHeaders(header http.Header) http.Header {
+	result := RedactHeaders(header)
+	for name := range result {
+		if strings.Contains(strings.ToLower(name), "user-agent") {
+			delete(result, name)
+		}
+	}
+	return result
+}
+
+func redactQuery(values url.Values) string {
+	for name := range values {
+		if isSensitive(name) {
+			values[name] = []string{redacted}
+		}
+	}
+	return values.Encode()
+}
@@ -0,0 +1,134 @@
+package myhttpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	c := context.TODO()
+	filename := filepath.Join(t.TempDir(), "cassettes", "payments.json")
+
+	newRequest := func(body string) Request {
+		req := Request{Method: http.MethodPost, URL: "https://api.example.com/v1/payments", Header: http.Header{}, Body: []byte(body)}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer sk_live_123")
+		return req
+	}
+
+	t.Run("Record", func(t *testing.T) {
+		// setup
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"pay_1","status":"open","client_secret":"pay_1_secret"}`))
+		}))
+		defer ts.Close()
+		sut, err := NewRecorder(filename, RecordModeRecord, nil)
+		assert.NoError(t, err)
+		client := NewClient(Config{Transport: sut, MaxAttempts: 1})
+
+		// when
+		req := newRequest(`{"amount":100,"currency":"EUR"}`)
+		req.URL = ts.URL + "/v1/payments"
+		httpStatus, body, err := client.Do(c, req)
+		assert.NoError(t, err)
+		err = sut.Stop()
+		assert.NoError(t, err)
+
+		// then
+		assert.Equal(t, 201, httpStatus)
+		assert.Equal(t, `{"id":"pay_1","status":"open","client_secret":"pay_1_secret"}`, string(body))
+		data, err := os.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(data), "sk_live_123")
+		assert.NotContains(t, string(data), "pay_1_secret")
+		assert.Contains(t, string(data), `"path": "/v1/payments"`)
+	})
+
+	t.Run("Replay on another host", func(t *testing.T) {
+		// setup
+		sut, err := NewRecorder(filename, RecordModeReplay, nil)
+		assert.NoError(t, err)
+		client := NewClient(Config{Transport: sut, MaxAttempts: 1})
+
+		// when
+		httpStatus, body, err := client.Do(c, newRequest(`{"currency":"EUR","amount":100}`))
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, 201, httpStatus)
+		assert.Equal(t, `{"client_secret":"[REDACTED]","id":"pay_1","status":"open"}`, string(body))
+		assert.Empty(t, sut.Unmatched())
+
+		// when
+		_, _, err = client.Do(c, newRequest(`{"currency":"EUR","amount":100}`))
+
+		// then
+		assert.Error(t, err, "an interaction is only replayed once")
+	})
+
+	t.Run("Unmatched body", func(t *testing.T) {
+		// setup
+		sut, err := NewRecorder(filename, RecordModeReplay, nil)
+		assert.NoError(t, err)
+		client := NewClient(Config{Transport: sut, MaxAttempts: 1})
+
+		// when
+		_, _, err = client.Do(c, newRequest(`{"amount":200,"currency":"EUR"}`))
+
+		// then
+		assert.Error(t, err)
+		assert.Equal(t, []string{`POST /v1/payments {"amount":200,"currency":"EUR"}`}, sut.Unmatched())
+	})
+
+	t.Run("Record keeps error codes and bodies of unknown content-types", func(t *testing.T) {
+		// setup
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/refunds" {
+				w.Header().Set("Content-Type", "text/plain")
+				_, _ = w.Write([]byte("refund accepted"))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write([]byte(`{"code":"card_declined","key":"k_1","access_token":"at_1"}`))
+		}))
+		defer ts.Close()
+		errorsFilename := filepath.Join(t.TempDir(), "cassettes", "errors.json")
+		sut, err := NewRecorder(errorsFilename, RecordModeRecord, nil)
+		assert.NoError(t, err)
+		client := NewClient(Config{Transport: sut, MaxAttempts: 1})
+
+		// when
+		req := newRequest(`{"amount":100,"currency":"EUR"}`)
+		req.URL = ts.URL + "/v1/payments"
+		httpStatus, _, err := client.Do(c, req)
+		assert.NoError(t, err)
+		assert.Equal(t, 402, httpStatus)
+		_, _, err = client.Do(c, Request{Method: http.MethodPost, URL: ts.URL + "/v1/refunds", Body: []byte("amount=100")})
+		assert.NoError(t, err)
+		err = sut.Stop()
+		assert.NoError(t, err)
+
+		// then
+		replayer, err := NewRecorder(errorsFilename, RecordModeReplay, nil)
+		assert.NoError(t, err)
+		assert.Len(t, replayer.cassette.Interactions, 2)
+		assert.Equal(t, `{"access_token":"[REDACTED]","code":"card_declined","key":"k_1"}`, replayer.cassette.Interactions[0].Response.Body)
+		assert.Equal(t, "amount=100", replayer.cassette.Interactions[1].Request.Body)
+		assert.Equal(t, "refund accepted", replayer.cassette.Interactions[1].Response.Body)
+	})
+
+	t.Run("Missing cassette", func(t *testing.T) {
+		_, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), RecordModeReplay, nil)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,142 @@
+package myhttpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveWords mark headers and body fields whose value must never end up in the logs
+var sensitiveWords = []string{"authorization", "cookie", "secret", "password", "api-key", "api_key", "apikey", "verifier"}
+
+// sensitiveNames are too generic to match as part of a name, like "code" in "currencyCode"
+var sensitiveNames = []string{"code", "key"}
+
+func isSensitive(name string) bool {
+	if isCredential(name) {
+		return true
+	}
+	name = strings.ToLower(name)
+	for _, sensitiveName := range sensitiveNames {
+		if name == sensitiveName {
+			return true
+		}
+	}
+	return false
+}
+
+// isCredential only matches names that carry a credential for sure, so fields like an error "code" are kept
+func isCredential(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	// access_token and X-Auth-Token, but not token_type
+	return strings.HasSuffix(name, "token")
+}
+
+func dumpRequest(httpReq *http.Request, body []byte) string {
+	return fmt.Sprintf("%s %s\n%s\n%s\n", httpReq.Method, httpReq.URL.Redacted(), dumpHeaders(httpReq.Header), redactBody(httpReq.Header.Get("Content-Type"), body))
+}
+
+func dumpResponse(httpResp *http.Response, body []byte) string {
+	return fmt.Sprintf("%s\n%s\n%s\n", httpResp.Status, dumpHeaders(httpResp.Header), redactBody(httpResp.Header.Get("Content-Type"), body))
+}
+
+func dumpHeaders(header http.Header) string {
+	lines := []string{}
+	for name, values := range RedactHeaders(header) {
+		lines = append(lines, fmt.Sprintf("%s: %s", name, strings.Join(values, ", ")))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// RedactHeaders returns a copy of header with the values of credential-carrying headers replaced
+func RedactHeaders(header http.Header) http.Header {
+	result := http.Header{}
+	for name, values := range header {
+		if isSensitive(name) {
+			result[name] = []string{redacted}
+			continue
+		}
+		result[name] = values
+	}
+	return result
+}
+
+// RedactBody replaces the values of sensitive fields in a json or form-encoded body.
+// Bodies of other content-types are left out completely, since we can not tell what is in there.
+func RedactBody(contentType string, body []byte) []byte {
+	return []byte(redactBody(contentType, body))
+}
+
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	result, ok := redactFields(contentType, body, isSensitive)
+	if !ok {
+		return fmt.Sprintf("[%d bytes of %s]", len(body), contentType)
+	}
+	return result
+}
+
+// redactFields replaces the values of the fields that are sensitive according to the given func.
+// It returns false when the body is not json or form-encoded.
+func redactFields(contentType string, body []byte, sensitive func(name string) bool) (string, bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err == nil {
+			for name := range values {
+				if sensitive(name) {
+					values[name] = []string{redacted}
+				}
+			}
+			return values.Encode(), true
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var doc any
+		err := json.Unmarshal(body, &doc)
+		if err == nil {
+			out, err := json.Marshal(redactJSON(doc, sensitive))
+			if err == nil {
+				return string(out), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func redactJSON(doc any, sensitive func(name string) bool) any {
+	switch value := doc.(type) {
+	case map[string]any:
+		for name, field := range value {
+			if sensitive(name) {
+				value[name] = redacted
+				continue
+			}
+			value[name] = redactJSON(field, sensitive)
+		}
+		return value
+	case []any:
+		for idx, elem := range value {
+			value[idx] = redactJSON(elem, sensitive)
+		}
+		return value
+	default:
+		return doc
+	}
+}
@@ -0,0 +1,55 @@
+package myhttpclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedaction(t *testing.T) {
+	t.Run("Credential headers", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Authorization", "Basic MTIzOjQ1Ng==")
+		header.Set("X-Api-Key", "sk_test_123")
+		header.Set("Accept", "application/json")
+
+		redacted := RedactHeaders(header)
+
+		assert.Equal(t, "[REDACTED]", redacted.Get("Authorization"))
+		assert.Equal(t, "[REDACTED]", redacted.Get("X-Api-Key"))
+		assert.Equal(t, "application/json", redacted.Get("Accept"))
+		assert.Equal(t, "Basic MTIzOjQ1Ng==", header.Get("Authorization"))
+	})
+
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:        "Form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "code=abc&code_verifier=def&grant_type=authorization_code",
+			expected:    "code=%5BREDACTED%5D&code_verifier=%5BREDACTED%5D&grant_type=authorization_code",
+		},
+		{
+			name:        "Json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"access_token":"abc","token_type":"bearer","expires_in":3600,"amount":{"currencyCode":"EUR"},"tokens":[{"refresh_token":"def"}]}`,
+			expected:    `{"access_token":"[REDACTED]","amount":{"currencyCode":"EUR"},"expires_in":3600,"token_type":"bearer","tokens":[{"refresh_token":"[REDACTED]"}]}`,
+		},
+		{
+			name:        "Unknown content",
+			contentType: "text/plain",
+			body:        "secret stuff",
+			expected:    "[12 bytes of text/plain]",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, string(RedactBody(tc.contentType, []byte(tc.body))))
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package myjobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/mylog"
+	"github.com/MarcGrol/shopbackend/lib/myqueue"
+	"github.com/MarcGrol/shopbackend/lib/mystore"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+const jobsQueueName = "jobs"
+
+func init() {
+	myqueue.RegisterQueue(myqueue.QueueDefinition{
+		Name:                    jobsQueueName,
+		MaxAttempts:             10,
+		MaxDispatchesPerSecond:  10,
+		MaxConcurrentDispatches: 5,
+		MinBackoff:              5 * time.Second,
+		MaxBackoff:              time.Hour,
+	})
+}
+
+type JobState string
+
+const (
+	JobStateQueued    JobState = "queued"
+	JobStateRunning   JobState = "running"
+	JobStateRetrying  JobState = "retrying"
+	JobStateSucceeded JobState = "succeeded"
+	JobStateFailed    JobState = "failed"
+)
+
+// JobStatus tracks the progress of a single job
+type JobStatus struct {
+	UID       string
+	JobName   string
+	QueueName string
+	Payload   string `datastore:",noindex"`
+	State     JobState
+	Attempts  int32
+	LastError string `datastore:",noindex"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Handler processes the typed payload of a job. Return an error classified as permanent by myerrors
+// (like an invalid-input error) to stop retrying; any other error is retried.
+type Handler[P any] func(c context.Context, payload P) error
+
+// LastAttemptHandler is called once a job has failed for good: either permanently or on its last attempt.
+type LastAttemptHandler[P any] func(c context.Context, payload P, err error)
+
+// Definition describes a named job
+type Definition[P any] struct {
+	Name          string
+	QueueName     string // optional: defaults to the shared jobs-queue
+	Handler       Handler[P]
+	OnLastAttempt LastAttemptHandler[P]
+}
+
+type registeredJob struct {
+	queueName     string
+	handle        func(c context.Context, payload []byte) error
+	onLastAttempt func(c context.Context, payload []byte, err error)
+}
+
+// Jobs runs typed job-handlers on top of a task-queue, all behind one dispatch endpoint
+type Jobs struct {
+	store  mystore.Store[JobStatus]
+	queue  myqueue.TaskQueuer
+	nower  mytime.Nower
+	logger mylog.Logger
+	sync.RWMutex
+	jobs map[string]registeredJob
+}
+
+func New(c context.Context, queue myqueue.TaskQueuer, nower mytime.Nower) (*Jobs, func(), error) {
+	store, storeCleanup, err := mystore.New[JobStatus](c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newJobs(store, queue, nower), storeCleanup, nil
+}
+
+func newJobs(store mystore.Store[JobStatus], queue myqueue.TaskQueuer, nower mytime.Nower) *Jobs {
+	return &Jobs{
+		store:  store,
+		queue:  queue,
+		nower:  nower,
+		logger: mylog.New("myjobs"),
+		jobs:   map[string]registeredJob{},
+	}
+}
+
+// Register makes a typed job available for enqueueing and dispatching
+func Register[P any](j *Jobs, definition Definition[P]) {
+	queueName := definition.QueueName
+	if queueName == "" {
+		queueName = jobsQueueName
+	}
+
+	job := registeredJob{
+		queueName: queueName,
+		handle: func(c context.Context, payload []byte) error {
+			p, err := decode[P](payload)
+			if err != nil {
+				return err
+			}
+			return definition.Handler(c, p)
+		},
+		onLastAttempt: func(c context.Context, payload []byte, err error) {
+			if definition.OnLastAttempt == nil {
+				return
+			}
+			p, decodeErr := decode[P](payload)
+			if decodeErr != nil {
+				return
+			}
+			definition.OnLastAttempt(c, p, err)
+		},
+	}
+
+	j.Lock()
+	defer j.Unlock()
+
+	j.jobs[definition.Name] = job
+}
+
+func decode[P any](payload []byte) (P, error) {
+	var p P
+	err := json.Unmarshal(payload, &p)
+	if err != nil {
+		return p, myerrors.NewInvalidInputError(fmt.Errorf("error decoding job-payload: %w", err))
+	}
+	return p, nil
+}
+
+// Enqueue schedules a job with the given payload. The uid de-duplicates: a job with an existing uid is ignored.
+func Enqueue[P any](c context.Context, j *Jobs, jobName string, uid string, payload P) error {
+	return EnqueueAt(c, j, jobName, uid, payload, time.Time{})
+}
+
+// EnqueueAt schedules a job to run at the given moment
+func EnqueueAt[P any](c context.Context, j *Jobs, jobName string, uid string, payload P, when time.Time) error {
+	job, found := j.job(jobName)
+	if !found {
+		return myerrors.NewNotFoundError(fmt.Errorf("job %s is not registered", jobName))
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return myerrors.NewInvalidInputError(fmt.Errorf("error encoding job-payload: %w", err))
+	}
+
+	now := j.nower.Now()
+	created := false
+	err = j.store.RunInTransaction(c, func(c context.Context) error {
+		_, exists, err := j.store.Get(c, uid)
+		if err != nil {
+			return fmt.Errorf("error fetching status of job %s: %w", uid, err)
+		}
+		created = !exists
+		if exists {
+			return nil
+		}
+
+		err = j.store.Put(c, uid, JobStatus{
+			UID:       uid,
+			JobName:   jobName,
+			QueueName: job.queueName,
+			Payload:   string(jsonPayload),
+			State:     JobStateQueued,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return fmt.Errorf("error storing status of job %s: %w", uid, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return myerrors.NewInternalError(err)
+	}
+	if !created {
+		j.logger.Log(c, uid, mylog.SeverityInfo, "Job %s with uid %s was already enqueued", jobName, uid)
+		return nil
+	}
+
+	err = j.queue.Enqueue(c, myqueue.Task{
+		QueueName:      job.queueName,
+		UID:            uid,
+		WebhookURLPath: fmt.Sprintf("/jobs/%s/%s", jobName, uid),
+		Payload:        jsonPayload,
+		ScheduleTime:   when,
+	})
+	if err != nil {
+		// without a task the status would block a retry of this enqueue as a duplicate
+		deleteErr := j.store.Delete(c, uid)
+		if deleteErr != nil {
+			j.logger.Log(c, uid, mylog.SeverityError, "Error removing status of unqueued job %s: %s", uid, deleteErr)
+		}
+		return myerrors.NewInternalError(fmt.Errorf("error queueing job %s: %w", uid, err))
+	}
+
+	return nil
+}
+
+// Status returns the status of a job
+func (j *Jobs) Status(c context.Context, uid string) (JobStatus, error) {
+	status, exists, err := j.store.Get(c, uid)
+	if err != nil {
+		return JobStatus{}, myerrors.NewInternalError(fmt.Errorf("error fetching status of job %s: %w", uid, err))
+	}
+	if !exists {
+		return JobStatus{}, myerrors.NewNotFoundError(fmt.Errorf("job %s not found", uid))
+	}
+	return status, nil
+}
+
+func (j *Jobs) job(jobName string) (registeredJob, bool) {
+	j.RLock()
+	defer j.RUnlock()
+
+	job, found := j.jobs[jobName]
+	return job, found
+}
+
+// dispatch runs the handler of a job and classifies its outcome. It only returns an error when the queue should retry.
+func (j *Jobs) dispatch(c context.Context, jobName string, uid string, payload []byte) (JobStatus, error) {
+	job, found := j.job(jobName)
+	if !found {
+		return JobStatus{}, myerrors.NewNotFoundError(fmt.Errorf("job %s is not registered", jobName))
+	}
+
+	status, err := j.updateStatus(c, uid, func(status *JobStatus) {
+		if status.UID == "" {
+			status.UID = uid
+			status.JobName = jobName
+			status.QueueName = job.queueName
+			status.Payload = string(payload)
+			status.CreatedAt = j.nower.Now()
+		}
+		status.State = JobStateRunning
+		status.Attempts++
+	})
+	if err != nil {
+		return status, err
+	}
+
+	handleErr := job.handle(c, payload)
+	if handleErr == nil {
+		return j.updateStatus(c, uid, func(status *JobStatus) {
+			status.State = JobStateSucceeded
+			status.LastError = ""
+		})
+	}
+
+	if !myerrors.IsPermanent(handleErr) {
+		attempt, maxAttempts := j.queue.IsLastAttempt(c, job.queueName, uid)
+		if maxAttempts <= 0 || attempt < maxAttempts {
+			j.logger.Log(c, uid, mylog.SeverityWarn, "Job %s failed (attempt %d of %d): %s -> retry", jobName, attempt, maxAttempts, handleErr)
+			status, err = j.updateStatus(c, uid, func(status *JobStatus) {
+				status.State = JobStateRetrying
+				status.LastError = handleErr.Error()
+			})
+			if err != nil {
+				return status, err
+			}
+			return status, handleErr
+		}
+	}
+
+	j.logger.Log(c, uid, mylog.SeverityError, "Job %s failed for good: %s", jobName, handleErr)
+	job.onLastAttempt(c, payload, handleErr)
+
+	return j.updateStatus(c, uid, func(status *JobStatus) {
+		status.State = JobStateFailed
+		status.LastError = handleErr.Error()
+	})
+}
+
+func (j *Jobs) updateStatus(c context.Context, uid string, modify func(status *JobStatus)) (JobStatus, error) {
+	var status JobStatus
+	err := j.store.RunInTransaction(c, func(c context.Context) error {
+		var err error
+		status, _, err = j.store.Get(c, uid)
+		if err != nil {
+			return fmt.Errorf("error fetching status of job %s: %w", uid, err)
+		}
+
+		modify(&status)
+		status.UpdatedAt = j.nower.Now()
+
+		err = j.store.Put(c, uid, status)
+		if err != nil {
+			return fmt.Errorf("error storing status of job %s: %w", uid, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return status, myerrors.NewInternalError(err)
+	}
+	return status, nil
+}
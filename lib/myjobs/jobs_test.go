@@ -0,0 +1,255 @@
+package myjobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myqueue"
+	"github.com/MarcGrol/shopbackend/lib/mystore"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+type expireBasket struct {
+	BasketUID string
+}
+
+func TestJobs(t *testing.T) {
+	t.Run("Enqueue job", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, _, queue, _ := setup(t, ctrl, nil)
+
+		// given
+		queue.EXPECT().Enqueue(gomock.Any(), myqueue.Task{
+			QueueName:      "jobs",
+			UID:            "job-1",
+			WebhookURLPath: "/jobs/basket.expire/job-1",
+			Payload:        []byte(`{"BasketUID":"123"}`),
+		}).Return(nil)
+
+		// when
+		err := Enqueue(c, sut, "basket.expire", "job-1", expireBasket{BasketUID: "123"})
+
+		// then
+		assert.NoError(t, err)
+		status, err := sut.Status(c, "job-1")
+		assert.NoError(t, err)
+		assert.Equal(t, JobStateQueued, status.State)
+	})
+
+	t.Run("Enqueue duplicate job", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, _, queue, _ := setup(t, ctrl, nil)
+
+		// given
+		queue.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		err := Enqueue(c, sut, "basket.expire", "job-1", expireBasket{BasketUID: "123"})
+		assert.NoError(t, err)
+
+		// when
+		err = Enqueue(c, sut, "basket.expire", "job-1", expireBasket{BasketUID: "456"})
+
+		// then
+		assert.NoError(t, err)
+		status, err := sut.Status(c, "job-1")
+		assert.NoError(t, err)
+		assert.Equal(t, `{"BasketUID":"123"}`, status.Payload)
+	})
+
+	t.Run("Enqueue can be retried after queue failure", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, _, queue, _ := setup(t, ctrl, nil)
+
+		// given
+		queue.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(fmt.Errorf("queue unavailable"))
+		err := Enqueue(c, sut, "basket.expire", "job-1", expireBasket{BasketUID: "123"})
+		assert.Error(t, err)
+
+		// when
+		queue.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(nil)
+		err = Enqueue(c, sut, "basket.expire", "job-1", expireBasket{BasketUID: "123"})
+
+		// then
+		assert.NoError(t, err)
+		status, err := sut.Status(c, "job-1")
+		assert.NoError(t, err)
+		assert.Equal(t, JobStateQueued, status.State)
+	})
+
+	t.Run("Enqueue unknown job", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		sut, _, _, _ := setup(t, ctrl, nil)
+
+		// when
+		err := Enqueue(context.TODO(), sut, "unknown", "job-1", expireBasket{})
+
+		// then
+		assert.Error(t, err)
+		assert.Equal(t, 404, myerrors.GetHTTPStatus(err))
+	})
+
+	t.Run("Dispatch succeeds", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, _, _ := setup(t, ctrl, nil)
+
+		// when
+		response := dispatch(t, router, "basket.expire", `{"BasketUID":"123"}`)
+
+		// then
+		assert.Equal(t, 200, response.Code)
+		status := decodeStatus(t, response)
+		assert.Equal(t, JobStateSucceeded, status.State)
+		assert.Equal(t, int32(1), status.Attempts)
+	})
+
+	t.Run("Retryable error is retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, queue, _ := setup(t, ctrl, fmt.Errorf("store unavailable"))
+
+		// given
+		queue.EXPECT().IsLastAttempt(gomock.Any(), "jobs", "job-1").Return(int32(1), int32(10))
+
+		// when
+		response := dispatch(t, router, "basket.expire", `{"BasketUID":"123"}`)
+
+		// then
+		assert.Equal(t, 500, response.Code)
+		status := getStatus(t, router)
+		assert.Equal(t, JobStateRetrying, status.State)
+		assert.Equal(t, "store unavailable", status.LastError)
+	})
+
+	t.Run("Retryable error on last attempt fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, queue, lastAttempt := setup(t, ctrl, fmt.Errorf("store unavailable"))
+
+		// given
+		queue.EXPECT().IsLastAttempt(gomock.Any(), "jobs", "job-1").Return(int32(10), int32(10))
+
+		// when
+		response := dispatch(t, router, "basket.expire", `{"BasketUID":"123"}`)
+
+		// then
+		assert.Equal(t, 200, response.Code)
+		status := decodeStatus(t, response)
+		assert.Equal(t, JobStateFailed, status.State)
+		assert.Equal(t, "123: store unavailable", *lastAttempt)
+	})
+
+	t.Run("Permanent error is not retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, _, _ := setup(t, ctrl, nil)
+
+		// when
+		response := dispatch(t, router, "basket.expire", `{"BasketUID":`)
+
+		// then
+		assert.Equal(t, 200, response.Code)
+		status := decodeStatus(t, response)
+		assert.Equal(t, JobStateFailed, status.State)
+		assert.Contains(t, status.LastError, "error decoding job-payload")
+	})
+
+	t.Run("Dispatch unknown job", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, _, _ := setup(t, ctrl, nil)
+
+		// when
+		response := dispatch(t, router, "unknown", `{}`)
+
+		// then
+		assert.Equal(t, 404, response.Code)
+	})
+}
+
+func setup(t *testing.T, ctrl *gomock.Controller, handlerErr error) (*Jobs, *mux.Router, *myqueue.MockTaskQueuer, *string) {
+	c := context.TODO()
+	store, _, err := mystore.NewInMemoryStore[JobStatus](c)
+	assert.NoError(t, err)
+	queue := myqueue.NewMockTaskQueuer(ctrl)
+	nower := mytime.NewMockNower(ctrl)
+	nower.EXPECT().Now().Return(mytime.ExampleTime).AnyTimes()
+
+	sut := newJobs(store, queue, nower)
+	router := mux.NewRouter()
+	lastAttempt := ""
+
+	Register(sut, Definition[expireBasket]{
+		Name: "basket.expire",
+		Handler: func(c context.Context, payload expireBasket) error {
+			return handlerErr
+		},
+		OnLastAttempt: func(c context.Context, payload expireBasket, err error) {
+			lastAttempt = fmt.Sprintf("%s: %s", payload.BasketUID, err)
+		},
+	})
+	sut.RegisterEndpoints(c, router)
+
+	return sut, router, queue, &lastAttempt
+}
+
+func dispatch(t *testing.T, router *mux.Router, jobName string, payload string) *httptest.ResponseRecorder {
+	request, err := http.NewRequest(http.MethodPut, "/jobs/"+jobName+"/job-1", strings.NewReader(payload))
+	assert.NoError(t, err)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	return response
+}
+
+func getStatus(t *testing.T, router *mux.Router) JobStatus {
+	request, err := http.NewRequest(http.MethodGet, "/api/jobs/job-1", nil)
+	assert.NoError(t, err)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	assert.Equal(t, 200, response.Code)
+
+	return decodeStatus(t, response)
+}
+
+func decodeStatus(t *testing.T, response *httptest.ResponseRecorder) JobStatus {
+	status := JobStatus{}
+	err := json.Unmarshal(response.Body.Bytes(), &status)
+	assert.NoError(t, err)
+
+	return status
+}
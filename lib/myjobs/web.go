@@ -0,0 +1,61 @@
+package myjobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myhttp"
+)
+
+func (j *Jobs) RegisterEndpoints(c context.Context, router *mux.Router) {
+	// Called by the task-queue
+	router.HandleFunc("/jobs/{name}/{uid}", j.dispatchJob()).Methods("PUT")
+
+	router.HandleFunc("/api/jobs/{uid}", j.jobStatus()).Methods("GET")
+}
+
+func (j *Jobs) dispatchJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(j.logger)
+
+		jobName := mux.Vars(r)["name"]
+		uid := mux.Vars(r)["uid"]
+
+		payload, err := io.ReadAll(r.Body)
+		if err != nil {
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("error reading job-payload: %w", err)))
+			return
+		}
+
+		// A non-2xx response makes the queue retry
+		status, err := j.dispatch(c, jobName, uid, payload)
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		responseWriter.Write(c, w, http.StatusOK, status)
+	}
+}
+
+func (j *Jobs) jobStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(j.logger)
+
+		status, err := j.Status(c, mux.Vars(r)["uid"])
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		responseWriter.Write(c, w, http.StatusOK, status)
+	}
+}
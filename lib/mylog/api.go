@@ -13,6 +13,7 @@ const (
 
 var New func(name string) Logger
 
+//go:generate mockgen -source=api.go -package mylog -destination logger_mock.go Logger
 type Logger interface {
 	Log(ctx context.Context, traceLabel string, severity Severity, format string, a ...any)
 }
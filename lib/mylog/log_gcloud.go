@@ -31,10 +31,14 @@ func newGloudLogger(componentName string) Logger {
 }
 
 func (l structuredLogger) Log(ctx context.Context, traceLabel string, severity Severity, format string, a ...interface{}) {
+	labels := map[string]string{"aggregate": traceLabel}
+	if requestID := mycontext.RequestIDFromContext(ctx); requestID != "" {
+		labels["requestID"] = requestID
+	}
 	log.Println(entry{
 		Component: l.componentName,
-		Labels:    map[string]string{"aggregate": traceLabel},
-		Trace:     ctx.Value(mycontext.CtxTraceContext{}).(string),
+		Labels:    labels,
+		Trace:     mycontext.TraceFromContext(ctx),
 		Severity:  string(severity),
 		Message:   l.componentName + ":" + fmt.Sprintf(format, a...),
 	}.String())
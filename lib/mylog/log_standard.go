@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
 )
 
 func init() {
@@ -23,5 +25,5 @@ func newStandardLogger(componentName string) Logger {
 }
 
 func (l standardLogger) Log(ctx context.Context, traceLabel string, severity Severity, format string, a ...interface{}) {
-	fmt.Fprintf(os.Stderr, "\n%s - %s - %s - %s\n", l.componentName, traceLabel, string(severity), fmt.Sprintf(format, a...))
+	fmt.Fprintf(os.Stderr, "\n%s - %s - %s - %s - %s\n", l.componentName, mycontext.RequestIDFromContext(ctx), traceLabel, string(severity), fmt.Sprintf(format, a...))
 }
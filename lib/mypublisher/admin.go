@@ -0,0 +1,301 @@
+package mypublisher
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/myhttp"
+	"github.com/MarcGrol/shopbackend/lib/myjobs"
+	"github.com/MarcGrol/shopbackend/lib/mylog"
+	"github.com/MarcGrol/shopbackend/lib/mystore"
+)
+
+const (
+	maxListedEnvelopes = 100
+	// pending envelopes are normally few: the overview never reads more than these
+	maxPendingEnvelopes = 1000
+)
+
+//go:embed templates
+var templateFolder embed.FS
+
+var outboxAdminPageTemplate = template.Must(template.ParseFS(templateFolder, "templates/outbox_admin.html"))
+
+type OutboxFilter struct {
+	Topic         string
+	EventTypeName string
+	Published     *bool
+	MinAge        time.Duration
+	MaxAge        time.Duration
+}
+
+type TopicStats struct {
+	Topic       string
+	Published   int
+	Unpublished int
+	Scheduled   int
+}
+
+type OutboxOverview struct {
+	Filter            OutboxFilter
+	Envelopes         []myevents.EventEnvelope
+	OldestUnpublished *time.Time
+	LagSeconds        float64
+	// TopicStats covers the topics of the pending and the listed envelopes
+	TopicStats []TopicStats
+	// PendingTruncated indicates that the unpublished and scheduled counts stop at maxPendingEnvelopes
+	PendingTruncated bool
+}
+
+func parseOutboxFilter(values url.Values) (OutboxFilter, error) {
+	filter := OutboxFilter{
+		Topic:         values.Get("topic"),
+		EventTypeName: values.Get("eventType"),
+	}
+
+	if values.Get("published") != "" {
+		published, err := strconv.ParseBool(values.Get("published"))
+		if err != nil {
+			return filter, myerrors.NewInvalidInputError(fmt.Errorf("invalid published-flag %s: %w", values.Get("published"), err))
+		}
+		filter.Published = &published
+	}
+
+	if values.Get("minAge") != "" {
+		minAge, err := time.ParseDuration(values.Get("minAge"))
+		if err != nil {
+			return filter, myerrors.NewInvalidInputError(fmt.Errorf("invalid minAge %s: %w", values.Get("minAge"), err))
+		}
+		filter.MinAge = minAge
+	}
+
+	if values.Get("maxAge") != "" {
+		maxAge, err := time.ParseDuration(values.Get("maxAge"))
+		if err != nil {
+			return filter, myerrors.NewInvalidInputError(fmt.Errorf("invalid maxAge %s: %w", values.Get("maxAge"), err))
+		}
+		filter.MaxAge = maxAge
+	}
+
+	return filter, nil
+}
+
+// queryFilters is ordered by -CreatedAt: index.yaml declares an index for every combination of the equality filters
+func (f OutboxFilter) queryFilters(now time.Time) []mystore.Filter {
+	filters := []mystore.Filter{}
+	if f.Topic != "" {
+		filters = append(filters, mystore.Filter{Field: "Topic", Compare: "=", Value: f.Topic})
+	}
+	if f.EventTypeName != "" {
+		filters = append(filters, mystore.Filter{Field: "EventTypeName", Compare: "=", Value: f.EventTypeName})
+	}
+	if f.Published != nil {
+		filters = append(filters, mystore.Filter{Field: "Published", Compare: "=", Value: *f.Published})
+	}
+	if f.MinAge > 0 {
+		filters = append(filters, mystore.Filter{Field: "CreatedAt", Compare: "<=", Value: now.Add(-f.MinAge)})
+	}
+	if f.MaxAge > 0 {
+		filters = append(filters, mystore.Filter{Field: "CreatedAt", Compare: ">=", Value: now.Add(-f.MaxAge)})
+	}
+	return filters
+}
+
+func (p *transactionalPublisher) outboxOverview(c context.Context, filter OutboxFilter) (OutboxOverview, error) {
+	now := p.nower.Now()
+
+	envelopes, err := p.outbox.QueryLimit(c, filter.queryFilters(now), "-CreatedAt", maxListedEnvelopes)
+	if err != nil {
+		return OutboxOverview{}, myerrors.NewInternalError(fmt.Errorf("error fetching envelopes: %w", err))
+	}
+
+	// oldest first, so the oldest unpublished envelope is found even when the page is truncated
+	pending, err := p.outbox.QueryLimit(c, []mystore.Filter{{Field: "Published", Compare: "=", Value: false}}, "CreatedAt", maxPendingEnvelopes)
+	if err != nil {
+		return OutboxOverview{}, myerrors.NewInternalError(fmt.Errorf("error fetching unpublished envelopes: %w", err))
+	}
+
+	overview := OutboxOverview{
+		Filter:           filter,
+		Envelopes:        envelopes,
+		PendingTruncated: len(pending) == maxPendingEnvelopes,
+	}
+	statsPerTopic := map[string]*TopicStats{}
+	statsOf := func(topic string) *TopicStats {
+		stats, found := statsPerTopic[topic]
+		if !found {
+			stats = &TopicStats{Topic: topic}
+			statsPerTopic[topic] = stats
+		}
+		return stats
+	}
+
+	for _, envelope := range pending {
+		stats := statsOf(envelope.Topic)
+		if envelope.PublishAfter.After(now) {
+			// scheduled for later, so not lagging behind
+			stats.Scheduled++
+		} else {
+			stats.Unpublished++
+			if overview.OldestUnpublished == nil || envelope.CreatedAt.Before(*overview.OldestUnpublished) {
+				createdAt := envelope.CreatedAt
+				overview.OldestUnpublished = &createdAt
+			}
+		}
+	}
+
+	for _, envelope := range envelopes {
+		statsOf(envelope.Topic)
+	}
+	if filter.Topic != "" {
+		statsOf(filter.Topic)
+	}
+
+	if overview.OldestUnpublished != nil {
+		overview.LagSeconds = now.Sub(*overview.OldestUnpublished).Seconds()
+	}
+
+	for topic, stats := range statsPerTopic {
+		stats.Published, err = p.outbox.Count(c, []mystore.Filter{
+			{Field: "Topic", Compare: "=", Value: topic},
+			{Field: "Published", Compare: "=", Value: true},
+		})
+		if err != nil {
+			return OutboxOverview{}, myerrors.NewInternalError(fmt.Errorf("error counting published envelopes of topic %s: %w", topic, err))
+		}
+		overview.TopicStats = append(overview.TopicStats, *stats)
+	}
+	sort.Slice(overview.TopicStats, func(i, j int) bool {
+		return overview.TopicStats[i].Topic < overview.TopicStats[j].Topic
+	})
+
+	return overview, nil
+}
+
+// republish marks the envelope as unpublished and triggers a new flush of the outbox
+func (p *transactionalPublisher) republish(c context.Context, uid string) (myevents.EventEnvelope, error) {
+	var envelope myevents.EventEnvelope
+
+	err := p.outbox.RunInTransaction(c, func(c context.Context) error {
+		var found bool
+		var err error
+		envelope, found, err = p.outbox.Get(c, uid)
+		if err != nil {
+			return myerrors.NewInternalError(fmt.Errorf("error fetching envelope %s: %w", uid, err))
+		}
+		if !found {
+			return myerrors.NewNotFoundError(fmt.Errorf("envelope %s not found", uid))
+		}
+
+		envelope.Published = false
+		err = p.outbox.Put(c, uid, envelope)
+		if err != nil {
+			return myerrors.NewInternalError(fmt.Errorf("error storing envelope %s: %w", uid, err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return envelope, err
+	}
+
+	// a previous trigger for this envelope may still exist: use a new job-uid
+	err = myjobs.Enqueue(c, p.jobs, outboxFlushJobName, fmt.Sprintf("%s-republish-%d", envelope.UID, p.nower.Now().Unix()), outboxTrigger{
+		Topic: envelope.Topic,
+		UID:   envelope.UID,
+	})
+	if err != nil {
+		return envelope, myerrors.NewInternalError(fmt.Errorf("error queueing publication-trigger %s: %w", envelope.UID, err))
+	}
+
+	return envelope, nil
+}
+
+func (p *transactionalPublisher) outboxAdminPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(mylog.New("transactionalPublisher"))
+
+		filter, err := parseOutboxFilter(r.URL.Query())
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		overview, err := p.outboxOverview(c, filter)
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = outboxAdminPageTemplate.Execute(w, overview)
+		if err != nil {
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
+			return
+		}
+	}
+}
+
+func (p *transactionalPublisher) outboxAdminRepublishPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(mylog.New("transactionalPublisher"))
+
+		_, err := p.republish(c, mux.Vars(r)["uid"])
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		http.Redirect(w, r, "/pubsub/admin", http.StatusSeeOther)
+	}
+}
+
+func (p *transactionalPublisher) outboxAPI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(mylog.New("transactionalPublisher"))
+
+		filter, err := parseOutboxFilter(r.URL.Query())
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		overview, err := p.outboxOverview(c, filter)
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		responseWriter.Write(c, w, http.StatusOK, overview)
+	}
+}
+
+func (p *transactionalPublisher) outboxRepublishAPI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(mylog.New("transactionalPublisher"))
+
+		envelope, err := p.republish(c, mux.Vars(r)["uid"])
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		responseWriter.Write(c, w, http.StatusOK, envelope)
+	}
+}
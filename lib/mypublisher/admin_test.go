@@ -0,0 +1,230 @@
+package mypublisher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/myjobs"
+	"github.com/MarcGrol/shopbackend/lib/myqueue"
+	"github.com/MarcGrol/shopbackend/lib/mystore"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+func TestOutboxAdmin(t *testing.T) {
+	t.Run("List unpublished envelopes with lag and topic stats", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		router, _, nower := setupAdmin(t, ctrl)
+
+		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+
+		// when
+		request, err := http.NewRequest(http.MethodGet, "/api/pubsub/outbox?topic=checkout&published=false", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 200, response.Code)
+		overview := OutboxOverview{}
+		err = json.Unmarshal(response.Body.Bytes(), &overview)
+		assert.NoError(t, err)
+		assert.Len(t, overview.Envelopes, 1)
+		assert.Equal(t, "2", overview.Envelopes[0].UID)
+		assert.Equal(t, float64(600), overview.LagSeconds)
+		assert.Equal(t, []TopicStats{
+			{Topic: "checkout", Published: 1, Unpublished: 1},
+		}, overview.TopicStats)
+	})
+
+	t.Run("Filters and limits are applied by the store", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		outbox := mystore.NewMockStore[myevents.EventEnvelope](ctrl)
+		nower := mytime.NewMockNower(ctrl)
+		sut := &transactionalPublisher{
+			outbox: outbox,
+			nower:  nower,
+		}
+
+		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		outbox.EXPECT().QueryLimit(gomock.Any(), []mystore.Filter{
+			{Field: "Topic", Compare: "=", Value: "checkout"},
+			{Field: "CreatedAt", Compare: "<=", Value: mytime.ExampleTime.Add(-15 * time.Minute)},
+		}, "-CreatedAt", maxListedEnvelopes).Return([]myevents.EventEnvelope{}, nil)
+		outbox.EXPECT().QueryLimit(gomock.Any(), []mystore.Filter{
+			{Field: "Published", Compare: "=", Value: false},
+		}, "CreatedAt", maxPendingEnvelopes).Return([]myevents.EventEnvelope{}, nil)
+		outbox.EXPECT().Count(gomock.Any(), []mystore.Filter{
+			{Field: "Topic", Compare: "=", Value: "checkout"},
+			{Field: "Published", Compare: "=", Value: true},
+		}).Return(42, nil)
+
+		// when
+		overview, err := sut.outboxOverview(context.TODO(), OutboxFilter{Topic: "checkout", MinAge: 15 * time.Minute})
+
+		// then
+		assert.NoError(t, err)
+		assert.Empty(t, overview.Envelopes)
+		assert.Nil(t, overview.OldestUnpublished)
+		assert.False(t, overview.PendingTruncated)
+		assert.Equal(t, []TopicStats{{Topic: "checkout", Published: 42}}, overview.TopicStats)
+	})
+
+	t.Run("List envelopes by age", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		router, _, nower := setupAdmin(t, ctrl)
+
+		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+
+		// when
+		request, err := http.NewRequest(http.MethodGet, "/api/pubsub/outbox?minAge=15m", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 200, response.Code)
+		overview := OutboxOverview{}
+		err = json.Unmarshal(response.Body.Bytes(), &overview)
+		assert.NoError(t, err)
+		assert.Len(t, overview.Envelopes, 1)
+		assert.Equal(t, "1", overview.Envelopes[0].UID)
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		router, _, _ := setupAdmin(t, ctrl)
+
+		// when
+		request, err := http.NewRequest(http.MethodGet, "/api/pubsub/outbox?published=maybe", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("Admin page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		router, _, nower := setupAdmin(t, ctrl)
+
+		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+
+		// when
+		request, err := http.NewRequest(http.MethodGet, "/pubsub/admin", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 200, response.Code)
+		assert.Contains(t, response.Body.String(), "lag of 600 seconds")
+		assert.Contains(t, response.Body.String(), `<form action="/pubsub/admin/2/republish" method="POST">`)
+	})
+
+	t.Run("Republish envelope", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		router, queue, nower := setupAdmin(t, ctrl)
+
+		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		queue.EXPECT().Enqueue(gomock.Any(), myqueue.Task{
+			QueueName:      "outbox",
+			UID:            "1-republish-1677542339",
+			WebhookURLPath: "/jobs/outbox.flush/1-republish-1677542339",
+			Payload:        []byte(`{"Topic":"checkout","UID":"1"}`),
+		}).Return(nil)
+
+		// when
+		request, err := http.NewRequest(http.MethodPost, "/api/pubsub/outbox/1/republish", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 200, response.Code)
+		envelope := myevents.EventEnvelope{}
+		err = json.Unmarshal(response.Body.Bytes(), &envelope)
+		assert.NoError(t, err)
+		assert.False(t, envelope.Published)
+	})
+
+	t.Run("Republish unknown envelope", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		router, _, _ := setupAdmin(t, ctrl)
+
+		// when
+		request, err := http.NewRequest(http.MethodPost, "/api/pubsub/outbox/unknown/republish", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 404, response.Code)
+	})
+}
+
+func setupAdmin(t *testing.T, ctrl *gomock.Controller) (*mux.Router, *myqueue.MockTaskQueuer, *mytime.MockNower) {
+	c := context.TODO()
+	outbox, _, err := mystore.NewInMemoryStore[myevents.EventEnvelope](c)
+	assert.NoError(t, err)
+	queue := myqueue.NewMockTaskQueuer(ctrl)
+	nower := mytime.NewMockNower(ctrl)
+
+	for _, envelope := range []myevents.EventEnvelope{
+		{UID: "1", CreatedAt: mytime.ExampleTime.Add(-20 * time.Minute), Topic: "checkout", EventTypeName: "checkout.started", Published: true},
+		{UID: "2", CreatedAt: mytime.ExampleTime.Add(-10 * time.Minute), Topic: "checkout", EventTypeName: "checkout.completed", Published: false},
+		{UID: "3", CreatedAt: mytime.ExampleTime.Add(-5 * time.Minute), Topic: "basket", EventTypeName: "basket.created", Published: true},
+	} {
+		err := outbox.Put(c, envelope.UID, envelope)
+		assert.NoError(t, err)
+	}
+
+	jobs, _, err := myjobs.New(c, queue, mytime.RealNower{})
+	assert.NoError(t, err)
+
+	sut := &transactionalPublisher{
+		outbox: outbox,
+		jobs:   jobs,
+		nower:  nower,
+	}
+	sut.registerJobs()
+	router := mux.NewRouter()
+	sut.RegisterEndpoints(c, router)
+
+	return router, queue, nower
+}
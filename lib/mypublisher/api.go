@@ -2,6 +2,7 @@ package mypublisher
 
 import (
 	"context"
+	"time"
 
 	"github.com/MarcGrol/shopbackend/lib/myevents"
 )
@@ -10,4 +11,5 @@ import (
 type Publisher interface {
 	CreateTopic(ctx context.Context, topicName string) error
 	Publish(c context.Context, topic string, env myevents.Event) error
+	PublishAt(c context.Context, topic string, env myevents.Event, when time.Time) error
 }
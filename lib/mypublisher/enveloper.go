@@ -1,12 +1,14 @@
 package mypublisher
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
 	"github.com/MarcGrol/shopbackend/lib/myevents"
 	"github.com/MarcGrol/shopbackend/lib/mytime"
 )
@@ -21,16 +23,17 @@ func newEnveloper(nower mytime.Nower) enveloper {
 	}
 }
 
-func (e enveloper) do(topic string, event myevents.Event) (myevents.EventEnvelope, error) {
+func (e enveloper) do(c context.Context, topic string, event myevents.Event) (myevents.EventEnvelope, error) {
 	jsonPayload, err := json.Marshal(event)
 	if err != nil {
-		return myevents.EventEnvelope{}, fmt.Errorf("error marshalling request-payload: %s", err)
+		return myevents.EventEnvelope{}, fmt.Errorf("error marshalling request-payload: %w", err)
 	}
 
 	envelope := myevents.EventEnvelope{
 		Topic:         topic,
 		AggregateUID:  event.GetAggregateName(),
 		EventTypeName: event.GetEventTypeName(),
+		EventVersion:  event.GetEventVersion(),
 		EventPayload:  string(jsonPayload),
 		Published:     false,
 	}
@@ -38,10 +41,12 @@ func (e enveloper) do(topic string, event myevents.Event) (myevents.EventEnvelop
 	// In order to be idempotent, we do NOT use an uuid to identify the event
 	envelope.UID, err = checksum(envelope)
 	if err != nil {
-		return myevents.EventEnvelope{}, fmt.Errorf("error checksumming request-payload: %s", err)
+		return myevents.EventEnvelope{}, fmt.Errorf("error checksumming request-payload: %w", err)
 	}
 	// In order to be idempotent, we exclude timestamp from the checksum
 	envelope.CreatedAt = e.nower.Now()
+	// Trace differs per request, so it is also excluded from the checksum
+	envelope.TraceContext = mycontext.TraceFromContext(c)
 
 	return envelope, nil
 }
@@ -0,0 +1,75 @@
+package mypublisher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+type testEvent struct {
+	UID string
+}
+
+func (e testEvent) GetEventTypeName() string {
+	return "test.happened"
+}
+
+func (e testEvent) GetAggregateName() string {
+	return e.UID
+}
+
+func (e testEvent) GetEventVersion() int {
+	return 1
+}
+
+func TestEnveloper(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nower := mytime.NewMockNower(ctrl)
+	nower.EXPECT().Now().Return(mytime.ExampleTime).AnyTimes()
+
+	e := newEnveloper(nower)
+
+	t.Run("Trace is propagated into envelope and attributes", func(t *testing.T) {
+		c := mycontext.WithTrace(context.TODO(), "projects/my-project/traces/123")
+
+		envelope, err := e.do(c, "test", testEvent{UID: "abc"})
+		assert.NoError(t, err)
+		assert.Equal(t, "projects/my-project/traces/123", envelope.TraceContext)
+
+		attributes := envelope.Attributes()
+		assert.Equal(t, envelope.UID, attributes[myevents.AttributeUID])
+		assert.Equal(t, "test", attributes[myevents.AttributeTopic])
+		assert.Equal(t, "abc", attributes[myevents.AttributeAggregateUID])
+		assert.Equal(t, "test.happened", attributes[myevents.AttributeEventTypeName])
+		assert.Equal(t, "2023-02-27T23:58:59Z", attributes[myevents.AttributeCreatedAt])
+		assert.Equal(t, "projects/my-project/traces/123", attributes[myevents.AttributeTraceContext])
+	})
+
+	t.Run("Trace does not influence idempotent uid", func(t *testing.T) {
+		withTrace, err := e.do(mycontext.WithTrace(context.TODO(), "projects/my-project/traces/123"), "test", testEvent{UID: "abc"})
+		assert.NoError(t, err)
+
+		withoutTrace, err := e.do(context.TODO(), "test", testEvent{UID: "abc"})
+		assert.NoError(t, err)
+
+		assert.Equal(t, withTrace.UID, withoutTrace.UID)
+		_, found := withoutTrace.Attributes()[myevents.AttributeTraceContext]
+		assert.False(t, found)
+	})
+
+	t.Run("Consumer continues trace of publisher", func(t *testing.T) {
+		envelope, err := e.do(mycontext.WithTrace(context.TODO(), "projects/my-project/traces/123"), "test", testEvent{UID: "abc"})
+		assert.NoError(t, err)
+
+		c := envelope.ContextWithTrace(context.TODO())
+		assert.Equal(t, "projects/my-project/traces/123", mycontext.TraceFromContext(c))
+	})
+}
@@ -12,6 +12,7 @@ package mypublisher
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	myevents "github.com/MarcGrol/shopbackend/lib/myevents"
 	gomock "go.uber.org/mock/gomock"
@@ -68,3 +69,17 @@ func (mr *MockPublisherMockRecorder) Publish(c, topic, env any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockPublisher)(nil).Publish), c, topic, env)
 }
+
+// PublishAt mocks base method.
+func (m *MockPublisher) PublishAt(c context.Context, topic string, env myevents.Event, when time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishAt", c, topic, env, when)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishAt indicates an expected call of PublishAt.
+func (mr *MockPublisherMockRecorder) PublishAt(c, topic, env, when any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishAt", reflect.TypeOf((*MockPublisher)(nil).PublishAt), c, topic, env, when)
+}
@@ -6,12 +6,17 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/MarcGrol/shopbackend/lib/mycontext"
 	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/myeventstore"
 	"github.com/MarcGrol/shopbackend/lib/myhttp"
+	"github.com/MarcGrol/shopbackend/lib/myjobs"
 	"github.com/MarcGrol/shopbackend/lib/mylog"
 	"github.com/MarcGrol/shopbackend/lib/mypubsub"
 	"github.com/MarcGrol/shopbackend/lib/myqueue"
@@ -19,14 +24,45 @@ import (
 	"github.com/MarcGrol/shopbackend/lib/mytime"
 )
 
+const (
+	defaultBatchSize   = 50
+	defaultConcurrency = 10
+	outboxQueueName    = "outbox"
+	outboxFlushJobName = "outbox.flush"
+)
+
+func init() {
+	// Triggers are cheap and idempotent: flush fast and keep trying
+	myqueue.RegisterQueue(myqueue.QueueDefinition{
+		Name:                    outboxQueueName,
+		MaxAttempts:             20,
+		MaxDispatchesPerSecond:  50,
+		MaxConcurrentDispatches: 10,
+		MinBackoff:              time.Second,
+		MaxBackoff:              5 * time.Minute,
+	})
+}
+
+// outboxTrigger is the payload of the job that flushes the outbox after an envelope was stored
+type outboxTrigger struct {
+	Topic string
+	UID   string
+}
+
 type transactionalPublisher struct {
-	outbox    mystore.Store[myevents.EventEnvelope]
-	queue     myqueue.TaskQueuer
-	enveloper enveloper
-	pubsub    mypubsub.PubSub
+	outbox      mystore.Store[myevents.EventEnvelope]
+	eventStore  myeventstore.EventStore
+	jobs        *myjobs.Jobs
+	enveloper   enveloper
+	pubsub      mypubsub.PubSub
+	nower       mytime.Nower
+	batchSize   int
+	concurrency int
+	backfill    sync.Mutex
+	backfilled  bool
 }
 
-func New(c context.Context, pubsub mypubsub.PubSub, queue myqueue.TaskQueuer, nower mytime.Nower) (*transactionalPublisher, func(), error) {
+func New(c context.Context, pubsub mypubsub.PubSub, jobs *myjobs.Jobs, eventStore myeventstore.EventStore, nower mytime.Nower) (*transactionalPublisher, func(), error) {
 	store, storeCleanup, err := mystore.New[myevents.EventEnvelope](c)
 	if err != nil {
 		return nil, nil, err
@@ -36,16 +72,41 @@ func New(c context.Context, pubsub mypubsub.PubSub, queue myqueue.TaskQueuer, no
 		storeCleanup()
 	}
 
-	return &transactionalPublisher{
-		outbox:    store,
-		queue:     queue,
-		enveloper: newEnveloper(nower),
-		pubsub:    pubsub,
-	}, cleanup, nil
+	p := &transactionalPublisher{
+		outbox:      store,
+		eventStore:  eventStore,
+		jobs:        jobs,
+		enveloper:   newEnveloper(nower),
+		pubsub:      pubsub,
+		nower:       nower,
+		batchSize:   defaultBatchSize,
+		concurrency: defaultConcurrency,
+	}
+	p.registerJobs()
+
+	return p, cleanup, nil
+}
+
+func (p *transactionalPublisher) registerJobs() {
+	// A failed flush is retried by the queue; any later trigger drains the outbox as well
+	myjobs.Register(p.jobs, myjobs.Definition[outboxTrigger]{
+		Name:      outboxFlushJobName,
+		QueueName: outboxQueueName,
+		Handler: func(c context.Context, trigger outboxTrigger) error {
+			return p.processTrigger(c, trigger.Topic, trigger.UID)
+		},
+	})
 }
 
 func (p *transactionalPublisher) RegisterEndpoints(c context.Context, router *mux.Router) {
+	// Only for triggers that were queued before the outbox was flushed via myjobs
 	router.HandleFunc("/pubsub/{topic}/{uid}", p.processTriggerToReadOutboxWebhook()).Methods("PUT")
+
+	// Admin of the outbox
+	router.HandleFunc("/pubsub/admin", p.outboxAdminPage()).Methods("GET")
+	router.HandleFunc("/pubsub/admin/{uid}/republish", p.outboxAdminRepublishPage()).Methods("POST")
+	router.HandleFunc("/api/pubsub/outbox", p.outboxAPI()).Methods("GET")
+	router.HandleFunc("/api/pubsub/outbox/{uid}/republish", p.outboxRepublishAPI()).Methods("POST")
 }
 
 func (p *transactionalPublisher) CreateTopic(c context.Context, topicName string) error {
@@ -53,25 +114,41 @@ func (p *transactionalPublisher) CreateTopic(c context.Context, topicName string
 }
 
 func (p *transactionalPublisher) Publish(c context.Context, topic string, event myevents.Event) error {
-	envelope, err := p.enveloper.do(topic, event)
+	return p.publish(c, topic, event, time.Time{})
+}
+
+// PublishAt stores the event in the outbox right away, but holds back its publication until the given moment.
+// The event is appended to the event log once it is published. Publishing the same event again replaces its schedule.
+func (p *transactionalPublisher) PublishAt(c context.Context, topic string, event myevents.Event, when time.Time) error {
+	return p.publish(c, topic, event, when)
+}
+
+func (p *transactionalPublisher) publish(c context.Context, topic string, event myevents.Event, when time.Time) error {
+	envelope, err := p.enveloper.do(c, topic, event)
 	if err != nil {
-		return fmt.Errorf("error creating envelope: %s", err)
+		return fmt.Errorf("error creating envelope: %w", err)
 	}
+	envelope.PublishAfter = envelope.CreatedAt
+	if when.After(envelope.CreatedAt) {
+		envelope.PublishAfter = when
+	}
+
 	err = p.outbox.Put(c, envelope.UID, envelope)
 	if err != nil {
-		return fmt.Errorf("error storing envelope: %s", err)
+		return fmt.Errorf("error storing envelope: %w", err)
 	}
 
-	err = p.queue.Enqueue(c, myqueue.Task{
-		UID:            envelope.UID,
-		WebhookURLPath: fmt.Sprintf("/pubsub/%s/%s", envelope.Topic, envelope.UID),
-		Payload:        []byte{},
-	})
+	// The envelope-uid does not depend on the schedule: each schedule gets its own job, so a rescheduled
+	// envelope is flushed at its new moment instead of being deduplicated against the original job.
+	err = myjobs.EnqueueAt(c, p.jobs, outboxFlushJobName, fmt.Sprintf("%s-at-%d", envelope.UID, envelope.PublishAfter.UnixNano()), outboxTrigger{
+		Topic: envelope.Topic,
+		UID:   envelope.UID,
+	}, envelope.PublishAfter)
 	if err != nil {
-		return fmt.Errorf("error queueing publication-trigger %s: %s", envelope.UID, err)
+		return fmt.Errorf("error queueing publication-trigger %s: %w", envelope.UID, err)
 	}
 
-	log.Printf("Enqueued event %s.%s on topic %s", envelope.EventTypeName, envelope.AggregateUID, envelope.Topic)
+	log.Printf("Enqueued event %s.%s on topic %s (publish after %s)", envelope.EventTypeName, envelope.AggregateUID, envelope.Topic, envelope.PublishAfter.Format(time.RFC3339))
 
 	return nil
 }
@@ -86,7 +163,7 @@ func (p *transactionalPublisher) processTriggerToReadOutboxWebhook() http.Handle
 
 		err := p.processTrigger(c, topicName, eventUID)
 		if err != nil {
-			errorWriter.WriteError(c, w, 1, err)
+			errorWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -97,38 +174,183 @@ func (p *transactionalPublisher) processTriggerToReadOutboxWebhook() http.Handle
 }
 
 func (p *transactionalPublisher) processTrigger(c context.Context, topicName string, uid string) error {
-	// fetch all envelopes that are not yet published
-	err := p.outbox.RunInTransaction(c, func(c context.Context) error {
+	err := p.backfillPublishAfter(c)
+	if err != nil {
+		return err
+	}
 
-		// fetch all envelopes that are not yet published
-		envelopes, err := p.outbox.Query(c, []mystore.Filter{{Field: "Published", Compare: "=", Value: false}}, "CreatedAt")
+	// Drain the outbox page by page. Pages are not read inside a transaction, so concurrent triggers can
+	// publish the same envelope twice: consumers are idempotent on the envelope-uid.
+	for {
+		envelopes, err := p.outbox.QueryLimit(c, []mystore.Filter{
+			{Field: "Published", Compare: "=", Value: false},
+			{Field: "PublishAfter", Compare: "<=", Value: p.nower.Now()},
+		}, "PublishAfter", p.pageSize())
 		if err != nil {
-			return fmt.Errorf("error fetching envelopes: %s", err)
+			return fmt.Errorf("error fetching envelopes: %w", err)
+		}
+		if len(envelopes) == 0 {
+			return nil
 		}
 		log.Printf("Found %d unpublished events", len(envelopes))
 
+		err = p.publishPage(c, envelopes)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// backfillPublishAfter sets PublishAfter of envelopes that were stored before it existed: the datastore
+// leaves entities without the property out of the PublishAfter query, so they would never be flushed.
+// The unpublished envelopes are walked page by page in order of creation, so reads and writes stay small.
+func (p *transactionalPublisher) backfillPublishAfter(c context.Context) error {
+	p.backfill.Lock()
+	defer p.backfill.Unlock()
+
+	if p.backfilled {
+		return nil
+	}
+
+	filters := []mystore.Filter{{Field: "Published", Compare: "=", Value: false}}
+	// envelopes created at the moment the previous page ended with: they are read again, on top of a full page
+	seen := map[string]bool{}
+	backfilled := 0
+	for {
+		limit := p.pageSize() + len(seen)
+		envelopes, err := p.outbox.QueryLimit(c, filters, "CreatedAt", limit)
+		if err != nil {
+			return fmt.Errorf("error fetching unpublished envelopes: %w", err)
+		}
+
+		uids := []string{}
+		legacy := []myevents.EventEnvelope{}
 		for _, envelope := range envelopes {
-			jsonBytes, err := json.Marshal(envelope)
-			if err != nil {
-				return fmt.Errorf("error serializing event: %s", err)
+			if seen[envelope.UID] {
+				continue
+			}
+			if envelope.PublishAfter.IsZero() {
+				envelope.PublishAfter = envelope.CreatedAt
+				uids = append(uids, envelope.UID)
+				legacy = append(legacy, envelope)
 			}
+		}
 
-			err = p.pubsub.Publish(c, envelope.Topic, string(jsonBytes))
+		if len(legacy) > 0 {
+			err = p.outbox.PutMulti(c, uids, legacy)
 			if err != nil {
-				return fmt.Errorf("error publishing event: %s", err)
+				return fmt.Errorf("error backfilling %d envelopes: %w", len(legacy), err)
 			}
+			backfilled += len(legacy)
+		}
 
-			// mark as published
-			envelope.Published = true
-			err = p.outbox.Put(c, envelope.UID, envelope)
-			if err != nil {
-				return fmt.Errorf("error store envelope: %s", err)
+		if len(envelopes) < limit {
+			break
+		}
+
+		last := envelopes[len(envelopes)-1].CreatedAt
+		filters = []mystore.Filter{
+			{Field: "Published", Compare: "=", Value: false},
+			{Field: "CreatedAt", Compare: ">=", Value: last},
+		}
+		seen = map[string]bool{}
+		for _, envelope := range envelopes {
+			if envelope.CreatedAt.Equal(last) {
+				seen[envelope.UID] = true
 			}
 		}
-		return nil
-	})
+	}
+
+	if backfilled > 0 {
+		log.Printf("Backfilled publish-after of %d envelopes", backfilled)
+	}
+
+	p.backfilled = true
+
+	return nil
+}
+
+func (p *transactionalPublisher) pageSize() int {
+	if p.batchSize <= 0 {
+		return defaultBatchSize
+	}
+	return p.batchSize
+}
+
+func (p *transactionalPublisher) maxInFlight() int {
+	if p.concurrency <= 0 {
+		return defaultConcurrency
+	}
+	return p.concurrency
+}
+
+// publishPage publishes the envelopes in parallel, appends them to the event log and marks the successful ones
+// as published in a single batch
+func (p *transactionalPublisher) publishPage(c context.Context, envelopes []myevents.EventEnvelope) error {
+	errs := make([]error, len(envelopes))
+
+	semaphore := make(chan struct{}, p.maxInFlight())
+	wg := sync.WaitGroup{}
+	for idx, envelope := range envelopes {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(idx int, envelope myevents.EventEnvelope) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			errs[idx] = p.publishEnvelope(c, envelope)
+		}(idx, envelope)
+	}
+	wg.Wait()
+
+	// appended in order of the page, so the event log of an aggregate keeps the order of publication
+	for idx, envelope := range envelopes {
+		if errs[idx] != nil {
+			continue
+		}
+		err := p.eventStore.Append(c, envelope)
+		if err != nil {
+			errs[idx] = fmt.Errorf("error appending envelope to event log: %w", err)
+		}
+	}
+
+	publishedUIDs := []string{}
+	published := []myevents.EventEnvelope{}
+	failures := []string{}
+	for idx, envelope := range envelopes {
+		if errs[idx] != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", envelope.UID, errs[idx]))
+			continue
+		}
+		envelope.Published = true
+		publishedUIDs = append(publishedUIDs, envelope.UID)
+		published = append(published, envelope)
+	}
+
+	if len(published) > 0 {
+		err := p.outbox.PutMulti(c, publishedUIDs, published)
+		if err != nil {
+			return fmt.Errorf("error marking %d envelopes as published: %w", len(published), err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("error publishing %d of %d events: %s", len(failures), len(envelopes), strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+func (p *transactionalPublisher) publishEnvelope(c context.Context, envelope myevents.EventEnvelope) error {
+	jsonBytes, err := json.Marshal(envelope)
 	if err != nil {
-		return err
+		return fmt.Errorf("error serializing event: %w", err)
+	}
+
+	err = p.pubsub.Publish(c, envelope.Topic, string(jsonBytes), envelope.Attributes())
+	if err != nil {
+		return fmt.Errorf("error publishing event: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,314 @@
+package mypublisher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/myeventstore"
+	"github.com/MarcGrol/shopbackend/lib/myjobs"
+	"github.com/MarcGrol/shopbackend/lib/mypubsub"
+	"github.com/MarcGrol/shopbackend/lib/myqueue"
+	"github.com/MarcGrol/shopbackend/lib/mystore"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+func TestProcessTrigger(t *testing.T) {
+	t.Run("Drain outbox in pages", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, outbox, pubsub := setupPublisher(t, ctrl, 5)
+
+		// given
+		pubsub.EXPECT().Publish(gomock.Any(), "checkout", gomock.Any(), gomock.Any()).Return(nil).Times(5)
+
+		// when
+		err := sut.processTrigger(c, "checkout", "1")
+
+		// then
+		assert.NoError(t, err)
+		assert.Empty(t, unpublishedUIDs(t, outbox))
+		events, err := sut.eventStore.ListByAggregate(c, "basket-1")
+		assert.NoError(t, err)
+		assert.Len(t, events, 5)
+		assert.Equal(t, "1", events[0].UID)
+		assert.Equal(t, "5", events[4].UID)
+	})
+
+	t.Run("Envelope stored before publish-after existed is flushed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, outbox, pubsub := setupPublisher(t, ctrl, 0)
+		err := outbox.Put(c, "legacy", myevents.EventEnvelope{
+			UID:       "legacy",
+			CreatedAt: mytime.ExampleTime,
+			Topic:     "checkout",
+		})
+		assert.NoError(t, err)
+
+		// given
+		pubsub.EXPECT().Publish(gomock.Any(), "checkout", gomock.Any(), gomock.Any()).Return(nil)
+
+		// when
+		err = sut.processTrigger(c, "checkout", "legacy")
+
+		// then
+		assert.NoError(t, err)
+		envelope, _, err := outbox.Get(c, "legacy")
+		assert.NoError(t, err)
+		assert.True(t, envelope.Published)
+		assert.Equal(t, mytime.ExampleTime, envelope.PublishAfter)
+	})
+
+	t.Run("Envelopes stored before publish-after existed are backfilled in pages", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, outbox, _ := setupPublisher(t, ctrl, 0)
+		for i := 1; i <= 5; i++ {
+			uid := fmt.Sprintf("legacy-%d", i)
+			err := outbox.Put(c, uid, myevents.EventEnvelope{
+				UID: uid,
+				// pages end in the middle of envelopes created at the same moment
+				CreatedAt: mytime.ExampleTime.Add(time.Duration(i/2) * time.Second),
+				Topic:     "checkout",
+			})
+			assert.NoError(t, err)
+		}
+
+		// when
+		err := sut.backfillPublishAfter(c)
+
+		// then
+		assert.NoError(t, err)
+		envelopes, err := outbox.List(c)
+		assert.NoError(t, err)
+		assert.Len(t, envelopes, 5)
+		for _, envelope := range envelopes {
+			assert.Equal(t, envelope.CreatedAt, envelope.PublishAfter, envelope.UID)
+		}
+	})
+
+	t.Run("Flush job drains outbox", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, outbox, pubsub := setupPublisher(t, ctrl, 3)
+		jobs, _, err := myjobs.New(c, myqueue.NewMockTaskQueuer(ctrl), mytime.RealNower{})
+		assert.NoError(t, err)
+		sut.jobs = jobs
+		sut.registerJobs()
+		router := mux.NewRouter()
+		jobs.RegisterEndpoints(c, router)
+
+		// given
+		pubsub.EXPECT().Publish(gomock.Any(), "checkout", gomock.Any(), gomock.Any()).Return(nil).Times(3)
+
+		// when
+		request, err := http.NewRequest(http.MethodPut, "/jobs/outbox.flush/1", strings.NewReader(`{"Topic":"checkout","UID":"1"}`))
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 200, response.Code)
+		assert.Empty(t, unpublishedUIDs(t, outbox))
+	})
+
+	t.Run("Failed publication keeps envelope in outbox", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, outbox, pubsub := setupPublisher(t, ctrl, 5)
+
+		// given
+		pubsub.EXPECT().Publish(gomock.Any(), "checkout", gomock.Any(), gomock.Any()).DoAndReturn(
+			func(c context.Context, topic string, data string, attributes map[string]string) error {
+				if attributes[myevents.AttributeUID] == "3" {
+					return fmt.Errorf("pubsub unavailable")
+				}
+				return nil
+			}).Times(4)
+
+		// when
+		err := sut.processTrigger(c, "checkout", "1")
+
+		// then
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error publishing 1 of 2 events")
+		assert.Equal(t, []string{"3", "5"}, unpublishedUIDs(t, outbox))
+		events, err := sut.eventStore.ListByAggregate(c, "basket-1")
+		assert.NoError(t, err)
+		assert.Len(t, events, 3)
+	})
+}
+
+func TestPublishAt(t *testing.T) {
+	t.Run("Scheduled publication triggers later", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, outbox, _ := setupPublisher(t, ctrl, 0)
+		eventStore := myeventstore.NewMockEventStore(ctrl)
+		queue := myqueue.NewMockTaskQueuer(ctrl)
+		jobs, _, err := myjobs.New(c, queue, mytime.RealNower{})
+		assert.NoError(t, err)
+		sut.eventStore = eventStore
+		sut.jobs = jobs
+		sut.registerJobs()
+		sut.enveloper = newEnveloper(sut.nower)
+		when := mytime.ExampleTime.Add(30 * time.Minute)
+
+		// given
+		// appended once published, not when scheduled
+		eventStore.EXPECT().Append(gomock.Any(), gomock.Any()).Times(0)
+		queue.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(c context.Context, task myqueue.Task) error {
+			assert.Equal(t, "outbox", task.QueueName)
+			assert.Equal(t, when, task.ScheduleTime)
+			return nil
+		})
+
+		// when
+		err = sut.PublishAt(c, "checkout", testEvent{UID: "123"}, when)
+
+		// then
+		assert.NoError(t, err)
+		envelopes, err := outbox.Query(c, []mystore.Filter{}, "")
+		assert.NoError(t, err)
+		assert.Len(t, envelopes, 1)
+		assert.Equal(t, when, envelopes[0].PublishAfter)
+	})
+
+	t.Run("Rescheduled publication triggers at the new moment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, outbox, _ := setupPublisher(t, ctrl, 0)
+		queue := myqueue.NewMockTaskQueuer(ctrl)
+		jobs, _, err := myjobs.New(c, queue, mytime.RealNower{})
+		assert.NoError(t, err)
+		sut.jobs = jobs
+		sut.registerJobs()
+		sut.enveloper = newEnveloper(sut.nower)
+		first := mytime.ExampleTime.Add(30 * time.Minute)
+		second := mytime.ExampleTime.Add(2 * time.Hour)
+
+		// given
+		tasks := []myqueue.Task{}
+		queue.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(c context.Context, task myqueue.Task) error {
+			tasks = append(tasks, task)
+			return nil
+		}).Times(2)
+		err = sut.PublishAt(c, "checkout", testEvent{UID: "123"}, first)
+		assert.NoError(t, err)
+
+		// when
+		err = sut.PublishAt(c, "checkout", testEvent{UID: "123"}, second)
+
+		// then
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 2)
+		assert.NotEqual(t, tasks[0].UID, tasks[1].UID)
+		assert.Equal(t, second, tasks[1].ScheduleTime)
+		envelopes, err := outbox.Query(c, []mystore.Filter{}, "")
+		assert.NoError(t, err)
+		assert.Len(t, envelopes, 1)
+		assert.Equal(t, second, envelopes[0].PublishAfter)
+	})
+
+	t.Run("Scheduled envelope is held back until due", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c := context.TODO()
+		sut, outbox, pubsub := setupPublisher(t, ctrl, 2)
+		err := outbox.Put(c, "3", myevents.EventEnvelope{
+			UID:          "3",
+			CreatedAt:    mytime.ExampleTime,
+			Topic:        "checkout",
+			PublishAfter: mytime.ExampleTime.Add(time.Hour),
+		})
+		assert.NoError(t, err)
+
+		// given
+		pubsub.EXPECT().Publish(gomock.Any(), "checkout", gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+		// when
+		err = sut.processTrigger(c, "checkout", "1")
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"3"}, unpublishedUIDs(t, outbox))
+	})
+}
+
+func setupPublisher(t *testing.T, ctrl *gomock.Controller, count int) (*transactionalPublisher, mystore.Store[myevents.EventEnvelope], *mypubsub.MockPubSub) {
+	c := context.TODO()
+	outbox, _, err := mystore.NewInMemoryStore[myevents.EventEnvelope](c)
+	assert.NoError(t, err)
+	pubsub := mypubsub.NewMockPubSub(ctrl)
+	nower := mytime.NewMockNower(ctrl)
+	nower.EXPECT().Now().Return(mytime.ExampleTime.Add(time.Minute)).AnyTimes()
+
+	for i := 1; i <= count; i++ {
+		uid := fmt.Sprintf("%d", i)
+		err := outbox.Put(c, uid, myevents.EventEnvelope{
+			UID:           uid,
+			CreatedAt:     mytime.ExampleTime.Add(time.Duration(i) * time.Second),
+			PublishAfter:  mytime.ExampleTime.Add(time.Duration(i) * time.Second),
+			Topic:         "checkout",
+			AggregateUID:  "basket-1",
+			EventTypeName: "checkout.completed",
+		})
+		assert.NoError(t, err)
+	}
+
+	eventStore, _, err := myeventstore.New(c)
+	assert.NoError(t, err)
+
+	return &transactionalPublisher{
+		outbox:      outbox,
+		eventStore:  eventStore,
+		pubsub:      pubsub,
+		nower:       nower,
+		batchSize:   2,
+		concurrency: 2,
+	}, outbox, pubsub
+}
+
+func unpublishedUIDs(t *testing.T, outbox mystore.Store[myevents.EventEnvelope]) []string {
+	envelopes, err := outbox.Query(context.TODO(), []mystore.Filter{{Field: "Published", Compare: "=", Value: false}}, "CreatedAt")
+	assert.NoError(t, err)
+
+	uids := []string{}
+	for _, envelope := range envelopes {
+		uids = append(uids, envelope.UID)
+	}
+	return uids
+}
@@ -4,9 +4,11 @@ import "context"
 
 //go:generate mockgen -source=pubsub_api.go -package mypubsub -destination pubsub_mock.go PubSub
 type PubSub interface {
-	Publish(c context.Context, topic string, data string) error
+	Publish(c context.Context, topic string, data string, attributes map[string]string) error
 	CreateTopic(c context.Context, topic string) error
 	Subscribe(c context.Context, topic string, urlToPostTo string) error
+	// HealthCheck verifies that the broker can be reached
+	HealthCheck(c context.Context) error
 }
 
 var New func(c context.Context) (PubSub, func(), error)
@@ -9,7 +9,7 @@ type fakePubSub struct {
 }
 
 func init() {
-	if os.Getenv("GOOGLE_CLOUD_PROJECT") == "" {
+	if os.Getenv("GOOGLE_CLOUD_PROJECT") == "" && os.Getenv("NATS_URL") == "" {
 		New = newFakePubSub
 	}
 }
@@ -27,6 +27,10 @@ func (q *fakePubSub) CreateTopic(c context.Context, topic string) error {
 	return nil
 }
 
-func (q *fakePubSub) Publish(c context.Context, topic string, data string) error {
+func (q *fakePubSub) Publish(c context.Context, topic string, data string, attributes map[string]string) error {
+	return nil
+}
+
+func (q *fakePubSub) HealthCheck(c context.Context) error {
 	return nil
 }
@@ -5,17 +5,20 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
 
 	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/iterator"
 )
 
 type gcloudPubSub struct {
 	client *pubsub.Client
+	sync.Mutex
 	topics map[string]*pubsub.Topic
 }
 
 func init() {
-	if os.Getenv("GOOGLE_CLOUD_PROJECT") != "" {
+	if os.Getenv("GOOGLE_CLOUD_PROJECT") != "" && os.Getenv("NATS_URL") == "" {
 		New = newGcloudPubSub
 	}
 }
@@ -25,12 +28,16 @@ func newGcloudPubSub(c context.Context) (PubSub, func(), error) {
 	if err != nil {
 		return nil, func() {}, err
 	}
+	return newGcloudPubSubWithClient(client), func() {
+		client.Close()
+	}, nil
+}
+
+func newGcloudPubSubWithClient(client *pubsub.Client) *gcloudPubSub {
 	return &gcloudPubSub{
-			client: client,
-			topics: map[string]*pubsub.Topic{},
-		}, func() {
-			client.Close()
-		}, nil
+		client: client,
+		topics: map[string]*pubsub.Topic{},
+	}
 }
 
 func (ps *gcloudPubSub) Subscribe(c context.Context, topicName string, urlToPostTo string) error {
@@ -42,7 +49,7 @@ func (ps *gcloudPubSub) Subscribe(c context.Context, topicName string, urlToPost
 	subscription := ps.client.Subscription(topicName)
 	exists, err := subscription.Exists(c)
 	if err != nil {
-		return fmt.Errorf("error checking if subscription %s (%s) exists: %s", topicName, urlToPostTo, err)
+		return fmt.Errorf("error checking if subscription %s (%s) exists: %w", topicName, urlToPostTo, err)
 	}
 
 	if exists {
@@ -58,7 +65,7 @@ func (ps *gcloudPubSub) Subscribe(c context.Context, topicName string, urlToPost
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("error subscribing to topic %s (%s): %s", topicName, urlToPostTo, err)
+		return fmt.Errorf("error subscribing to topic %s (%s): %w", topicName, urlToPostTo, err)
 	}
 
 	log.Printf("*** Subscribed to topic %s (%s)", topic.String(), urlToPostTo)
@@ -70,7 +77,7 @@ func (ps *gcloudPubSub) CreateTopic(c context.Context, topicName string) error {
 	topic := ps.client.Topic(topicName)
 	exists, err := topic.Exists(c)
 	if err != nil {
-		return fmt.Errorf("error checking if topic %s exists: %s", topicName, err)
+		return fmt.Errorf("error checking if topic %s exists: %w", topicName, err)
 	}
 
 	if exists {
@@ -80,26 +87,39 @@ func (ps *gcloudPubSub) CreateTopic(c context.Context, topicName string) error {
 
 	_, err = ps.client.CreateTopic(c, topicName)
 	if err != nil {
-		return fmt.Errorf("error creating topic %s: %s", topicName, err)
+		return fmt.Errorf("error creating topic %s: %w", topicName, err)
 	}
-	ps.topics[topicName] = topic
-
 	log.Printf("*** Created topic %s", topicName)
 
 	return nil
 }
 
-func (ps *gcloudPubSub) Publish(c context.Context, topicName string, data string) error {
+func (ps *gcloudPubSub) Publish(c context.Context, topicName string, data string, attributes map[string]string) error {
+	_, err := ps.topic(topicName).Publish(c, &pubsub.Message{Data: []byte(data), Attributes: attributes}).Get(c)
+	if err != nil {
+		return fmt.Errorf("error publishing event on topic %s: %w", topicName, err)
+	}
+
+	return nil
+}
+
+// topic returns the cached topic handle; publishers run in parallel so the cache is guarded
+func (ps *gcloudPubSub) topic(topicName string) *pubsub.Topic {
+	ps.Lock()
+	defer ps.Unlock()
+
 	topic, found := ps.topics[topicName]
 	if !found {
 		topic = ps.client.Topic(topicName)
 		ps.topics[topicName] = topic
 	}
+	return topic
+}
 
-	_, err := topic.Publish(c, &pubsub.Message{Data: []byte(data)}).Get(c)
-	if err != nil {
-		return fmt.Errorf("error publishing event on topic %s: %s", topicName, err)
+func (ps *gcloudPubSub) HealthCheck(c context.Context) error {
+	_, err := ps.client.Topics(c).Next()
+	if err != nil && err != iterator.Done {
+		return fmt.Errorf("error listing topics: %w", err)
 	}
-
 	return nil
 }
@@ -0,0 +1,59 @@
+package mypubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestGcloudPublishConcurrently(t *testing.T) {
+	// setup
+	c := context.TODO()
+	server := pstest.NewServer()
+	defer server.Close()
+
+	conn, err := grpc.NewClient(server.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	client, err := pubsub.NewClient(c, "test", option.WithGRPCConn(conn))
+	assert.NoError(t, err)
+	defer client.Close()
+
+	ps := newGcloudPubSubWithClient(client)
+
+	// given
+	topicNames := []string{"shop.basket", "oauth.session"}
+	for _, topicName := range topicNames {
+		err = ps.CreateTopic(c, topicName)
+		assert.NoError(t, err)
+	}
+
+	// when
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- ps.Publish(c, topicNames[i%len(topicNames)], fmt.Sprintf("message %d", i), map[string]string{"index": fmt.Sprintf("%d", i)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	// then
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Len(t, server.Messages(), 20)
+	assert.Len(t, ps.topics, len(topicNames))
+}
@@ -54,18 +54,32 @@ func (mr *MockPubSubMockRecorder) CreateTopic(c, topic any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTopic", reflect.TypeOf((*MockPubSub)(nil).CreateTopic), c, topic)
 }
 
+// HealthCheck mocks base method.
+func (m *MockPubSub) HealthCheck(c context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthCheck", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HealthCheck indicates an expected call of HealthCheck.
+func (mr *MockPubSubMockRecorder) HealthCheck(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockPubSub)(nil).HealthCheck), c)
+}
+
 // Publish mocks base method.
-func (m *MockPubSub) Publish(c context.Context, topic, data string) error {
+func (m *MockPubSub) Publish(c context.Context, topic, data string, attributes map[string]string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Publish", c, topic, data)
+	ret := m.ctrl.Call(m, "Publish", c, topic, data, attributes)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Publish indicates an expected call of Publish.
-func (mr *MockPubSubMockRecorder) Publish(c, topic, data any) *gomock.Call {
+func (mr *MockPubSubMockRecorder) Publish(c, topic, data, attributes any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockPubSub)(nil).Publish), c, topic, data)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockPubSub)(nil).Publish), c, topic, data, attributes)
 }
 
 // Subscribe mocks base method.
@@ -0,0 +1,205 @@
+package mypubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+)
+
+const (
+	natsMaxDeliveries = 10
+	natsRetryDelay    = 5 * time.Second
+)
+
+var natsInvalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// natsPubSub maps topics onto JetStream streams and push-subscriptions onto durable consumers
+// that deliver messages to an url, in the same format as Google pubsub push-subscriptions.
+type natsPubSub struct {
+	conn       *nats.Conn
+	js         jetstream.JetStream
+	httpClient *http.Client
+	sync.Mutex
+	consumers []jetstream.ConsumeContext
+}
+
+func init() {
+	if os.Getenv("NATS_URL") != "" {
+		New = newNatsPubSub
+	}
+}
+
+func newNatsPubSub(c context.Context) (PubSub, func(), error) {
+	conn, err := nats.Connect(os.Getenv("NATS_URL"), nats.Name("shopbackend"))
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("error connecting to nats %s: %w", os.Getenv("NATS_URL"), err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, func() {}, fmt.Errorf("error creating jetstream context: %w", err)
+	}
+
+	ps := &natsPubSub{
+		conn: conn,
+		js:   js,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		consumers: []jetstream.ConsumeContext{},
+	}
+
+	return ps, func() {
+		ps.Lock()
+		defer ps.Unlock()
+		for _, consumer := range ps.consumers {
+			consumer.Stop()
+		}
+		conn.Drain()
+	}, nil
+}
+
+func (ps *natsPubSub) CreateTopic(c context.Context, topicName string) error {
+	_, err := ps.js.CreateOrUpdateStream(c, jetstream.StreamConfig{
+		Name:     streamName(topicName),
+		Subjects: []string{topicName},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating stream for topic %s: %w", topicName, err)
+	}
+
+	log.Printf("*** Created stream for topic %s", topicName)
+
+	return nil
+}
+
+func (ps *natsPubSub) Subscribe(c context.Context, topicName string, urlToPostTo string) error {
+	err := ps.CreateTopic(c, topicName)
+	if err != nil {
+		return err
+	}
+
+	durableName := consumerName(topicName, urlToPostTo)
+	consumer, err := ps.js.CreateOrUpdateConsumer(c, streamName(topicName), jetstream.ConsumerConfig{
+		Durable:       durableName,
+		Description:   urlToPostTo,
+		FilterSubject: topicName,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		MaxDeliver:    natsMaxDeliveries,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating consumer for topic %s (%s): %w", topicName, urlToPostTo, err)
+	}
+
+	consumeContext, err := consumer.Consume(func(msg jetstream.Msg) {
+		ps.push(durableName, urlToPostTo, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("error consuming topic %s (%s): %w", topicName, urlToPostTo, err)
+	}
+
+	ps.Lock()
+	ps.consumers = append(ps.consumers, consumeContext)
+	ps.Unlock()
+
+	log.Printf("*** Subscribed to topic %s (%s)", topicName, urlToPostTo)
+
+	return nil
+}
+
+func (ps *natsPubSub) push(durableName string, urlToPostTo string, msg jetstream.Msg) {
+	err := ps.postMessage(durableName, urlToPostTo, msg)
+	if err != nil {
+		log.Printf("Error pushing message to %s: %s", urlToPostTo, err)
+		err = msg.NakWithDelay(natsRetryDelay)
+		if err != nil {
+			log.Printf("Error nak-ing message for %s: %s", urlToPostTo, err)
+		}
+		return
+	}
+
+	err = msg.Ack()
+	if err != nil {
+		log.Printf("Error ack-ing message for %s: %s", urlToPostTo, err)
+	}
+}
+
+func (ps *natsPubSub) postMessage(durableName string, urlToPostTo string, msg jetstream.Msg) error {
+	messageID := ""
+	metadata, err := msg.Metadata()
+	if err == nil {
+		messageID = fmt.Sprintf("%d", metadata.Sequence.Stream)
+	}
+
+	attributes := map[string]string{}
+	for key := range msg.Headers() {
+		attributes[key] = msg.Headers().Get(key)
+	}
+
+	body, err := json.Marshal(myevents.PushRequest{
+		Message: myevents.PushMessage{
+			Attributes: attributes,
+			Data:       msg.Data(),
+			ID:         messageID,
+		},
+		Subscription: durableName,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling push-request: %w", err)
+	}
+
+	resp, err := ps.httpClient.Post(urlToPostTo, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error posting push-request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("push-request was rejected with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (ps *natsPubSub) Publish(c context.Context, topicName string, data string, attributes map[string]string) error {
+	msg := nats.NewMsg(topicName)
+	msg.Data = []byte(data)
+	for key, value := range attributes {
+		msg.Header.Set(key, value)
+	}
+
+	_, err := ps.js.PublishMsg(c, msg)
+	if err != nil {
+		return fmt.Errorf("error publishing event on topic %s: %w", topicName, err)
+	}
+
+	return nil
+}
+
+func streamName(topicName string) string {
+	return natsInvalidNameChars.ReplaceAllString(topicName, "_")
+}
+
+func consumerName(topicName string, urlToPostTo string) string {
+	return natsInvalidNameChars.ReplaceAllString(topicName+"_"+urlToPostTo, "_")
+}
+
+func (ps *natsPubSub) HealthCheck(c context.Context) error {
+	if !ps.conn.IsConnected() {
+		return fmt.Errorf("nats connection is %s", ps.conn.Status())
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package mypubsub
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+)
+
+// fakeMsg only implements the part of jetstream.Msg that is used when pushing
+type fakeMsg struct {
+	jetstream.Msg
+	data       []byte
+	headers    nats.Header
+	sequence   uint64
+	acked      bool
+	nakedDelay time.Duration
+}
+
+func (m *fakeMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{Sequence: jetstream.SequencePair{Stream: m.sequence}}, nil
+}
+
+func (m *fakeMsg) Data() []byte {
+	return m.data
+}
+
+func (m *fakeMsg) Headers() nats.Header {
+	return m.headers
+}
+
+func (m *fakeMsg) Ack() error {
+	m.acked = true
+	return nil
+}
+
+func (m *fakeMsg) NakWithDelay(delay time.Duration) error {
+	m.nakedDelay = delay
+	return nil
+}
+
+func newFakeMsg() *fakeMsg {
+	headers := nats.Header{}
+	headers.Set("eventTypeName", "shop.basket.finalized")
+	headers.Set("aggregateUID", "123")
+	return &fakeMsg{
+		data:     []byte(`{"uid":"abc"}`),
+		headers:  headers,
+		sequence: 42,
+	}
+}
+
+func TestNatsPush(t *testing.T) {
+	t.Run("Push request has the format of a Google pubsub push-subscription", func(t *testing.T) {
+		// setup
+		var received myevents.PushRequest
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			err := json.NewDecoder(r.Body).Decode(&received)
+			assert.NoError(t, err)
+		}))
+		defer ts.Close()
+		sut := &natsPubSub{httpClient: ts.Client()}
+
+		// when
+		err := sut.postMessage("shop_consumer", ts.URL, newFakeMsg())
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, myevents.PushRequest{
+			Message: myevents.PushMessage{
+				Attributes: map[string]string{"eventTypeName": "shop.basket.finalized", "aggregateUID": "123"},
+				Data:       []byte(`{"uid":"abc"}`),
+				ID:         "42",
+			},
+			Subscription: "shop_consumer",
+		}, received)
+	})
+
+	testCases := []struct {
+		name       string
+		httpStatus int
+		acked      bool
+		nakedDelay time.Duration
+	}{
+		{name: "Accepted message is acked", httpStatus: 200, acked: true},
+		{name: "No content is acked", httpStatus: 204, acked: true},
+		{name: "Rejected message is nak-ed for redelivery", httpStatus: 400, nakedDelay: natsRetryDelay},
+		{name: "Failing message is nak-ed for redelivery", httpStatus: 500, nakedDelay: natsRetryDelay},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// setup
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.httpStatus)
+			}))
+			defer ts.Close()
+			sut := &natsPubSub{httpClient: ts.Client()}
+			msg := newFakeMsg()
+
+			// when
+			sut.push("shop_consumer", ts.URL, msg)
+
+			// then
+			assert.Equal(t, tc.acked, msg.acked)
+			assert.Equal(t, tc.nakedDelay, msg.nakedDelay)
+		})
+	}
+
+	t.Run("Unreachable endpoint is nak-ed for redelivery", func(t *testing.T) {
+		// setup
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		ts.Close()
+		sut := &natsPubSub{httpClient: &http.Client{}}
+		msg := newFakeMsg()
+
+		// when
+		sut.push("shop_consumer", ts.URL, msg)
+
+		// then
+		assert.False(t, msg.acked)
+		assert.Equal(t, natsRetryDelay, msg.nakedDelay)
+	})
+}
+
+func TestNatsNames(t *testing.T) {
+	assert.Equal(t, "shop_basket", streamName("shop.basket"))
+	assert.Equal(t, "shop_basket_http___localhost_8080_shop_event", consumerName("shop.basket", "http://localhost:8080/shop/event"))
+}
@@ -2,13 +2,39 @@ package myqueue
 
 import (
 	"context"
+	"time"
 )
 
+// defaultDelay gives the caller some time to complete its own work (like a redirect) before the task is delivered
+const defaultDelay = 5 * time.Second
+
 type Task struct {
+	// QueueName refers to a registered queue; empty means the default queue
+	QueueName      string
 	UID            string
 	WebhookURLPath string
-	Payload        []byte
-	IsLastAttempt  bool
+	// WebhookURL is an absolute URL that takes precedence over the WebhookURLPath
+	WebhookURL    string
+	Headers       map[string]string
+	Payload       []byte
+	IsLastAttempt bool
+	// ScheduleTime is the moment the task should be delivered; a zero value means "as soon as possible"
+	ScheduleTime time.Time
+}
+
+func (t Task) scheduleTime(now time.Time, minDelay time.Duration) time.Time {
+	earliest := now.Add(minDelay)
+	if t.ScheduleTime.Before(earliest) {
+		return earliest
+	}
+	return t.ScheduleTime
+}
+
+// TaskInfo describes a pending task
+type TaskInfo struct {
+	Task
+	CreatedAt     time.Time
+	DispatchCount int32
 }
 
 var New func(c context.Context) (TaskQueuer, func(), error)
@@ -16,5 +42,13 @@ var New func(c context.Context) (TaskQueuer, func(), error)
 //go:generate mockgen -source=api.go -package myqueue -destination queuer_mock.go TaskQueuer
 type TaskQueuer interface {
 	Enqueue(c context.Context, task Task) error
-	IsLastAttempt(c context.Context, taskUID string) (int32, int32)
+	IsLastAttempt(c context.Context, queueName string, taskUID string) (int32, int32)
+	// Reconcile creates or updates the registered queues to match their definitions
+	Reconcile(c context.Context) error
+	Get(c context.Context, queueName string, taskUID string) (TaskInfo, bool, error)
+	// Delete cancels a pending task; deleting an unknown task is not an error
+	Delete(c context.Context, queueName string, taskUID string) error
+	List(c context.Context, queueName string) ([]TaskInfo, error)
+	// HealthCheck verifies that tasks can be enqueued
+	HealthCheck(c context.Context) error
 }
@@ -0,0 +1,24 @@
+package myqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleTime(t *testing.T) {
+	now := time.Date(2023, time.February, 28, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Default delay", func(t *testing.T) {
+		assert.Equal(t, now.Add(5*time.Second), Task{}.scheduleTime(now, defaultDelay))
+	})
+
+	t.Run("Scheduled in the past", func(t *testing.T) {
+		assert.Equal(t, now.Add(5*time.Second), Task{ScheduleTime: now.Add(-time.Hour)}.scheduleTime(now, defaultDelay))
+	})
+
+	t.Run("Scheduled in the future", func(t *testing.T) {
+		assert.Equal(t, now.Add(30*time.Minute), Task{ScheduleTime: now.Add(30 * time.Minute)}.scheduleTime(now, defaultDelay))
+	})
+}
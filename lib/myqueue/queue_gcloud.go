@@ -5,17 +5,22 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path"
 	"time"
 
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
 	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"google.golang.org/api/iterator"
 	grpcCodes "google.golang.org/grpc/codes"
 	grpcStatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type gcloudTaskQueue struct {
 	client *cloudtasks.Client
+	target Target
 }
 
 func init() {
@@ -27,32 +32,27 @@ func init() {
 func newGcloudQueue(c context.Context) (TaskQueuer, func(), error) {
 	cloudTaskClient, err := cloudtasks.NewClient(c)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error creating cloudtask-client: %s", err)
+		return nil, nil, fmt.Errorf("error creating cloudtask-client: %w", err)
 	}
 
 	return &gcloudTaskQueue{
 			client: cloudTaskClient,
+			target: targetFromEnv(),
 		}, func() {
 			cloudTaskClient.Close()
 		}, nil
 }
 
 func (q *gcloudTaskQueue) Enqueue(c context.Context, task Task) error {
-	taskUID := composeTaskName(task.UID)
-	_, err := q.client.CreateTask(c, &taskspb.CreateTaskRequest{
-		Parent: composeQueueName(),
-		Task: &taskspb.Task{
-			Name:         taskUID,                                          // de-duplicate
-			ScheduleTime: timestamppb.New(time.Now().Add(time.Second * 5)), // delay to give shop some time for redirect
-			MessageType: &taskspb.Task_AppEngineHttpRequest{
-				AppEngineHttpRequest: &taskspb.AppEngineHttpRequest{
-					HttpMethod:  taskspb.HttpMethod_PUT,
-					RelativeUri: task.WebhookURLPath,
-					Body:        task.Payload,
-				},
-			},
-			View: taskspb.Task_FULL,
-		},
+	definition, err := queueDefinition(task.QueueName)
+	if err != nil {
+		return err
+	}
+
+	taskUID := composeTaskName(definition.Name, task.UID)
+	_, err = q.client.CreateTask(c, &taskspb.CreateTaskRequest{
+		Parent: composeQueueName(definition.Name),
+		Task:   q.target.newTask(taskUID, task, task.scheduleTime(time.Now(), defaultDelay)),
 	})
 	if err != nil {
 		rsp, ok := grpcStatus.FromError(err)
@@ -62,31 +62,113 @@ func (q *gcloudTaskQueue) Enqueue(c context.Context, task Task) error {
 			return nil
 		}
 
-		return fmt.Errorf("error submitting task to queue: %s", err)
+		return fmt.Errorf("error submitting task to queue: %w", err)
 	}
 
 	return nil
 }
 
-func composeQueueName() string {
+// newTask creates an HTTP-target when an absolute URL is known and an App Engine target otherwise
+func (t Target) newTask(taskName string, task Task, scheduleTime time.Time) *taskspb.Task {
+	cloudTask := &taskspb.Task{
+		Name:         taskName, // de-duplicate
+		ScheduleTime: timestamppb.New(scheduleTime),
+		View:         taskspb.Task_FULL,
+	}
+
+	url, absolute := t.url(task)
+	if !absolute {
+		cloudTask.MessageType = &taskspb.Task_AppEngineHttpRequest{
+			AppEngineHttpRequest: &taskspb.AppEngineHttpRequest{
+				HttpMethod:  taskspb.HttpMethod_PUT,
+				RelativeUri: task.WebhookURLPath,
+				Headers:     task.Headers,
+				Body:        task.Payload,
+			},
+		}
+		return cloudTask
+	}
+
+	httpRequest := &taskspb.HttpRequest{
+		HttpMethod: taskspb.HttpMethod_PUT,
+		Url:        url,
+		Headers:    task.Headers,
+		Body:       task.Payload,
+	}
+	if t.ServiceAccountEmail != "" {
+		httpRequest.AuthorizationHeader = &taskspb.HttpRequest_OidcToken{
+			OidcToken: &taskspb.OidcToken{
+				ServiceAccountEmail: t.ServiceAccountEmail,
+				Audience:            t.audience(),
+			},
+		}
+	}
+	cloudTask.MessageType = &taskspb.Task_HttpRequest{
+		HttpRequest: httpRequest,
+	}
+
+	return cloudTask
+}
+
+func composeLocationName() string {
 	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
 	locationID := os.Getenv("LOCATION_ID")
-	queueName := os.Getenv("QUEUE_NAME")
-	if queueName == "" {
-		queueName = "default"
-	}
-	return fmt.Sprintf("projects/%s/locations/%s/queues/%s", projectID, locationID, queueName)
+	return fmt.Sprintf("projects/%s/locations/%s", projectID, locationID)
 }
 
-func composeTaskName(taskUID string) string {
-	return fmt.Sprintf("%s/tasks/%s", composeQueueName(), taskUID)
+func composeQueueName(queueName string) string {
+	return fmt.Sprintf("%s/queues/%s", composeLocationName(), queueName)
 }
 
-func (q *gcloudTaskQueue) IsLastAttempt(c context.Context, taskUID string) (int32, int32) {
+func composeTaskName(queueName string, taskUID string) string {
+	return fmt.Sprintf("%s/tasks/%s", composeQueueName(queueName), taskUID)
+}
+
+// Reconcile creates the registered queues that do not exist yet and updates the limits of the existing ones
+func (q *gcloudTaskQueue) Reconcile(c context.Context) error {
+	for _, definition := range RegisteredQueues() {
+		_, err := q.client.UpdateQueue(c, &taskspb.UpdateQueueRequest{
+			Queue: &taskspb.Queue{
+				Name: composeQueueName(definition.Name),
+				RateLimits: &taskspb.RateLimits{
+					MaxDispatchesPerSecond:  definition.MaxDispatchesPerSecond,
+					MaxConcurrentDispatches: definition.MaxConcurrentDispatches,
+				},
+				RetryConfig: &taskspb.RetryConfig{
+					MaxAttempts: definition.MaxAttempts,
+					MinBackoff:  durationpb.New(definition.MinBackoff),
+					MaxBackoff:  durationpb.New(definition.MaxBackoff),
+				},
+			},
+			// Only touch the fields we manage: UpdateQueue creates the queue when it does not exist
+			UpdateMask: &fieldmaskpb.FieldMask{
+				Paths: []string{
+					"rate_limits.max_dispatches_per_second",
+					"rate_limits.max_concurrent_dispatches",
+					"retry_config.max_attempts",
+					"retry_config.min_backoff",
+					"retry_config.max_backoff",
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("error reconciling queue %s: %w", definition.Name, err)
+		}
+		log.Printf("Reconciled queue %s", definition.Name)
+	}
+
+	return nil
+}
+
+func (q *gcloudTaskQueue) IsLastAttempt(c context.Context, queueName string, taskUID string) (int32, int32) {
 	var numRetries int32 = 0
 	var maxRetries int32 = -1
 
-	queue, err := q.getQueue(c, composeQueueName())
+	if queueName == "" {
+		queueName = DefaultQueueName()
+	}
+
+	queue, err := q.getQueue(c, queueName)
 	if err != nil {
 		return numRetries, maxRetries
 	}
@@ -95,7 +177,7 @@ func (q *gcloudTaskQueue) IsLastAttempt(c context.Context, taskUID string) (int3
 		maxRetries = queue.RetryConfig.MaxAttempts
 	}
 
-	task, err := q.getTask(c, taskUID)
+	task, err := q.getTask(c, queueName, taskUID)
 	if err != nil {
 		return numRetries, maxRetries
 	}
@@ -104,24 +186,126 @@ func (q *gcloudTaskQueue) IsLastAttempt(c context.Context, taskUID string) (int3
 	return task.DispatchCount, maxRetries
 }
 
+func (q *gcloudTaskQueue) Get(c context.Context, queueName string, taskUID string) (TaskInfo, bool, error) {
+	definition, err := queueDefinition(queueName)
+	if err != nil {
+		return TaskInfo{}, false, err
+	}
+
+	task, err := q.client.GetTask(c, &taskspb.GetTaskRequest{
+		Name:         composeTaskName(definition.Name, taskUID),
+		ResponseView: taskspb.Task_FULL,
+	})
+	if err != nil {
+		rsp, ok := grpcStatus.FromError(err)
+		if ok && rsp.Code() == grpcCodes.NotFound {
+			return TaskInfo{}, false, nil
+		}
+		return TaskInfo{}, false, fmt.Errorf("error getting task with uid %s: %w", taskUID, err)
+	}
+
+	return toTaskInfo(definition.Name, task), true, nil
+}
+
+func (q *gcloudTaskQueue) Delete(c context.Context, queueName string, taskUID string) error {
+	definition, err := queueDefinition(queueName)
+	if err != nil {
+		return err
+	}
+
+	err = q.client.DeleteTask(c, &taskspb.DeleteTaskRequest{
+		Name: composeTaskName(definition.Name, taskUID),
+	})
+	if err != nil {
+		rsp, ok := grpcStatus.FromError(err)
+		if ok && rsp.Code() == grpcCodes.NotFound {
+			log.Printf("Task with id %s does not exist -> ignore\n", taskUID)
+			return nil
+		}
+		return fmt.Errorf("error deleting task with uid %s: %w", taskUID, err)
+	}
+
+	return nil
+}
+
+func (q *gcloudTaskQueue) List(c context.Context, queueName string) ([]TaskInfo, error) {
+	definition, err := queueDefinition(queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []TaskInfo{}
+	it := q.client.ListTasks(c, &taskspb.ListTasksRequest{
+		Parent:       composeQueueName(definition.Name),
+		ResponseView: taskspb.Task_FULL,
+	})
+	for {
+		task, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error listing tasks of queue %s: %w", definition.Name, err)
+		}
+		tasks = append(tasks, toTaskInfo(definition.Name, task))
+	}
+
+	return tasks, nil
+}
+
+func toTaskInfo(queueName string, task *taskspb.Task) TaskInfo {
+	info := TaskInfo{
+		Task: Task{
+			QueueName:    queueName,
+			UID:          path.Base(task.Name),
+			ScheduleTime: task.ScheduleTime.AsTime(),
+		},
+		CreatedAt:     task.CreateTime.AsTime(),
+		DispatchCount: task.DispatchCount,
+	}
+
+	if request := task.GetAppEngineHttpRequest(); request != nil {
+		info.WebhookURLPath = request.RelativeUri
+		info.Headers = request.Headers
+		info.Payload = request.Body
+	}
+	if request := task.GetHttpRequest(); request != nil {
+		info.WebhookURL = request.Url
+		info.Headers = request.Headers
+		info.Payload = request.Body
+	}
+
+	return info
+}
+
 func (q *gcloudTaskQueue) getQueue(c context.Context, queueName string) (*taskspb.Queue, error) {
 	// find characteristics of the queue
 	queue, err := q.client.GetQueue(c, &taskspb.GetQueueRequest{
-		Name: composeQueueName(),
+		Name: composeQueueName(queueName),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("error getting queue with name %s: %s", queueName, err)
+		return nil, fmt.Errorf("error getting queue with name %s: %w", queueName, err)
 	}
 	return queue, nil
 }
 
-func (q *gcloudTaskQueue) getTask(c context.Context, taskUID string) (*taskspb.Task, error) {
+func (q *gcloudTaskQueue) getTask(c context.Context, queueName string, taskUID string) (*taskspb.Task, error) {
 	// find characteristics of the task
 	task, err := q.client.GetTask(c, &taskspb.GetTaskRequest{
-		Name: composeTaskName(taskUID),
+		Name: composeTaskName(queueName, taskUID),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("error getting task with uid %s: %s", taskUID, err)
+		return nil, fmt.Errorf("error getting task with uid %s: %w", taskUID, err)
 	}
 	return task, nil
 }
+
+func (q *gcloudTaskQueue) HealthCheck(c context.Context) error {
+	_, err := q.client.GetQueue(c, &taskspb.GetQueueRequest{
+		Name: composeQueueName(DefaultQueueName()),
+	})
+	if err != nil {
+		return fmt.Errorf("error fetching queue %s: %w", DefaultQueueName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package myqueue
+
+import (
+	"testing"
+	"time"
+
+	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTask(t *testing.T) {
+	scheduleTime := time.Date(2023, time.February, 28, 0, 0, 0, 0, time.UTC)
+	task := Task{
+		UID:            "1",
+		WebhookURLPath: "/pubsub/checkout/1",
+		Headers:        map[string]string{"X-Tenant": "shop"},
+		Payload:        []byte("{}"),
+	}
+
+	t.Run("App Engine target", func(t *testing.T) {
+		cloudTask := Target{}.newTask("tasks/1", task, scheduleTime)
+
+		request := cloudTask.GetAppEngineHttpRequest()
+		assert.NotNil(t, request)
+		assert.Equal(t, "/pubsub/checkout/1", request.RelativeUri)
+		assert.Equal(t, map[string]string{"X-Tenant": "shop"}, request.Headers)
+		assert.Equal(t, scheduleTime, cloudTask.ScheduleTime.AsTime())
+	})
+
+	t.Run("HTTP target", func(t *testing.T) {
+		cloudTask := Target{BaseURL: "https://shop.a.run.app"}.newTask("tasks/1", task, scheduleTime)
+
+		request := cloudTask.GetHttpRequest()
+		assert.NotNil(t, request)
+		assert.Equal(t, taskspb.HttpMethod_PUT, request.HttpMethod)
+		assert.Equal(t, "https://shop.a.run.app/pubsub/checkout/1", request.Url)
+		assert.Equal(t, map[string]string{"X-Tenant": "shop"}, request.Headers)
+		assert.Nil(t, request.GetOidcToken())
+	})
+
+	t.Run("HTTP target with OIDC", func(t *testing.T) {
+		target := Target{BaseURL: "https://shop.a.run.app", ServiceAccountEmail: "tasks@shop.iam.gserviceaccount.com"}
+		cloudTask := target.newTask("tasks/1", task, scheduleTime)
+
+		token := cloudTask.GetHttpRequest().GetOidcToken()
+		assert.NotNil(t, token)
+		assert.Equal(t, "tasks@shop.iam.gserviceaccount.com", token.ServiceAccountEmail)
+		assert.Equal(t, "https://shop.a.run.app", token.Audience)
+	})
+
+	t.Run("Absolute task URL", func(t *testing.T) {
+		absoluteTask := task
+		absoluteTask.WebhookURL = "https://other.example.com/hook"
+		cloudTask := Target{}.newTask("tasks/1", absoluteTask, scheduleTime)
+
+		assert.Equal(t, "https://other.example.com/hook", cloudTask.GetHttpRequest().Url)
+	})
+}
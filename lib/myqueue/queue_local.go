@@ -0,0 +1,369 @@
+package myqueue
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// localTaskQueue delivers tasks to the locally running webserver, so the flows that depend on
+// (delayed) webhooks also work without Cloud Tasks. It enforces the same limits as the registered
+// queue-definitions: max attempts with backoff, dispatch-rate and concurrency.
+type localTaskQueue struct {
+	sync.Mutex
+	baseURL    string
+	target     Target
+	httpClient *http.Client
+	queues     map[string]*localQueue
+	minDelay   time.Duration
+	stopped    bool
+}
+
+type localQueue struct {
+	definition   QueueDefinition
+	slots        chan struct{}
+	nextDispatch time.Time
+	tasks        map[string]*localTask
+}
+
+type localTask struct {
+	task        Task
+	createdAt   time.Time
+	scheduledAt time.Time
+	attempts    int32
+	timer       *time.Timer
+	// finished tasks are kept to de-duplicate, like Cloud Tasks does
+	finished bool
+}
+
+func init() {
+	if os.Getenv("GOOGLE_CLOUD_PROJECT") == "" {
+		New = newLocalQueue
+	}
+}
+
+func newLocalQueue(c context.Context) (TaskQueuer, func(), error) {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	q := newLocalTaskQueue(fmt.Sprintf("http://localhost:%s", port))
+	q.target = targetFromEnv()
+
+	return q, q.stop, nil
+}
+
+func newLocalTaskQueue(baseURL string) *localTaskQueue {
+	return &localTaskQueue{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		queues:     map[string]*localQueue{},
+		minDelay:   defaultDelay,
+	}
+}
+
+func (q *localTaskQueue) Reconcile(c context.Context) error {
+	q.Lock()
+	defer q.Unlock()
+
+	for _, definition := range RegisteredQueues() {
+		q.reconcileQueue(definition)
+	}
+
+	return nil
+}
+
+func (q *localTaskQueue) reconcileQueue(definition QueueDefinition) *localQueue {
+	queue, found := q.queues[definition.Name]
+	if !found {
+		queue = &localQueue{
+			tasks: map[string]*localTask{},
+		}
+		q.queues[definition.Name] = queue
+	}
+	if !found || queue.definition.MaxConcurrentDispatches != definition.MaxConcurrentDispatches {
+		queue.slots = nil
+		if definition.MaxConcurrentDispatches > 0 {
+			queue.slots = make(chan struct{}, definition.MaxConcurrentDispatches)
+		}
+	}
+	queue.definition = definition
+
+	return queue
+}
+
+func (q *localTaskQueue) Enqueue(c context.Context, task Task) error {
+	definition, err := queueDefinition(task.QueueName)
+	if err != nil {
+		return err
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	queue, found := q.queues[definition.Name]
+	if !found {
+		queue = q.reconcileQueue(definition)
+	}
+
+	_, exists := queue.tasks[task.UID]
+	if exists {
+		log.Printf("Task with id %s already exists -> ignore\n", task.UID)
+		return nil
+	}
+
+	t := &localTask{task: task, createdAt: time.Now()}
+	queue.tasks[task.UID] = t
+	q.schedule(queue, t, task.scheduleTime(time.Now(), q.minDelay))
+
+	return nil
+}
+
+// schedule must be called with the lock held
+func (q *localTaskQueue) schedule(queue *localQueue, t *localTask, at time.Time) {
+	if q.stopped {
+		return
+	}
+	t.scheduledAt = at
+	t.timer = time.AfterFunc(time.Until(at), func() {
+		q.dispatch(queue, t)
+	})
+}
+
+func (q *localTaskQueue) dispatch(queue *localQueue, t *localTask) {
+	q.Lock()
+	if t.finished {
+		q.Unlock()
+		return
+	}
+	slots := queue.slots
+	definition := queue.definition
+	q.Unlock()
+
+	// enforce max concurrent dispatches
+	if slots != nil {
+		slots <- struct{}{}
+		defer func() {
+			<-slots
+		}()
+	}
+
+	// enforce max dispatches per second
+	time.Sleep(time.Until(q.reserveDispatch(queue)))
+
+	q.Lock()
+	if t.finished {
+		// deleted while waiting for its turn
+		q.Unlock()
+		return
+	}
+	t.attempts++
+	attempt := t.attempts
+	q.Unlock()
+
+	err := q.deliver(t.task)
+	if err == nil {
+		q.finish(t)
+		return
+	}
+
+	if definition.isLastAttempt(attempt) {
+		log.Printf("Error delivering task %s (attempt %d of %d): %s -> give up", t.task.UID, attempt, definition.MaxAttempts, err)
+		q.finish(t)
+		return
+	}
+
+	backoff := definition.backoff(attempt)
+	log.Printf("Error delivering task %s (attempt %d): %s -> retry in %s", t.task.UID, attempt, err, backoff)
+
+	q.Lock()
+	defer q.Unlock()
+	q.schedule(queue, t, time.Now().Add(backoff))
+}
+
+func (q *localTaskQueue) finish(t *localTask) {
+	q.Lock()
+	defer q.Unlock()
+
+	t.finished = true
+}
+
+func (q *localTaskQueue) Get(c context.Context, queueName string, taskUID string) (TaskInfo, bool, error) {
+	definition, err := queueDefinition(queueName)
+	if err != nil {
+		return TaskInfo{}, false, err
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	queue, found := q.queues[definition.Name]
+	if !found {
+		return TaskInfo{}, false, nil
+	}
+	t, found := queue.tasks[taskUID]
+	if !found || t.finished {
+		return TaskInfo{}, false, nil
+	}
+
+	return t.info(definition.Name), true, nil
+}
+
+func (q *localTaskQueue) Delete(c context.Context, queueName string, taskUID string) error {
+	definition, err := queueDefinition(queueName)
+	if err != nil {
+		return err
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	queue, found := q.queues[definition.Name]
+	if !found {
+		return nil
+	}
+	t, found := queue.tasks[taskUID]
+	if !found {
+		return nil
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.finished = true
+
+	return nil
+}
+
+func (q *localTaskQueue) List(c context.Context, queueName string) ([]TaskInfo, error) {
+	definition, err := queueDefinition(queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	tasks := []TaskInfo{}
+	queue, found := q.queues[definition.Name]
+	if !found {
+		return tasks, nil
+	}
+	for _, t := range queue.tasks {
+		if !t.finished {
+			tasks = append(tasks, t.info(definition.Name))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ScheduleTime.Before(tasks[j].ScheduleTime)
+	})
+
+	return tasks, nil
+}
+
+func (t *localTask) info(queueName string) TaskInfo {
+	task := t.task
+	task.QueueName = queueName
+	task.ScheduleTime = t.scheduledAt
+
+	return TaskInfo{
+		Task:          task,
+		CreatedAt:     t.createdAt,
+		DispatchCount: t.attempts,
+	}
+}
+
+func (q *localTaskQueue) reserveDispatch(queue *localQueue) time.Time {
+	q.Lock()
+	defer q.Unlock()
+
+	now := time.Now()
+	if queue.nextDispatch.Before(now) {
+		queue.nextDispatch = now
+	}
+	dispatchAt := queue.nextDispatch
+
+	if queue.definition.MaxDispatchesPerSecond > 0 {
+		queue.nextDispatch = queue.nextDispatch.Add(time.Duration(float64(time.Second) / queue.definition.MaxDispatchesPerSecond))
+	}
+
+	return dispatchAt
+}
+
+func (q *localTaskQueue) deliver(task Task) error {
+	url, absolute := q.target.url(task)
+	if !absolute {
+		url = q.baseURL + task.WebhookURLPath
+	}
+
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(task.Payload))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	for name, value := range task.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := q.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (q *localTaskQueue) stop() {
+	q.Lock()
+	defer q.Unlock()
+
+	q.stopped = true
+	for _, queue := range q.queues {
+		for _, t := range queue.tasks {
+			if t.timer != nil {
+				t.timer.Stop()
+			}
+		}
+	}
+}
+
+func (q *localTaskQueue) IsLastAttempt(c context.Context, queueName string, taskUID string) (int32, int32) {
+	definition, err := queueDefinition(queueName)
+	if err != nil {
+		return 0, -1
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	queue, found := q.queues[definition.Name]
+	if !found {
+		return 0, definition.MaxAttempts
+	}
+	t, found := queue.tasks[taskUID]
+	if !found {
+		return 0, queue.definition.MaxAttempts
+	}
+
+	return t.attempts, queue.definition.MaxAttempts
+}
+
+func (q *localTaskQueue) HealthCheck(c context.Context) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.stopped {
+		return fmt.Errorf("local queue is stopped")
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package myqueue
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalQueue(t *testing.T) {
+	RegisterQueue(QueueDefinition{
+		Name:                    "test-retry",
+		MaxAttempts:             3,
+		MaxConcurrentDispatches: 1,
+		MinBackoff:              time.Millisecond,
+		MaxBackoff:              5 * time.Millisecond,
+	})
+	RegisterQueue(QueueDefinition{
+		Name:                    "test-concurrency",
+		MaxAttempts:             1,
+		MaxDispatchesPerSecond:  1000,
+		MaxConcurrentDispatches: 2,
+	})
+
+	t.Run("Deliver task", func(t *testing.T) {
+		// setup
+		received := make(chan *http.Request, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received <- r
+		}))
+		defer server.Close()
+		sut := setupLocalQueue(server.URL)
+		defer sut.stop()
+
+		// when
+		err := sut.Enqueue(context.TODO(), Task{UID: "1", WebhookURLPath: "/pubsub/checkout/1"})
+
+		// then
+		assert.NoError(t, err)
+		r := <-received
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/pubsub/checkout/1", r.URL.Path)
+	})
+
+	t.Run("Deliver task to absolute URL with headers", func(t *testing.T) {
+		// setup
+		received := make(chan *http.Request, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received <- r
+		}))
+		defer server.Close()
+		sut := setupLocalQueue("http://localhost:0")
+		defer sut.stop()
+
+		// when
+		err := sut.Enqueue(context.TODO(), Task{
+			UID:        "2",
+			WebhookURL: server.URL + "/hook",
+			Headers:    map[string]string{"X-Tenant": "shop"},
+		})
+
+		// then
+		assert.NoError(t, err)
+		r := <-received
+		assert.Equal(t, "/hook", r.URL.Path)
+		assert.Equal(t, "shop", r.Header.Get("X-Tenant"))
+	})
+
+	t.Run("Inspect and delete pending task", func(t *testing.T) {
+		// setup
+		c := context.TODO()
+		sut := setupLocalQueue("http://localhost:0")
+		defer sut.stop()
+		later := time.Now().Add(time.Hour)
+
+		// given
+		err := sut.Enqueue(c, Task{UID: "expire-1", WebhookURLPath: "/jobs/basket.expire/expire-1", ScheduleTime: later})
+		assert.NoError(t, err)
+
+		// when
+		info, found, err := sut.Get(c, "", "expire-1")
+
+		// then
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "/jobs/basket.expire/expire-1", info.WebhookURLPath)
+		assert.Equal(t, later, info.ScheduleTime)
+		tasks, err := sut.List(c, "")
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 1)
+
+		// when
+		err = sut.Delete(c, "", "expire-1")
+
+		// then
+		assert.NoError(t, err)
+		_, found, err = sut.Get(c, "", "expire-1")
+		assert.NoError(t, err)
+		assert.False(t, found)
+		tasks, err = sut.List(c, "")
+		assert.NoError(t, err)
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("Unknown queue", func(t *testing.T) {
+		// setup
+		sut := setupLocalQueue("http://localhost")
+		defer sut.stop()
+
+		// when
+		err := sut.Enqueue(context.TODO(), Task{QueueName: "unknown", UID: "1"})
+
+		// then
+		assert.Error(t, err)
+	})
+
+	t.Run("Retry until max attempts", func(t *testing.T) {
+		// setup
+		attempts := int32(0)
+		done := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) == 3 {
+				close(done)
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+		sut := setupLocalQueue(server.URL)
+		defer sut.stop()
+
+		// when
+		err := sut.Enqueue(context.TODO(), Task{QueueName: "test-retry", UID: "1", WebhookURLPath: "/"})
+
+		// then
+		assert.NoError(t, err)
+		<-done
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+		count, max := sut.IsLastAttempt(context.TODO(), "test-retry", "1")
+		assert.Equal(t, int32(3), count)
+		assert.Equal(t, int32(3), max)
+	})
+
+	t.Run("Limit concurrent dispatches", func(t *testing.T) {
+		// setup
+		inFlight := int32(0)
+		maxInFlight := int32(0)
+		wg := sync.WaitGroup{}
+		wg.Add(5)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer wg.Done()
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}))
+		defer server.Close()
+		sut := setupLocalQueue(server.URL)
+		defer sut.stop()
+
+		// when
+		for _, uid := range []string{"1", "2", "3", "4", "5"} {
+			err := sut.Enqueue(context.TODO(), Task{QueueName: "test-concurrency", UID: uid, WebhookURLPath: "/"})
+			assert.NoError(t, err)
+		}
+
+		// then
+		wg.Wait()
+		assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+	})
+}
+
+func TestQueueDefinition(t *testing.T) {
+	definition := QueueDefinition{MinBackoff: time.Second, MaxBackoff: 5 * time.Second, MaxAttempts: 3}
+
+	assert.Equal(t, time.Second, definition.backoff(1))
+	assert.Equal(t, 2*time.Second, definition.backoff(2))
+	assert.Equal(t, 4*time.Second, definition.backoff(3))
+	assert.Equal(t, 5*time.Second, definition.backoff(4))
+	assert.False(t, definition.isLastAttempt(2))
+	assert.True(t, definition.isLastAttempt(3))
+}
+
+func setupLocalQueue(baseURL string) *localTaskQueue {
+	q := newLocalTaskQueue(baseURL)
+	q.minDelay = 0
+	return q
+}
@@ -40,6 +40,20 @@ func (m *MockTaskQueuer) EXPECT() *MockTaskQueuerMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockTaskQueuer) Delete(c context.Context, queueName, taskUID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", c, queueName, taskUID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTaskQueuerMockRecorder) Delete(c, queueName, taskUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTaskQueuer)(nil).Delete), c, queueName, taskUID)
+}
+
 // Enqueue mocks base method.
 func (m *MockTaskQueuer) Enqueue(c context.Context, task Task) error {
 	m.ctrl.T.Helper()
@@ -54,17 +68,76 @@ func (mr *MockTaskQueuerMockRecorder) Enqueue(c, task any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockTaskQueuer)(nil).Enqueue), c, task)
 }
 
+// Get mocks base method.
+func (m *MockTaskQueuer) Get(c context.Context, queueName, taskUID string) (TaskInfo, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", c, queueName, taskUID)
+	ret0, _ := ret[0].(TaskInfo)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockTaskQueuerMockRecorder) Get(c, queueName, taskUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTaskQueuer)(nil).Get), c, queueName, taskUID)
+}
+
+// HealthCheck mocks base method.
+func (m *MockTaskQueuer) HealthCheck(c context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthCheck", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HealthCheck indicates an expected call of HealthCheck.
+func (mr *MockTaskQueuerMockRecorder) HealthCheck(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockTaskQueuer)(nil).HealthCheck), c)
+}
+
 // IsLastAttempt mocks base method.
-func (m *MockTaskQueuer) IsLastAttempt(c context.Context, taskUID string) (int32, int32) {
+func (m *MockTaskQueuer) IsLastAttempt(c context.Context, queueName, taskUID string) (int32, int32) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsLastAttempt", c, taskUID)
+	ret := m.ctrl.Call(m, "IsLastAttempt", c, queueName, taskUID)
 	ret0, _ := ret[0].(int32)
 	ret1, _ := ret[1].(int32)
 	return ret0, ret1
 }
 
 // IsLastAttempt indicates an expected call of IsLastAttempt.
-func (mr *MockTaskQueuerMockRecorder) IsLastAttempt(c, taskUID any) *gomock.Call {
+func (mr *MockTaskQueuerMockRecorder) IsLastAttempt(c, queueName, taskUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsLastAttempt", reflect.TypeOf((*MockTaskQueuer)(nil).IsLastAttempt), c, queueName, taskUID)
+}
+
+// List mocks base method.
+func (m *MockTaskQueuer) List(c context.Context, queueName string) ([]TaskInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", c, queueName)
+	ret0, _ := ret[0].([]TaskInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockTaskQueuerMockRecorder) List(c, queueName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockTaskQueuer)(nil).List), c, queueName)
+}
+
+// Reconcile mocks base method.
+func (m *MockTaskQueuer) Reconcile(c context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reconcile indicates an expected call of Reconcile.
+func (mr *MockTaskQueuerMockRecorder) Reconcile(c any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsLastAttempt", reflect.TypeOf((*MockTaskQueuer)(nil).IsLastAttempt), c, taskUID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockTaskQueuer)(nil).Reconcile), c)
 }
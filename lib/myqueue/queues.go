@@ -0,0 +1,101 @@
+package myqueue
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// QueueDefinition describes the retry- and rate-limits of a named queue.
+// Definitions are declared in code and reconciled with the queue-infrastructure on startup.
+type QueueDefinition struct {
+	Name                    string
+	MaxAttempts             int32 // -1 means unlimited
+	MaxDispatchesPerSecond  float64
+	MaxConcurrentDispatches int32
+	MinBackoff              time.Duration
+	MaxBackoff              time.Duration
+}
+
+// backoff returns the delay before the given (1-based) retry
+func (d QueueDefinition) backoff(retry int32) time.Duration {
+	delay := d.MinBackoff
+	for i := int32(1); i < retry && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		return d.MaxBackoff
+	}
+	return delay
+}
+
+func (d QueueDefinition) isLastAttempt(attempt int32) bool {
+	return d.MaxAttempts > 0 && attempt >= d.MaxAttempts
+}
+
+var (
+	queuesMutex sync.RWMutex
+	queues      = map[string]QueueDefinition{}
+)
+
+func init() {
+	RegisterQueue(QueueDefinition{
+		Name:                    DefaultQueueName(),
+		MaxAttempts:             10,
+		MaxDispatchesPerSecond:  5,
+		MaxConcurrentDispatches: 5,
+		MinBackoff:              time.Second,
+		MaxBackoff:              time.Hour,
+	})
+}
+
+// DefaultQueueName is the queue used by tasks that do not specify one
+func DefaultQueueName() string {
+	queueName := os.Getenv("QUEUE_NAME")
+	if queueName == "" {
+		return "default"
+	}
+	return queueName
+}
+
+// RegisterQueue declares a named queue.
+// Typically called from the init() of the package that owns the queue.
+func RegisterQueue(definition QueueDefinition) {
+	queuesMutex.Lock()
+	defer queuesMutex.Unlock()
+
+	queues[definition.Name] = definition
+}
+
+// RegisteredQueues returns all declared queues ordered by name
+func RegisteredQueues() []QueueDefinition {
+	queuesMutex.RLock()
+	defer queuesMutex.RUnlock()
+
+	result := make([]QueueDefinition, 0, len(queues))
+	for _, definition := range queues {
+		result = append(result, definition)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+func queueDefinition(queueName string) (QueueDefinition, error) {
+	if queueName == "" {
+		queueName = DefaultQueueName()
+	}
+
+	queuesMutex.RLock()
+	defer queuesMutex.RUnlock()
+
+	definition, found := queues[queueName]
+	if !found {
+		return QueueDefinition{}, fmt.Errorf("queue %s is not registered", queueName)
+	}
+	return definition, nil
+}
@@ -0,0 +1,40 @@
+package myqueue
+
+import (
+	"os"
+	"strings"
+)
+
+// Target describes where tasks are delivered.
+// Without a base-URL, tasks are delivered to App Engine using their relative path.
+type Target struct {
+	BaseURL             string // like https://shop-xyz.a.run.app
+	ServiceAccountEmail string // when set, requests carry an OIDC token of this service account
+	Audience            string // audience of the OIDC token; defaults to the base-URL
+}
+
+func targetFromEnv() Target {
+	return Target{
+		BaseURL:             strings.TrimSuffix(os.Getenv("QUEUE_TARGET_BASE_URL"), "/"),
+		ServiceAccountEmail: os.Getenv("QUEUE_OIDC_SERVICE_ACCOUNT"),
+		Audience:            os.Getenv("QUEUE_OIDC_AUDIENCE"),
+	}
+}
+
+// url returns the absolute URL for the task, or false when the task should use a relative App Engine URI
+func (t Target) url(task Task) (string, bool) {
+	if task.WebhookURL != "" {
+		return task.WebhookURL, true
+	}
+	if t.BaseURL != "" {
+		return t.BaseURL + task.WebhookURLPath, true
+	}
+	return "", false
+}
+
+func (t Target) audience() string {
+	if t.Audience != "" {
+		return t.Audience
+	}
+	return t.BaseURL
+}
@@ -0,0 +1,152 @@
+package myreplay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/myeventstore"
+	"github.com/MarcGrol/shopbackend/lib/mylog"
+)
+
+const progressInterval = 100
+
+type Request struct {
+	Consumer      string
+	Topic         string
+	EventTypeName string
+	From          time.Time
+	Until         time.Time
+	DryRun        bool
+}
+
+type ReplayStatus string
+
+const (
+	ReplayStatusSkipped    ReplayStatus = "skipped"
+	ReplayStatusDispatched ReplayStatus = "dispatched"
+	ReplayStatusFailed     ReplayStatus = "failed"
+)
+
+type ReplayedEvent struct {
+	UID           string
+	AggregateUID  string
+	EventTypeName string
+	CreatedAt     time.Time
+	Status        ReplayStatus
+	Error         string `json:",omitempty"`
+}
+
+// Report describes the progress and outcome of a replay
+type Report struct {
+	Request    Request
+	Selected   int
+	Dispatched int
+	Failed     int
+	Events     []ReplayedEvent
+}
+
+// Replayer re-dispatches stored events to a single named consumer, without publishing them to other subscribers.
+type Replayer struct {
+	eventStore myeventstore.EventStore
+	logger     mylog.Logger
+	sync.RWMutex
+	consumers map[string]*myevents.Dispatcher
+}
+
+func New(eventStore myeventstore.EventStore) *Replayer {
+	return &Replayer{
+		eventStore: eventStore,
+		logger:     mylog.New("replay"),
+		consumers:  map[string]*myevents.Dispatcher{},
+	}
+}
+
+// RegisterConsumer makes a consumer available as target for replays
+func (r *Replayer) RegisterConsumer(name string, dispatcher *myevents.Dispatcher) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.consumers[name] = dispatcher
+}
+
+// ConsumerNames returns the names of all registered consumers
+func (r *Replayer) ConsumerNames() []string {
+	r.RLock()
+	defer r.RUnlock()
+
+	names := make([]string, 0, len(r.consumers))
+	for name := range r.consumers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Replay dispatches the selected events in order of creation. In dry-run mode the events are only selected.
+// A failing event does not stop the replay: it is reported instead.
+func (r *Replayer) Replay(c context.Context, req Request) (Report, error) {
+	r.RLock()
+	dispatcher, found := r.consumers[req.Consumer]
+	r.RUnlock()
+	if !found {
+		return Report{}, myerrors.NewNotFoundError(fmt.Errorf("consumer %s not found", req.Consumer))
+	}
+
+	events, err := r.eventStore.Search(c, myeventstore.Criteria{
+		Topic:         req.Topic,
+		EventTypeName: req.EventTypeName,
+		From:          req.From,
+		Until:         req.Until,
+	})
+	if err != nil {
+		return Report{}, myerrors.NewInternalError(err)
+	}
+
+	report := Report{
+		Request:  req,
+		Selected: len(events),
+		Events:   make([]ReplayedEvent, 0, len(events)),
+	}
+
+	r.logger.Log(c, "", mylog.SeverityInfo, "Start replay of %d events to %s (dry-run: %v)", len(events), req.Consumer, req.DryRun)
+
+	replayContext := myevents.WithReplay(c)
+	for i, event := range events {
+		replayed := ReplayedEvent{
+			UID:           event.UID,
+			AggregateUID:  event.AggregateUID,
+			EventTypeName: event.Envelope.EventTypeName,
+			CreatedAt:     event.Envelope.CreatedAt,
+			Status:        ReplayStatusSkipped,
+		}
+
+		if !req.DryRun {
+			err := dispatcher.Dispatch(replayContext, event.Envelope)
+			if err != nil {
+				replayed.Status = ReplayStatusFailed
+				replayed.Error = err.Error()
+				report.Failed++
+				r.logger.Log(c, event.AggregateUID, mylog.SeverityWarn, "Error replaying event %s to %s: %s", event.Envelope.String(), req.Consumer, err)
+			} else {
+				replayed.Status = ReplayStatusDispatched
+				report.Dispatched++
+			}
+		}
+		report.Events = append(report.Events, replayed)
+
+		if (i+1)%progressInterval == 0 {
+			r.logger.Log(c, "", mylog.SeverityInfo, "Replayed %d of %d events to %s", i+1, len(events), req.Consumer)
+		}
+	}
+
+	r.logger.Log(c, "", mylog.SeverityInfo, "Completed replay to %s: selected %d, dispatched %d, failed %d",
+		req.Consumer, report.Selected, report.Dispatched, report.Failed)
+
+	return report, nil
+}
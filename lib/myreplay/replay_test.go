@@ -0,0 +1,117 @@
+package myreplay
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/myeventstore"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+type basketPaid struct {
+	BasketUID string
+}
+
+func (e basketPaid) GetEventTypeName() string {
+	return "basket.paid"
+}
+
+func (e basketPaid) GetAggregateName() string {
+	return e.BasketUID
+}
+
+func (e basketPaid) GetEventVersion() int {
+	return 1
+}
+
+var storedEvents = []myeventstore.StoredEvent{
+	{
+		UID:          "1",
+		AggregateUID: "basket_1",
+		Sequence:     1,
+		Envelope:     myevents.EventEnvelope{UID: "1", CreatedAt: mytime.ExampleTime, Topic: "basket", AggregateUID: "basket_1", EventTypeName: "basket.paid", EventPayload: `{"BasketUID":"basket_1"}`},
+	},
+	{
+		UID:          "2",
+		AggregateUID: "basket_2",
+		Sequence:     1,
+		Envelope:     myevents.EventEnvelope{UID: "2", CreatedAt: mytime.ExampleTime, Topic: "basket", AggregateUID: "basket_2", EventTypeName: "basket.paid", EventPayload: `{"BasketUID":"basket_2"}`},
+	},
+}
+
+func TestReplay(t *testing.T) {
+	c := context.TODO()
+	criteria := myeventstore.Criteria{Topic: "basket", From: mytime.ExampleTime}
+
+	t.Run("Dry-run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		eventStore := myeventstore.NewMockEventStore(ctrl)
+		eventStore.EXPECT().Search(gomock.Any(), criteria).Return(storedEvents, nil)
+
+		called := false
+		dispatcher := myevents.NewDispatcher("projection")
+		myevents.Register(dispatcher, func(c context.Context, topic string, event basketPaid) error {
+			called = true
+			return nil
+		})
+
+		sut := New(eventStore)
+		sut.RegisterConsumer("projection", dispatcher)
+
+		report, err := sut.Replay(c, Request{Consumer: "projection", Topic: "basket", From: mytime.ExampleTime, DryRun: true})
+		assert.NoError(t, err)
+		assert.False(t, called)
+		assert.Equal(t, 2, report.Selected)
+		assert.Equal(t, 0, report.Dispatched)
+		assert.Equal(t, ReplayStatusSkipped, report.Events[0].Status)
+	})
+
+	t.Run("Replay to consumer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		eventStore := myeventstore.NewMockEventStore(ctrl)
+		eventStore.EXPECT().Search(gomock.Any(), criteria).Return(storedEvents, nil)
+
+		received := []string{}
+		dispatcher := myevents.NewDispatcher("projection")
+		myevents.Register(dispatcher, func(c context.Context, topic string, event basketPaid) error {
+			assert.True(t, myevents.IsReplay(c))
+			if event.BasketUID == "basket_2" {
+				return fmt.Errorf("error handling event")
+			}
+			received = append(received, event.BasketUID)
+			return nil
+		})
+
+		sut := New(eventStore)
+		sut.RegisterConsumer("projection", dispatcher)
+
+		report, err := sut.Replay(c, Request{Consumer: "projection", Topic: "basket", From: mytime.ExampleTime})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"basket_1"}, received)
+		assert.Equal(t, 2, report.Selected)
+		assert.Equal(t, 1, report.Dispatched)
+		assert.Equal(t, 1, report.Failed)
+		assert.Equal(t, ReplayStatusDispatched, report.Events[0].Status)
+		assert.Equal(t, ReplayStatusFailed, report.Events[1].Status)
+		assert.Equal(t, "error handling event", report.Events[1].Error)
+	})
+
+	t.Run("Unknown consumer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sut := New(myeventstore.NewMockEventStore(ctrl))
+
+		_, err := sut.Replay(c, Request{Consumer: "unknown"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,39 @@
+package myreplay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myhttp"
+)
+
+func (r *Replayer) RegisterEndpoints(c context.Context, router *mux.Router) {
+	router.HandleFunc("/api/events/replay", r.replay()).Methods("POST")
+}
+
+func (r *Replayer) replay() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(req)
+		responseWriter := myhttp.NewWriter(r.logger)
+
+		replayRequest := Request{}
+		err := json.NewDecoder(req.Body).Decode(&replayRequest)
+		if err != nil {
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(err))
+			return
+		}
+
+		report, err := r.Replay(c, replayRequest)
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		responseWriter.Write(c, w, http.StatusOK, report)
+	}
+}
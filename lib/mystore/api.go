@@ -17,9 +17,17 @@ type Filter struct {
 type Store[T any] interface {
 	RunInTransaction(c context.Context, f func(c context.Context) error) error
 	Put(c context.Context, uid string, value T) error
+	PutMulti(c context.Context, uids []string, values []T) error
 	Get(c context.Context, uid string) (T, bool, error)
+	Delete(c context.Context, uid string) error
 	List(c context.Context) ([]T, error)
+	ListUIDs(c context.Context) ([]string, error)
 	Query(c context.Context, filters []Filter, orderByField string) ([]T, error)
+	QueryLimit(c context.Context, filters []Filter, orderByField string, limit int) ([]T, error)
+	// Count returns the number of entities that match the filters, without fetching them
+	Count(c context.Context, filters []Filter) (int, error)
+	// HealthCheck verifies that the underlying datastore can be reached
+	HealthCheck(c context.Context) error
 }
 
 func New[T any](c context.Context) (Store[T], func(), error) {
@@ -29,3 +37,13 @@ func New[T any](c context.Context) (Store[T], func(), error) {
 
 	return NewInMemoryStore[T](c)
 }
+
+// NewWithKind stores entities under the given kind instead of the one derived from the type-name.
+// Useful when a generic type (like an encrypted record) is stored on behalf of several other types.
+func NewWithKind[T any](c context.Context, kind string) (Store[T], func(), error) {
+	if os.Getenv("GOOGLE_CLOUD_PROJECT") != "" {
+		return newGcloudStoreWithKind[T](c, kind)
+	}
+
+	return NewInMemoryStore[T](c)
+}
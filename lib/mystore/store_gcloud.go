@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/datastore/apiv1/datastorepb"
+	"google.golang.org/api/iterator"
 )
 
 type gcloudStore[T any] struct {
@@ -16,19 +18,23 @@ type gcloudStore[T any] struct {
 }
 
 func newGcloudStore[T any](c context.Context) (*gcloudStore[T], func(), error) {
-	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
-
-	client, err := datastore.NewClient(c, projectID)
-	if err != nil {
-		return nil, nil, fmt.Errorf("error creating datastore-client: %s", err)
-	}
-
 	val := new(T)
 	kind := fmt.Sprintf("%T", *val)
 	if strings.Contains(kind, ".") {
 		kind = strings.Split(kind, ".")[1]
 	}
 
+	return newGcloudStoreWithKind[T](c, kind)
+}
+
+func newGcloudStoreWithKind[T any](c context.Context, kind string) (*gcloudStore[T], func(), error) {
+	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
+
+	client, err := datastore.NewClient(c, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating datastore-client: %w", err)
+	}
+
 	return &gcloudStore[T]{
 			client: client,
 			kind:   kind,
@@ -50,6 +56,11 @@ func (s *gcloudStore[T]) RunInTransactionNative(c context.Context, f func(c cont
 }
 
 func (s *gcloudStore[T]) RunInTransaction(c context.Context, f func(c context.Context) error) error {
+	if c.Value(ctxTransactionKey{}) != nil {
+		// Join the transaction that is already running, so that writes on multiple stores are atomic
+		return f(c)
+	}
+
 	var err error
 	// retry 3 times
 	for i := 1; i <= 3; i++ {
@@ -116,7 +127,7 @@ func (s *gcloudStore[T]) Put(c context.Context, uid string, value T) error {
 	if transaction != nil {
 		_, err := transaction.(*datastore.Transaction).Put(datastore.NameKey(s.kind, uid, nil), &value)
 		if err != nil {
-			return fmt.Errorf("error transctionally storing entity %s with uid %s: %s", s.kind, uid, err)
+			return fmt.Errorf("error transctionally storing entity %s with uid %s: %w", s.kind, uid, err)
 		}
 
 		//log.Printf("In transaction %p: stored entity %s with uid %s", transaction, s.kind, uid)
@@ -126,7 +137,7 @@ func (s *gcloudStore[T]) Put(c context.Context, uid string, value T) error {
 
 	_, err := s.client.Put(c, datastore.NameKey(s.kind, uid, nil), &value)
 	if err != nil {
-		return fmt.Errorf("error storing entity %s with uid %s: %s", s.kind, uid, err)
+		return fmt.Errorf("error storing entity %s with uid %s: %w", s.kind, uid, err)
 	}
 
 	//log.Printf("Non-transactionally stored entity %s with uid %s", s.kind, uid)
@@ -134,6 +145,25 @@ func (s *gcloudStore[T]) Put(c context.Context, uid string, value T) error {
 	return nil
 }
 
+func (s *gcloudStore[T]) Delete(c context.Context, uid string) error {
+	transaction := c.Value(ctxTransactionKey{})
+
+	if transaction != nil {
+		err := transaction.(*datastore.Transaction).Delete(datastore.NameKey(s.kind, uid, nil))
+		if err != nil {
+			return fmt.Errorf("error transctionally deleting entity %s with uid %s: %w", s.kind, uid, err)
+		}
+		return nil
+	}
+
+	err := s.client.Delete(c, datastore.NameKey(s.kind, uid, nil))
+	if err != nil {
+		return fmt.Errorf("error deleting entity %s with uid %s: %w", s.kind, uid, err)
+	}
+
+	return nil
+}
+
 func (s *gcloudStore[T]) Get(c context.Context, uid string) (T, bool, error) {
 	value := new(T)
 
@@ -145,7 +175,7 @@ func (s *gcloudStore[T]) Get(c context.Context, uid string) (T, bool, error) {
 			if err == datastore.ErrNoSuchEntity {
 				return *value, false, nil
 			}
-			return *value, false, fmt.Errorf("error transctionally fetching entity %s with uid %s: %s", s.kind, uid, err)
+			return *value, false, fmt.Errorf("error transctionally fetching entity %s with uid %s: %w", s.kind, uid, err)
 		}
 
 		//log.Printf("In transaction %p: fetched entity %s with uid %s", transaction, s.kind, uid)
@@ -158,7 +188,7 @@ func (s *gcloudStore[T]) Get(c context.Context, uid string) (T, bool, error) {
 		if err == datastore.ErrNoSuchEntity {
 			return *value, false, nil
 		}
-		return *value, false, fmt.Errorf("error fetching entity %s with uid %s: %s", s.kind, uid, err)
+		return *value, false, fmt.Errorf("error fetching entity %s with uid %s: %w", s.kind, uid, err)
 	}
 
 	//log.Printf("Non-transactionally fetched entity %s with uid %s", s.kind, uid)
@@ -179,12 +209,98 @@ func (s *gcloudStore[T]) List(c context.Context) ([]T, error) {
 
 	_, err := s.client.GetAll(c, q, &objectsToFetch)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching all entities %s: %s", s.kind, err)
+		return nil, fmt.Errorf("error fetching all entities %s: %w", s.kind, err)
 	}
 	return objectsToFetch, nil
 }
 
+func (s *gcloudStore[T]) ListUIDs(c context.Context) ([]string, error) {
+	transaction := c.Value(ctxTransactionKey{})
+
+	q := datastore.NewQuery(s.kind).KeysOnly()
+
+	if transaction != nil {
+		q = q.Transaction(transaction.(*datastore.Transaction))
+	}
+
+	keys, err := s.client.GetAll(c, q, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching all keys of %s: %w", s.kind, err)
+	}
+
+	uids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		uids = append(uids, key.Name)
+	}
+	return uids, nil
+}
+
+func (s *gcloudStore[T]) Count(c context.Context, filters []Filter) (int, error) {
+	transaction := c.Value(ctxTransactionKey{})
+
+	q := datastore.NewQuery(s.kind)
+	for _, f := range filters {
+		q = q.FilterField(f.Field, f.Compare, f.Value)
+	}
+
+	if transaction != nil {
+		q = q.Transaction(transaction.(*datastore.Transaction))
+	}
+
+	result, err := s.client.RunAggregationQuery(c, q.NewAggregationQuery().WithCount("count"))
+	if err != nil {
+		return 0, fmt.Errorf("error counting entities %s: %w", s.kind, err)
+	}
+
+	count, ok := result["count"].(*datastorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("error counting entities %s: unexpected result %v", s.kind, result["count"])
+	}
+	return int(count.GetIntegerValue()), nil
+}
+
+func (s *gcloudStore[T]) HealthCheck(c context.Context) error {
+	_, err := s.client.Run(c, datastore.NewQuery(s.kind).KeysOnly().Limit(1)).Next(nil)
+	if err != nil && err != iterator.Done {
+		return fmt.Errorf("error querying %s: %w", s.kind, err)
+	}
+	return nil
+}
+
+func (s *gcloudStore[T]) PutMulti(c context.Context, uids []string, values []T) error {
+	if len(uids) != len(values) {
+		return fmt.Errorf("error storing entities %s: %d uids for %d values", s.kind, len(uids), len(values))
+	}
+
+	keys := make([]*datastore.Key, 0, len(uids))
+	for _, uid := range uids {
+		keys = append(keys, datastore.NameKey(s.kind, uid, nil))
+	}
+
+	transaction := c.Value(ctxTransactionKey{})
+
+	if transaction != nil {
+		_, err := transaction.(*datastore.Transaction).PutMulti(keys, values)
+		if err != nil {
+			return fmt.Errorf("error transctionally storing %d entities %s: %w", len(keys), s.kind, err)
+		}
+		return nil
+	}
+
+	_, err := s.client.PutMulti(c, keys, values)
+	if err != nil {
+		return fmt.Errorf("error storing %d entities %s: %w", len(keys), s.kind, err)
+	}
+
+	return nil
+}
+
 func (s *gcloudStore[T]) Query(c context.Context, filters []Filter, orderByField string) ([]T, error) {
+	return s.QueryLimit(c, filters, orderByField, 0)
+}
+
+// QueryLimit returns at most limit entities; a limit of 0 means no limit
+func (s *gcloudStore[T]) QueryLimit(c context.Context, filters []Filter, orderByField string, limit int) ([]T, error) {
 	objectsToFetch := []T{}
 
 	transaction := c.Value(ctxTransactionKey{})
@@ -194,13 +310,16 @@ func (s *gcloudStore[T]) Query(c context.Context, filters []Filter, orderByField
 		q = q.FilterField(f.Field, f.Compare, f.Value)
 	}
 	q = q.Order(orderByField)
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
 
 	if transaction != nil {
 		q = q.Transaction(transaction.(*datastore.Transaction))
 	}
 	_, err := s.client.GetAll(c, q, &objectsToFetch)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching all entities %s: %s", s.kind, err)
+		return nil, fmt.Errorf("error fetching all entities %s: %w", s.kind, err)
 	}
 
 	return objectsToFetch, nil
@@ -1,9 +1,15 @@
 package mystore
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"log"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 type InMemoryStore[T any] struct {
@@ -56,6 +62,44 @@ func (s *InMemoryStore[T]) Put(c context.Context, uid string, value T) error {
 	return nil
 }
 
+func (s *InMemoryStore[T]) PutMulti(c context.Context, uids []string, values []T) error {
+	if len(uids) != len(values) {
+		return fmt.Errorf("error storing entities: %d uids for %d values", len(uids), len(values))
+	}
+
+	nonTransactional := c.Value(ctxTransactionKey{}) == nil
+
+	if nonTransactional {
+		s.Lock()
+	}
+
+	for i, uid := range uids {
+		s.Items[uid] = values[i]
+	}
+
+	if nonTransactional {
+		s.Unlock()
+	}
+
+	return nil
+}
+
+func (s *InMemoryStore[T]) Delete(c context.Context, uid string) error {
+	nonTransactional := c.Value(ctxTransactionKey{}) == nil
+
+	if nonTransactional {
+		s.Lock()
+	}
+
+	delete(s.Items, uid)
+
+	if nonTransactional {
+		s.Unlock()
+	}
+
+	return nil
+}
+
 func (s *InMemoryStore[T]) Get(c context.Context, uid string) (T, bool, error) {
 	nonTransactional := c.Value(ctxTransactionKey{}) == nil
 
@@ -90,6 +134,179 @@ func (s *InMemoryStore[T]) List(c context.Context) ([]T, error) {
 	return result, nil
 }
 
+func (s *InMemoryStore[T]) HealthCheck(c context.Context) error {
+	return nil
+}
+
+func (s *InMemoryStore[T]) ListUIDs(c context.Context) ([]string, error) {
+	nonTransactional := c.Value(ctxTransactionKey{}) == nil
+
+	if nonTransactional {
+		s.Lock()
+	}
+
+	result := make([]string, 0, len(s.Items))
+	for uid := range s.Items {
+		result = append(result, uid)
+	}
+
+	if nonTransactional {
+		s.Unlock()
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
 func (s *InMemoryStore[T]) Query(c context.Context, filters []Filter, orderByField string) ([]T, error) {
-	return s.List(c)
+	items, err := s.List(c)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []T{}
+	for _, item := range items {
+		matches, err := matchesFilters(item, filters)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			result = append(result, item)
+		}
+	}
+
+	if orderByField != "" {
+		// Same notation as datastore: a leading "-" means descending
+		descending := strings.HasPrefix(orderByField, "-")
+		fieldName := strings.TrimPrefix(orderByField, "-")
+
+		var sortErr error
+		sort.SliceStable(result, func(i, j int) bool {
+			order, err := compareValues(fieldByName(result[i], fieldName), fieldByName(result[j], fieldName))
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			if descending {
+				return order > 0
+			}
+			return order < 0
+		})
+		if sortErr != nil {
+			return nil, fmt.Errorf("error ordering by field %s: %s", orderByField, sortErr)
+		}
+	}
+
+	return result, nil
+}
+
+// QueryLimit returns at most limit items; a limit of 0 means no limit
+func (s *InMemoryStore[T]) QueryLimit(c context.Context, filters []Filter, orderByField string, limit int) ([]T, error) {
+	result, err := s.Query(c, filters, orderByField)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+func (s *InMemoryStore[T]) Count(c context.Context, filters []Filter) (int, error) {
+	result, err := s.Query(c, filters, "")
+	if err != nil {
+		return 0, err
+	}
+
+	return len(result), nil
+}
+
+func matchesFilters(item any, filters []Filter) (bool, error) {
+	for _, f := range filters {
+		field := fieldByName(item, f.Field)
+		if !field.IsValid() {
+			return false, fmt.Errorf("unknown field %s", f.Field)
+		}
+
+		order, err := compareValues(field, reflect.ValueOf(f.Value))
+		if err != nil {
+			return false, fmt.Errorf("error filtering on field %s: %w", f.Field, err)
+		}
+
+		var matches bool
+		switch f.Compare {
+		case "=":
+			matches = order == 0
+		case "!=":
+			matches = order != 0
+		case "<":
+			matches = order < 0
+		case "<=":
+			matches = order <= 0
+		case ">":
+			matches = order > 0
+		case ">=":
+			matches = order >= 0
+		default:
+			return false, fmt.Errorf("unsupported comparison %s", f.Compare)
+		}
+		if !matches {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// fieldByName supports nested fields using the datastore notation "Outer.Inner"
+func fieldByName(item any, name string) reflect.Value {
+	value := reflect.ValueOf(item)
+	for _, part := range strings.Split(name, ".") {
+		if value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		value = value.FieldByName(part)
+	}
+	return value
+}
+
+func compareValues(a reflect.Value, b reflect.Value) (int, error) {
+	if !a.IsValid() || !b.IsValid() {
+		return 0, fmt.Errorf("unknown field")
+	}
+
+	if ta, ok := a.Interface().(time.Time); ok {
+		tb, ok := b.Interface().(time.Time)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare time with %s", b.Type())
+		}
+		return ta.Compare(tb), nil
+	}
+
+	switch {
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return strings.Compare(a.String(), b.String()), nil
+	case a.Kind() == reflect.Bool && b.Kind() == reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0, nil
+		}
+		if !a.Bool() {
+			return -1, nil
+		}
+		return 1, nil
+	case a.CanInt() && b.CanInt():
+		return cmp.Compare(a.Int(), b.Int()), nil
+	case a.CanUint() && b.CanUint():
+		return cmp.Compare(a.Uint(), b.Uint()), nil
+	case a.CanFloat() && b.CanFloat():
+		return cmp.Compare(a.Float(), b.Float()), nil
+	default:
+		return 0, fmt.Errorf("cannot compare %s with %s", a.Type(), b.Type())
+	}
 }
@@ -46,4 +46,43 @@ func TestStore(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, all, []Person{person})
 	})
+
+	t.Run("Query", func(t *testing.T) {
+		qs, _, err := NewInMemoryStore[Person](c)
+		assert.NoError(t, err)
+		_ = qs.Put(c, "1", Person{UID: "1", Name: "Marc", Age: 42})
+		_ = qs.Put(c, "2", Person{UID: "2", Name: "Eva", Age: 44})
+		_ = qs.Put(c, "3", Person{UID: "3", Name: "Pien", Age: 12})
+
+		adults, err := qs.Query(c, []Filter{{Field: "Age", Compare: ">=", Value: 18}}, "Name")
+		assert.NoError(t, err)
+		assert.Equal(t, []Person{{UID: "2", Name: "Eva", Age: 44}, {UID: "1", Name: "Marc", Age: 42}}, adults)
+
+		all, err := qs.Query(c, []Filter{}, "-Age")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2", "1", "3"}, []string{all[0].UID, all[1].UID, all[2].UID})
+
+		marc, err := qs.Query(c, []Filter{{Field: "Name", Compare: "=", Value: "Marc"}}, "")
+		assert.NoError(t, err)
+		assert.Equal(t, []Person{{UID: "1", Name: "Marc", Age: 42}}, marc)
+
+		_, err = qs.Query(c, []Filter{{Field: "Unknown", Compare: "=", Value: "Marc"}}, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		qs, _, err := NewInMemoryStore[Person](c)
+		assert.NoError(t, err)
+		_ = qs.Put(c, "1", Person{UID: "1", Name: "Marc", Age: 42})
+		_ = qs.Put(c, "2", Person{UID: "2", Name: "Eva", Age: 44})
+		_ = qs.Put(c, "3", Person{UID: "3", Name: "Pien", Age: 12})
+
+		adults, err := qs.Count(c, []Filter{{Field: "Age", Compare: ">=", Value: 18}})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, adults)
+
+		all, err := qs.Count(c, []Filter{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, all)
+	})
 }
@@ -40,6 +40,35 @@ func (m *MockStore[T]) EXPECT() *MockStoreMockRecorder[T] {
 	return m.recorder
 }
 
+// Count mocks base method.
+func (m *MockStore[T]) Count(c context.Context, filters []Filter) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count", c, filters)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockStoreMockRecorder[T]) Count(c, filters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockStore[T])(nil).Count), c, filters)
+}
+
+// Delete mocks base method.
+func (m *MockStore[T]) Delete(c context.Context, uid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", c, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockStoreMockRecorder[T]) Delete(c, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStore[T])(nil).Delete), c, uid)
+}
+
 // Get mocks base method.
 func (m *MockStore[T]) Get(c context.Context, uid string) (T, bool, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +85,20 @@ func (mr *MockStoreMockRecorder[T]) Get(c, uid any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStore[T])(nil).Get), c, uid)
 }
 
+// HealthCheck mocks base method.
+func (m *MockStore[T]) HealthCheck(c context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthCheck", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HealthCheck indicates an expected call of HealthCheck.
+func (mr *MockStoreMockRecorder[T]) HealthCheck(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockStore[T])(nil).HealthCheck), c)
+}
+
 // List mocks base method.
 func (m *MockStore[T]) List(c context.Context) ([]T, error) {
 	m.ctrl.T.Helper()
@@ -71,6 +114,21 @@ func (mr *MockStoreMockRecorder[T]) List(c any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockStore[T])(nil).List), c)
 }
 
+// ListUIDs mocks base method.
+func (m *MockStore[T]) ListUIDs(c context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUIDs", c)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUIDs indicates an expected call of ListUIDs.
+func (mr *MockStoreMockRecorder[T]) ListUIDs(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUIDs", reflect.TypeOf((*MockStore[T])(nil).ListUIDs), c)
+}
+
 // Put mocks base method.
 func (m *MockStore[T]) Put(c context.Context, uid string, value T) error {
 	m.ctrl.T.Helper()
@@ -85,6 +143,20 @@ func (mr *MockStoreMockRecorder[T]) Put(c, uid, value any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockStore[T])(nil).Put), c, uid, value)
 }
 
+// PutMulti mocks base method.
+func (m *MockStore[T]) PutMulti(c context.Context, uids []string, values []T) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutMulti", c, uids, values)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutMulti indicates an expected call of PutMulti.
+func (mr *MockStoreMockRecorder[T]) PutMulti(c, uids, values any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutMulti", reflect.TypeOf((*MockStore[T])(nil).PutMulti), c, uids, values)
+}
+
 // Query mocks base method.
 func (m *MockStore[T]) Query(c context.Context, filters []Filter, orderByField string) ([]T, error) {
 	m.ctrl.T.Helper()
@@ -100,6 +172,21 @@ func (mr *MockStoreMockRecorder[T]) Query(c, filters, orderByField any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockStore[T])(nil).Query), c, filters, orderByField)
 }
 
+// QueryLimit mocks base method.
+func (m *MockStore[T]) QueryLimit(c context.Context, filters []Filter, orderByField string, limit int) ([]T, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryLimit", c, filters, orderByField, limit)
+	ret0, _ := ret[0].([]T)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryLimit indicates an expected call of QueryLimit.
+func (mr *MockStoreMockRecorder[T]) QueryLimit(c, filters, orderByField, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryLimit", reflect.TypeOf((*MockStore[T])(nil).QueryLimit), c, filters, orderByField, limit)
+}
+
 // RunInTransaction mocks base method.
 func (m *MockStore[T]) RunInTransaction(c context.Context, f func(context.Context) error) error {
 	m.ctrl.T.Helper()
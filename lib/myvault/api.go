@@ -2,6 +2,7 @@ package myvault
 
 import (
 	"context"
+	"time"
 )
 
 type VaultReader[T any] interface {
@@ -11,5 +12,71 @@ type VaultReader[T any] interface {
 //go:generate mockgen -source=api.go -package myvault -destination vault_read_writer_mock.go VaultReadWriter
 type VaultReadWriter[T any] interface {
 	VaultReader[T]
+	// Put stores value as the new current version of the record; older versions are kept as history
 	Put(c context.Context, uid string, value T) error
+	GetVersion(c context.Context, uid string, version int) (VaultVersion[T], bool, error)
+	// ListVersions returns the retained versions of a record, most recent first
+	ListVersions(c context.Context, uid string) ([]VaultVersion[T], error)
+	// Restore makes a previous version current again by storing it as a new version
+	Restore(c context.Context, uid string, version int) error
+	// HealthCheck verifies that the records can be reached
+	HealthCheck(c context.Context) error
+}
+
+// VaultVersion is the value of a record as it was stored at CreatedAt
+type VaultVersion[T any] struct {
+	Version   int
+	CreatedAt time.Time
+	Value     T
+}
+
+// KeyProvider protects the per-record data-keys with a key-encryption-key
+type KeyProvider interface {
+	// CurrentKeyID identifies the key-encryption-key that WrapKey uses
+	CurrentKeyID(c context.Context) (string, error)
+	WrapKey(c context.Context, dataKey []byte) (keyID string, wrappedKey []byte, err error)
+	UnwrapKey(c context.Context, keyID string, wrappedKey []byte) ([]byte, error)
+}
+
+// ReEncrypter moves all records of a vault onto the current key-encryption-key
+type ReEncrypter interface {
+	ReEncrypt(c context.Context) (ReEncryptReport, error)
+}
+
+type ReEncryptReport struct {
+	Migrated  int // plaintext records that got encrypted
+	Rewrapped int // records whose data-key got wrapped with the current key
+	Unchanged int
+}
+
+type AuditOperation string
+
+const (
+	AuditOperationGet AuditOperation = "get"
+	AuditOperationPut AuditOperation = "put"
+
+	AuditOperationGetVersion   AuditOperation = "get-version"
+	AuditOperationListVersions AuditOperation = "list-versions"
+	AuditOperationRestore      AuditOperation = "restore"
+)
+
+// AuditRecord registers a single access to a vault-record. Records are only appended, never modified.
+type AuditRecord struct {
+	UID       string
+	Vault     string
+	VaultUID  string
+	Operation AuditOperation
+	Component string
+	Found     bool
+	// Pending marks the record appended before a write: without a completing record the outcome of the write is unknown
+	Pending   bool
+	Error     string `datastore:",noindex"`
+	TraceID   string
+	Timestamp time.Time
+}
+
+type AuditTrail interface {
+	Append(c context.Context, record AuditRecord) error
+	// ListByVaultUID returns the audit records of a vault-record, most recent first
+	ListByVaultUID(c context.Context, vaultUID string) ([]AuditRecord, error)
 }
@@ -0,0 +1,198 @@
+package myvault
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/mystore"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+	"github.com/MarcGrol/shopbackend/lib/myuuid"
+)
+
+type auditTrail struct {
+	store mystore.Store[AuditRecord]
+}
+
+func NewAuditTrail(c context.Context) (AuditTrail, func(), error) {
+	store, storeCleanup, err := mystore.New[AuditRecord](c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &auditTrail{
+		store: store,
+	}, storeCleanup, nil
+}
+
+func (t *auditTrail) Append(c context.Context, record AuditRecord) error {
+	err := t.store.Put(c, record.UID, record)
+	if err != nil {
+		return fmt.Errorf("error appending audit-record for %s: %w", record.VaultUID, err)
+	}
+	return nil
+}
+
+func (t *auditTrail) ListByVaultUID(c context.Context, vaultUID string) ([]AuditRecord, error) {
+	records, err := t.store.Query(c, []mystore.Filter{{Field: "VaultUID", Compare: "=", Value: vaultUID}}, "-Timestamp")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching audit-records for %s: %w", vaultUID, err)
+	}
+	return records, nil
+}
+
+type auditor struct {
+	trail     AuditTrail
+	vault     string
+	component string
+	nower     mytime.Nower
+	uuider    myuuid.UUIDer
+}
+
+// record appends an audit-record. Access to a secret that cannot be audited fails.
+func (a auditor) record(c context.Context, operation AuditOperation, uid string, found bool, opErr error) error {
+	return a.trail.Append(c, a.newRecord(c, operation, uid, found, opErr))
+}
+
+// write appends a pending audit-record before the write, so no write is done without being audited.
+// A write whose outcome cannot be audited is not reported as failed: it did happen and the pending record remains.
+func (a auditor) write(c context.Context, operation AuditOperation, uid string, write func() error) error {
+	pending := a.newRecord(c, operation, uid, false, nil)
+	pending.Pending = true
+	err := a.trail.Append(c, pending)
+	if err != nil {
+		return err
+	}
+
+	err = write()
+
+	auditErr := a.record(c, operation, uid, err == nil, err)
+	if auditErr != nil {
+		log.Printf("Outcome of %s of vault-record %s.%s (error: %v) was not audited: %s", operation, a.vault, uid, err, auditErr)
+	}
+
+	return err
+}
+
+func (a auditor) newRecord(c context.Context, operation AuditOperation, uid string, found bool, opErr error) AuditRecord {
+	record := AuditRecord{
+		UID:       a.uuider.Create(),
+		Vault:     a.vault,
+		VaultUID:  uid,
+		Operation: operation,
+		Component: a.component,
+		Found:     found,
+		TraceID:   mycontext.TraceFromContext(c),
+		Timestamp: a.nower.Now(),
+	}
+	if opErr != nil {
+		record.Error = opErr.Error()
+	}
+
+	return record
+}
+
+type auditingReader[T any] struct {
+	auditor
+	inner VaultReader[T]
+}
+
+// NewAuditingReader records every read of the given vault on behalf of the given component
+func NewAuditingReader[T any](inner VaultReader[T], trail AuditTrail, component string, nower mytime.Nower, uuider myuuid.UUIDer) VaultReader[T] {
+	return &auditingReader[T]{
+		auditor: newAuditor[T](trail, component, nower, uuider),
+		inner:   inner,
+	}
+}
+
+func (v *auditingReader[T]) Get(c context.Context, uid string) (T, bool, error) {
+	return auditedGet(c, v.auditor, v.inner, uid)
+}
+
+type auditingReadWriter[T any] struct {
+	auditor
+	inner VaultReadWriter[T]
+}
+
+// NewAuditingReadWriter records every read and write of the given vault on behalf of the given component
+func NewAuditingReadWriter[T any](inner VaultReadWriter[T], trail AuditTrail, component string, nower mytime.Nower, uuider myuuid.UUIDer) VaultReadWriter[T] {
+	return &auditingReadWriter[T]{
+		auditor: newAuditor[T](trail, component, nower, uuider),
+		inner:   inner,
+	}
+}
+
+func (v *auditingReadWriter[T]) Get(c context.Context, uid string) (T, bool, error) {
+	return auditedGet(c, v.auditor, v.inner, uid)
+}
+
+func (v *auditingReadWriter[T]) Put(c context.Context, uid string, value T) error {
+	return v.write(c, AuditOperationPut, uid, func() error {
+		return v.inner.Put(c, uid, value)
+	})
+}
+
+func (v *auditingReadWriter[T]) GetVersion(c context.Context, uid string, version int) (VaultVersion[T], bool, error) {
+	value, found, err := v.inner.GetVersion(c, uid, version)
+
+	auditErr := v.record(c, AuditOperationGetVersion, uid, found, err)
+	if err != nil {
+		return value, found, err
+	}
+	if auditErr != nil {
+		return VaultVersion[T]{}, false, auditErr
+	}
+
+	return value, found, nil
+}
+
+func (v *auditingReadWriter[T]) ListVersions(c context.Context, uid string) ([]VaultVersion[T], error) {
+	versions, err := v.inner.ListVersions(c, uid)
+
+	auditErr := v.record(c, AuditOperationListVersions, uid, len(versions) > 0, err)
+	if err != nil {
+		return nil, err
+	}
+	if auditErr != nil {
+		return nil, auditErr
+	}
+
+	return versions, nil
+}
+
+// HealthCheck touches no record, so it is not audited
+func (v *auditingReadWriter[T]) HealthCheck(c context.Context) error {
+	return v.inner.HealthCheck(c)
+}
+
+func (v *auditingReadWriter[T]) Restore(c context.Context, uid string, version int) error {
+	return v.write(c, AuditOperationRestore, uid, func() error {
+		return v.inner.Restore(c, uid, version)
+	})
+}
+
+func newAuditor[T any](trail AuditTrail, component string, nower mytime.Nower, uuider myuuid.UUIDer) auditor {
+	return auditor{
+		trail:     trail,
+		vault:     kindOf[T](),
+		component: component,
+		nower:     nower,
+		uuider:    uuider,
+	}
+}
+
+func auditedGet[T any](c context.Context, a auditor, inner VaultReader[T], uid string) (T, bool, error) {
+	value, found, err := inner.Get(c, uid)
+
+	auditErr := a.record(c, AuditOperationGet, uid, found, err)
+	if err != nil {
+		return value, found, err
+	}
+	if auditErr != nil {
+		var zero T
+		return zero, false, auditErr
+	}
+
+	return value, found, nil
+}
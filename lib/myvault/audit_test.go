@@ -0,0 +1,193 @@
+package myvault
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/mystore"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+	"github.com/MarcGrol/shopbackend/lib/myuuid"
+)
+
+func TestAuditingVault(t *testing.T) {
+	c := mycontext.WithTrace(context.TODO(), "trace-123")
+
+	t.Run("Read and write are audited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		inner, trail, nower, uuider := setupAudit(t, ctrl)
+		sut := NewAuditingReadWriter[secret](inner, trail, "oauth", nower, uuider)
+
+		// given
+		inner.EXPECT().Put(gomock.Any(), "adyen", secret{AccessToken: "abc"}).Return(nil)
+		inner.EXPECT().Get(gomock.Any(), "adyen").Return(secret{AccessToken: "abc"}, true, nil)
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		nower.EXPECT().Now().Return(mytime.ExampleTime.Add(1))
+		nower.EXPECT().Now().Return(mytime.ExampleTime.Add(2))
+		uuider.EXPECT().Create().Return("1")
+		uuider.EXPECT().Create().Return("2")
+		uuider.EXPECT().Create().Return("3")
+
+		// when
+		err := sut.Put(c, "adyen", secret{AccessToken: "abc"})
+		assert.NoError(t, err)
+		value, found, err := sut.Get(c, "adyen")
+
+		// then
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "abc", value.AccessToken)
+
+		records, err := trail.ListByVaultUID(c, "adyen")
+		assert.NoError(t, err)
+		assert.Equal(t, []AuditRecord{
+			{UID: "3", Vault: "secret", VaultUID: "adyen", Operation: AuditOperationGet, Component: "oauth", Found: true, TraceID: "trace-123", Timestamp: mytime.ExampleTime.Add(2)},
+			{UID: "2", Vault: "secret", VaultUID: "adyen", Operation: AuditOperationPut, Component: "oauth", Found: true, TraceID: "trace-123", Timestamp: mytime.ExampleTime.Add(1)},
+			{UID: "1", Vault: "secret", VaultUID: "adyen", Operation: AuditOperationPut, Component: "oauth", Pending: true, TraceID: "trace-123", Timestamp: mytime.ExampleTime},
+		}, records)
+	})
+
+	t.Run("Restore is audited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		inner, trail, nower, uuider := setupAudit(t, ctrl)
+		sut := NewAuditingReadWriter[secret](inner, trail, "oauth", nower, uuider)
+
+		// given
+		inner.EXPECT().Restore(gomock.Any(), "adyen", 2).Return(nil)
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		nower.EXPECT().Now().Return(mytime.ExampleTime.Add(1))
+		uuider.EXPECT().Create().Return("1")
+		uuider.EXPECT().Create().Return("2")
+
+		// when
+		err := sut.Restore(c, "adyen", 2)
+
+		// then
+		assert.NoError(t, err)
+		records, err := trail.ListByVaultUID(c, "adyen")
+		assert.NoError(t, err)
+		assert.Equal(t, []AuditRecord{
+			{UID: "2", Vault: "secret", VaultUID: "adyen", Operation: AuditOperationRestore, Component: "oauth", Found: true, TraceID: "trace-123", Timestamp: mytime.ExampleTime.Add(1)},
+			{UID: "1", Vault: "secret", VaultUID: "adyen", Operation: AuditOperationRestore, Component: "oauth", Pending: true, TraceID: "trace-123", Timestamp: mytime.ExampleTime},
+		}, records)
+	})
+
+	t.Run("Failed read is audited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		inner, trail, nower, uuider := setupAudit(t, ctrl)
+		sut := NewAuditingReader[secret](inner, trail, "checkoutadyen", nower, uuider)
+
+		// given
+		inner.EXPECT().Get(gomock.Any(), "adyen").Return(secret{}, false, fmt.Errorf("datastore error"))
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		uuider.EXPECT().Create().Return("1")
+
+		// when
+		_, _, err := sut.Get(c, "adyen")
+
+		// then
+		assert.Error(t, err)
+		records, err := trail.ListByVaultUID(c, "adyen")
+		assert.NoError(t, err)
+		assert.Len(t, records, 1)
+		assert.Equal(t, "datastore error", records[0].Error)
+	})
+
+	t.Run("Read fails when it cannot be audited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		inner := NewMockVaultReadWriter[secret](ctrl)
+		trail := NewMockAuditTrail(ctrl)
+		nower := mytime.NewMockNower(ctrl)
+		uuider := myuuid.NewMockUUIDer(ctrl)
+		sut := NewAuditingReader[secret](inner, trail, "checkoutadyen", nower, uuider)
+
+		// given
+		inner.EXPECT().Get(gomock.Any(), "adyen").Return(secret{AccessToken: "abc"}, true, nil)
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		uuider.EXPECT().Create().Return("1")
+		trail.EXPECT().Append(gomock.Any(), gomock.Any()).Return(fmt.Errorf("datastore error"))
+
+		// when
+		value, found, err := sut.Get(c, "adyen")
+
+		// then
+		assert.Error(t, err)
+		assert.False(t, found)
+		assert.Empty(t, value.AccessToken)
+	})
+
+	t.Run("Write is not done when it cannot be audited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		inner := NewMockVaultReadWriter[secret](ctrl)
+		trail := NewMockAuditTrail(ctrl)
+		nower := mytime.NewMockNower(ctrl)
+		uuider := myuuid.NewMockUUIDer(ctrl)
+		sut := NewAuditingReadWriter[secret](inner, trail, "oauth", nower, uuider)
+
+		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		uuider.EXPECT().Create().Return("1")
+		trail.EXPECT().Append(gomock.Any(), gomock.Any()).Return(fmt.Errorf("datastore error"))
+		inner.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		// when
+		err := sut.Put(c, "adyen", secret{AccessToken: "abc"})
+
+		// then
+		assert.Error(t, err)
+	})
+
+	t.Run("Write succeeds when its outcome cannot be audited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		inner := NewMockVaultReadWriter[secret](ctrl)
+		trail := NewMockAuditTrail(ctrl)
+		nower := mytime.NewMockNower(ctrl)
+		uuider := myuuid.NewMockUUIDer(ctrl)
+		sut := NewAuditingReadWriter[secret](inner, trail, "oauth", nower, uuider)
+
+		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime).Times(2)
+		uuider.EXPECT().Create().Return("1")
+		uuider.EXPECT().Create().Return("2")
+		gomock.InOrder(
+			trail.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil),
+			inner.EXPECT().Put(gomock.Any(), "adyen", secret{AccessToken: "abc"}).Return(nil),
+			trail.EXPECT().Append(gomock.Any(), gomock.Any()).Return(fmt.Errorf("datastore error")),
+		)
+
+		// when
+		err := sut.Put(c, "adyen", secret{AccessToken: "abc"})
+
+		// then
+		assert.NoError(t, err)
+	})
+}
+
+func setupAudit(t *testing.T, ctrl *gomock.Controller) (*MockVaultReadWriter[secret], AuditTrail, *mytime.MockNower, *myuuid.MockUUIDer) {
+	store, _, err := mystore.NewInMemoryStore[AuditRecord](context.TODO())
+	assert.NoError(t, err)
+
+	return NewMockVaultReadWriter[secret](ctrl), &auditTrail{store: store}, mytime.NewMockNower(ctrl), myuuid.NewMockUUIDer(ctrl)
+}
@@ -0,0 +1,57 @@
+package myvault
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+var (
+	ephemeralOnce     sync.Once
+	ephemeralProvider *localKeyProvider
+	ephemeralErr      error
+)
+
+// newKeyProvider selects the key-provider based on the environment:
+//   - VAULT_KMS_KEY: name of a Cloud KMS crypto-key (production)
+//   - VAULT_KEYFILE: path of a json keyfile
+//   - VAULT_KEYS: comma separated keyID:base64key pairs, current key first
+//
+// Without any of these, a random key is generated, which is only acceptable for the in-memory store.
+func newKeyProvider(c context.Context) (KeyProvider, func(), error) {
+	if keyName := os.Getenv("VAULT_KMS_KEY"); keyName != "" {
+		return newKMSKeyProvider(c, keyName)
+	}
+
+	if filename := os.Getenv("VAULT_KEYFILE"); filename != "" {
+		provider, err := newLocalKeyProviderFromFile(filename)
+		if err != nil {
+			return nil, nil, err
+		}
+		return provider, func() {}, nil
+	}
+
+	if keys := os.Getenv("VAULT_KEYS"); keys != "" {
+		provider, err := newLocalKeyProviderFromEnv(keys)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing VAULT_KEYS: %w", err)
+		}
+		return provider, func() {}, nil
+	}
+
+	if os.Getenv("GOOGLE_CLOUD_PROJECT") != "" {
+		return nil, nil, fmt.Errorf("no vault key configured: set VAULT_KMS_KEY, VAULT_KEYFILE or VAULT_KEYS")
+	}
+
+	// All vaults in this process must share the same random key
+	ephemeralOnce.Do(func() {
+		log.Printf("No vault key configured: using a random key that is lost on restart")
+		ephemeralProvider, ephemeralErr = newEphemeralKeyProvider()
+	})
+	if ephemeralErr != nil {
+		return nil, nil, ephemeralErr
+	}
+	return ephemeralProvider, func() {}, nil
+}
@@ -0,0 +1,67 @@
+package myvault
+
+import (
+	"context"
+	"fmt"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+)
+
+// kmsKeyProvider lets Cloud KMS wrap the data-keys, so the key-encryption-key never leaves KMS.
+// KMS keeps old key-versions for decryption, so rotation only requires re-encryption when old versions are to be destroyed.
+type kmsKeyProvider struct {
+	client  *kms.KeyManagementClient
+	keyName string // projects/*/locations/*/keyRings/*/cryptoKeys/*
+}
+
+func newKMSKeyProvider(c context.Context, keyName string) (*kmsKeyProvider, func(), error) {
+	client, err := kms.NewKeyManagementClient(c)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating kms-client: %w", err)
+	}
+
+	return &kmsKeyProvider{
+		client:  client,
+		keyName: keyName,
+	}, func() {
+		client.Close()
+	}, nil
+}
+
+func (p *kmsKeyProvider) CurrentKeyID(c context.Context) (string, error) {
+	key, err := p.client.GetCryptoKey(c, &kmspb.GetCryptoKeyRequest{
+		Name: p.keyName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error getting crypto-key %s: %w", p.keyName, err)
+	}
+	if key.Primary == nil {
+		return "", fmt.Errorf("crypto-key %s has no primary version", p.keyName)
+	}
+	return key.Primary.Name, nil
+}
+
+func (p *kmsKeyProvider) WrapKey(c context.Context, dataKey []byte) (string, []byte, error) {
+	resp, err := p.client.Encrypt(c, &kmspb.EncryptRequest{
+		Name:      p.keyName,
+		Plaintext: dataKey,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("error wrapping data-key with %s: %w", p.keyName, err)
+	}
+	// The response tells which key-version was used
+	return resp.Name, resp.Ciphertext, nil
+}
+
+func (p *kmsKeyProvider) UnwrapKey(c context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	// KMS finds the key-version itself: the ciphertext refers to it
+	resp, err := p.client.Decrypt(c, &kmspb.DecryptRequest{
+		Name:       p.keyName,
+		Ciphertext: wrappedKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping data-key with %s: %w", keyID, err)
+	}
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,127 @@
+package myvault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// localKeyProvider holds the key-encryption-keys in memory.
+// Meant for development: keys come from a keyfile, an env-var or are generated on the fly.
+type localKeyProvider struct {
+	currentKeyID string
+	keys         map[string][]byte
+}
+
+// keyfile is the format of the file that VAULT_KEYFILE refers to
+type keyfile struct {
+	CurrentKeyID string
+	Keys         map[string]string // base64 encoded
+}
+
+func newLocalKeyProvider(currentKeyID string, encodedKeys map[string]string) (*localKeyProvider, error) {
+	keys := map[string][]byte{}
+	for keyID, encodedKey := range encodedKeys {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding key %s: %w", keyID, err)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key %s has %d bytes, expected %d", keyID, len(key), keySize)
+		}
+		keys[keyID] = key
+	}
+
+	_, found := keys[currentKeyID]
+	if !found {
+		return nil, fmt.Errorf("current key %s not found", currentKeyID)
+	}
+
+	return &localKeyProvider{
+		currentKeyID: currentKeyID,
+		keys:         keys,
+	}, nil
+}
+
+func newLocalKeyProviderFromFile(filename string) (*localKeyProvider, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading keyfile %s: %w", filename, err)
+	}
+
+	kf := keyfile{}
+	err = json.Unmarshal(data, &kf)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing keyfile %s: %w", filename, err)
+	}
+
+	return newLocalKeyProvider(kf.CurrentKeyID, kf.Keys)
+}
+
+// newLocalKeyProviderFromEnv parses keys formatted as "keyID:base64key,...". The first key is the current one:
+// rotate by prepending a new key and re-encrypting.
+func newLocalKeyProviderFromEnv(value string) (*localKeyProvider, error) {
+	currentKeyID := ""
+	encodedKeys := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		keyID, encodedKey, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found {
+			return nil, fmt.Errorf("invalid key-entry: expected keyID:base64key")
+		}
+		if currentKeyID == "" {
+			currentKeyID = keyID
+		}
+		encodedKeys[keyID] = encodedKey
+	}
+
+	return newLocalKeyProvider(currentKeyID, encodedKeys)
+}
+
+func newEphemeralKeyProvider() (*localKeyProvider, error) {
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &localKeyProvider{
+		currentKeyID: "ephemeral",
+		keys:         map[string][]byte{"ephemeral": key},
+	}, nil
+}
+
+func (p *localKeyProvider) CurrentKeyID(c context.Context) (string, error) {
+	return p.currentKeyID, nil
+}
+
+func (p *localKeyProvider) WrapKey(c context.Context, dataKey []byte) (string, []byte, error) {
+	wrappedKey, err := seal(p.keys[p.currentKeyID], dataKey, []byte(p.currentKeyID))
+	if err != nil {
+		return "", nil, fmt.Errorf("error wrapping data-key: %w", err)
+	}
+	return p.currentKeyID, wrappedKey, nil
+}
+
+func (p *localKeyProvider) UnwrapKey(c context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	key, found := p.keys[keyID]
+	if !found {
+		return nil, fmt.Errorf("key %s not found", keyID)
+	}
+
+	dataKey, err := open(key, wrappedKey, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping data-key with key %s: %w", keyID, err)
+	}
+	return dataKey, nil
+}
+
+// GenerateEncodedKey returns a new random key-encryption-key, encoded for use in VAULT_KEYS or a keyfile
+func GenerateEncodedKey() (string, error) {
+	key, err := newKey()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
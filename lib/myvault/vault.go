@@ -2,40 +2,419 @@ package myvault
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/MarcGrol/shopbackend/lib/mystore"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
 )
 
+// defaultMaxVersions is the number of versions retained per record, including the current one
+const defaultMaxVersions = 10
+
+// EncryptedRecord is what the vault actually stores: the value is encrypted with its own data-key,
+// which in turn is wrapped by a key-encryption-key of the key-provider (envelope encryption).
+type EncryptedRecord struct {
+	UID              string
+	KeyID            string
+	EncryptedDataKey []byte `datastore:",noindex"`
+	Ciphertext       []byte `datastore:",noindex"`
+	Version          int
+	CreatedAt        time.Time
+}
+
 type vault[T any] struct {
-	store mystore.Store[T]
+	records mystore.Store[EncryptedRecord]
+	// every version of every record, keyed on uid and version
+	history mystore.Store[EncryptedRecord]
+	// plaintext records stored before encryption was introduced
+	legacy      mystore.Store[T]
+	keys        KeyProvider
+	nower       mytime.Nower
+	maxVersions int
 }
 
 func NewReaderWriter[T any](c context.Context) (VaultReadWriter[T], func(), error) {
-	store, storeCleanup, err := mystore.New[T](c)
+	return newVault[T](c)
+}
+
+func NewReader[T any](c context.Context) (VaultReader[T], func(), error) {
+	return newVault[T](c)
+}
+
+func NewReEncrypter[T any](c context.Context) (ReEncrypter, func(), error) {
+	return newVault[T](c)
+}
+
+func newVault[T any](c context.Context) (*vault[T], func(), error) {
+	legacy, legacyCleanup, err := mystore.New[T](c)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return &vault[T]{
-		store: store,
-	}, storeCleanup, nil
-}
+	records, recordsCleanup, err := mystore.NewWithKind[EncryptedRecord](c, "Encrypted"+kindOf[T]())
+	if err != nil {
+		legacyCleanup()
+		return nil, nil, err
+	}
 
-func NewReader[T any](c context.Context) (VaultReader[T], func(), error) {
-	store, storeCleanup, err := mystore.New[T](c)
+	history, historyCleanup, err := mystore.NewWithKind[EncryptedRecord](c, "EncryptedHistory"+kindOf[T]())
 	if err != nil {
+		legacyCleanup()
+		recordsCleanup()
+		return nil, nil, err
+	}
+
+	keys, keysCleanup, err := newKeyProvider(c)
+	if err != nil {
+		legacyCleanup()
+		recordsCleanup()
+		historyCleanup()
 		return nil, nil, err
 	}
 
 	return &vault[T]{
-		store: store,
-	}, storeCleanup, nil
+		records:     records,
+		history:     history,
+		legacy:      legacy,
+		keys:        keys,
+		nower:       mytime.RealNower{},
+		maxVersions: defaultMaxVersions,
+	}, func() {
+		keysCleanup()
+		historyCleanup()
+		recordsCleanup()
+		legacyCleanup()
+	}, nil
+}
+
+func kindOf[T any]() string {
+	kind := fmt.Sprintf("%T", *new(T))
+	if strings.Contains(kind, ".") {
+		kind = strings.Split(kind, ".")[1]
+	}
+	return kind
+}
+
+func historyUID(uid string, version int) string {
+	return fmt.Sprintf("%s@%d", uid, version)
 }
 
 func (v vault[T]) Put(c context.Context, uid string, value T) error {
-	return v.store.Put(c, uid, value)
+	// encrypt up front: the key-provider is remote and should not extend the transaction
+	record, err := v.encrypt(c, uid, value)
+	if err != nil {
+		return err
+	}
+
+	return v.records.RunInTransaction(c, func(c context.Context) error {
+		return v.putVersion(c, uid, record)
+	})
+}
+
+// putVersion stores the record as the next version; it must run within a transaction
+func (v vault[T]) putVersion(c context.Context, uid string, record EncryptedRecord) error {
+	current, _, err := v.records.Get(c, uid)
+	if err != nil {
+		return fmt.Errorf("error fetching vault-record %s: %w", uid, err)
+	}
+
+	record.Version = current.Version + 1
+	record.CreatedAt = v.nower.Now()
+
+	err = v.history.Put(c, historyUID(uid, record.Version), record)
+	if err != nil {
+		return fmt.Errorf("error storing version %d of vault-record %s: %w", record.Version, uid, err)
+	}
+
+	err = v.records.Put(c, uid, record)
+	if err != nil {
+		return err
+	}
+
+	if expired := record.Version - v.maxVersions; expired > 0 {
+		err = v.history.Delete(c, historyUID(uid, expired))
+		if err != nil {
+			return fmt.Errorf("error pruning version %d of vault-record %s: %w", expired, uid, err)
+		}
+	}
+
+	return nil
+}
+
+func (v vault[T]) GetVersion(c context.Context, uid string, version int) (VaultVersion[T], bool, error) {
+	record, exists, err := v.history.Get(c, historyUID(uid, version))
+	if err != nil {
+		return VaultVersion[T]{}, false, fmt.Errorf("error fetching version %d of vault-record %s: %w", version, uid, err)
+	}
+	if !exists {
+		return VaultVersion[T]{}, false, nil
+	}
+
+	value, err := v.decrypt(c, record)
+	if err != nil {
+		return VaultVersion[T]{}, false, err
+	}
+
+	return VaultVersion[T]{
+		Version:   record.Version,
+		CreatedAt: record.CreatedAt,
+		Value:     value,
+	}, true, nil
+}
+
+func (v vault[T]) HealthCheck(c context.Context) error {
+	err := v.records.HealthCheck(c)
+	if err != nil {
+		return fmt.Errorf("error reaching vault records: %w", err)
+	}
+
+	_, err = v.keys.CurrentKeyID(c)
+	if err != nil {
+		return fmt.Errorf("error reaching vault key provider: %w", err)
+	}
+
+	return nil
+}
+
+func (v vault[T]) ListVersions(c context.Context, uid string) ([]VaultVersion[T], error) {
+	current, exists, err := v.records.Get(c, uid)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching vault-record %s: %w", uid, err)
+	}
+	if !exists {
+		return []VaultVersion[T]{}, nil
+	}
+
+	versions := []VaultVersion[T]{}
+	for version := current.Version; version > 0 && version > current.Version-v.maxVersions; version-- {
+		value, exists, err := v.GetVersion(c, uid, version)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			versions = append(versions, value)
+		}
+	}
+
+	return versions, nil
+}
+
+func (v vault[T]) Restore(c context.Context, uid string, version int) error {
+	// decrypt and re-encrypt up front, like Put: the key-provider is remote and should not extend the transaction
+	previous, exists, err := v.GetVersion(c, uid, version)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("version %d of vault-record %s not found", version, uid)
+	}
+
+	record, err := v.encrypt(c, uid, previous.Value)
+	if err != nil {
+		return err
+	}
+
+	return v.records.RunInTransaction(c, func(c context.Context) error {
+		return v.putVersion(c, uid, record)
+	})
 }
 
 func (v vault[T]) Get(c context.Context, uid string) (T, bool, error) {
-	return v.store.Get(c, uid)
+	var value T
+
+	record, exists, err := v.records.Get(c, uid)
+	if err != nil {
+		return value, false, err
+	}
+	if !exists {
+		// Not migrated yet
+		return v.legacy.Get(c, uid)
+	}
+
+	value, err = v.decrypt(c, record)
+	if err != nil {
+		return value, false, err
+	}
+
+	return value, true, nil
+}
+
+func (v vault[T]) encrypt(c context.Context, uid string, value T) (EncryptedRecord, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return EncryptedRecord{}, fmt.Errorf("error marshalling vault-record %s: %w", uid, err)
+	}
+
+	dataKey, err := newKey()
+	if err != nil {
+		return EncryptedRecord{}, err
+	}
+
+	// the uid is authenticated, so a ciphertext cannot be swapped into another record
+	ciphertext, err := seal(dataKey, plaintext, []byte(uid))
+	if err != nil {
+		return EncryptedRecord{}, fmt.Errorf("error encrypting vault-record %s: %w", uid, err)
+	}
+
+	keyID, wrappedKey, err := v.keys.WrapKey(c, dataKey)
+	if err != nil {
+		return EncryptedRecord{}, err
+	}
+
+	return EncryptedRecord{
+		UID:              uid,
+		KeyID:            keyID,
+		EncryptedDataKey: wrappedKey,
+		Ciphertext:       ciphertext,
+	}, nil
+}
+
+func (v vault[T]) decrypt(c context.Context, record EncryptedRecord) (T, error) {
+	var value T
+
+	dataKey, err := v.keys.UnwrapKey(c, record.KeyID, record.EncryptedDataKey)
+	if err != nil {
+		return value, err
+	}
+
+	plaintext, err := open(dataKey, record.Ciphertext, []byte(record.UID))
+	if err != nil {
+		return value, fmt.Errorf("error decrypting vault-record %s: %w", record.UID, err)
+	}
+
+	err = json.Unmarshal(plaintext, &value)
+	if err != nil {
+		return value, fmt.Errorf("error unmarshalling vault-record %s: %w", record.UID, err)
+	}
+
+	return value, nil
+}
+
+// ReEncrypt encrypts the remaining plaintext records and rewraps the data-keys of records
+// that are protected by an older key-encryption-key
+func (v vault[T]) ReEncrypt(c context.Context) (ReEncryptReport, error) {
+	report := ReEncryptReport{}
+
+	currentKeyID, err := v.keys.CurrentKeyID(c)
+	if err != nil {
+		return report, err
+	}
+
+	for _, store := range []mystore.Store[EncryptedRecord]{v.records, v.history} {
+		err = v.rewrap(c, store, currentKeyID, &report)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	migrated, err := v.migrateLegacy(c)
+	report.Migrated = migrated
+	if err != nil {
+		return report, err
+	}
+
+	log.Printf("Re-encrypted vault %s: %+v", kindOf[T](), report)
+
+	return report, nil
+}
+
+func (v vault[T]) rewrap(c context.Context, store mystore.Store[EncryptedRecord], currentKeyID string, report *ReEncryptReport) error {
+	uids, err := store.ListUIDs(c)
+	if err != nil {
+		return fmt.Errorf("error listing vault-records: %w", err)
+	}
+	for _, uid := range uids {
+		rewrapped := false
+		err = store.RunInTransaction(c, func(c context.Context) error {
+			record, exists, err := store.Get(c, uid)
+			if err != nil {
+				return fmt.Errorf("error fetching vault-record %s: %w", uid, err)
+			}
+			if !exists || record.KeyID == currentKeyID {
+				rewrapped = false
+				return nil
+			}
+
+			dataKey, err := v.keys.UnwrapKey(c, record.KeyID, record.EncryptedDataKey)
+			if err != nil {
+				return err
+			}
+			record.KeyID, record.EncryptedDataKey, err = v.keys.WrapKey(c, dataKey)
+			if err != nil {
+				return err
+			}
+			err = store.Put(c, uid, record)
+			if err != nil {
+				return fmt.Errorf("error storing vault-record %s: %w", uid, err)
+			}
+			rewrapped = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// counted outside the transaction, which may be retried
+		if rewrapped {
+			report.Rewrapped++
+		} else {
+			report.Unchanged++
+		}
+	}
+	return nil
+}
+
+func (v vault[T]) migrateLegacy(c context.Context) (int, error) {
+	uids, err := v.legacy.ListUIDs(c)
+	if err != nil {
+		return 0, fmt.Errorf("error listing plaintext vault-records: %w", err)
+	}
+
+	migrated := 0
+	for _, uid := range uids {
+		value, exists, err := v.legacy.Get(c, uid)
+		if err != nil {
+			return migrated, fmt.Errorf("error fetching plaintext vault-record %s: %w", uid, err)
+		}
+		if !exists {
+			continue
+		}
+
+		record, err := v.encrypt(c, uid, value)
+		if err != nil {
+			return migrated, err
+		}
+
+		encrypted := false
+		err = v.records.RunInTransaction(c, func(c context.Context) error {
+			_, encrypted, err = v.records.Get(c, uid)
+			if err != nil {
+				return fmt.Errorf("error fetching vault-record %s: %w", uid, err)
+			}
+			if !encrypted {
+				// an encrypted record always wins: it was written after the plaintext one
+				err = v.putVersion(c, uid, record)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = v.legacy.Delete(c, uid)
+			if err != nil {
+				return fmt.Errorf("error deleting plaintext vault-record %s: %w", uid, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return migrated, err
+		}
+		if !encrypted {
+			migrated++
+		}
+	}
+
+	return migrated, nil
 }
@@ -96,6 +96,51 @@ func (mr *MockVaultReadWriterMockRecorder[T]) Get(c, uid any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockVaultReadWriter[T])(nil).Get), c, uid)
 }
 
+// GetVersion mocks base method.
+func (m *MockVaultReadWriter[T]) GetVersion(c context.Context, uid string, version int) (VaultVersion[T], bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", c, uid, version)
+	ret0, _ := ret[0].(VaultVersion[T])
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockVaultReadWriterMockRecorder[T]) GetVersion(c, uid, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockVaultReadWriter[T])(nil).GetVersion), c, uid, version)
+}
+
+// HealthCheck mocks base method.
+func (m *MockVaultReadWriter[T]) HealthCheck(c context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthCheck", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HealthCheck indicates an expected call of HealthCheck.
+func (mr *MockVaultReadWriterMockRecorder[T]) HealthCheck(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockVaultReadWriter[T])(nil).HealthCheck), c)
+}
+
+// ListVersions mocks base method.
+func (m *MockVaultReadWriter[T]) ListVersions(c context.Context, uid string) ([]VaultVersion[T], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVersions", c, uid)
+	ret0, _ := ret[0].([]VaultVersion[T])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVersions indicates an expected call of ListVersions.
+func (mr *MockVaultReadWriterMockRecorder[T]) ListVersions(c, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVersions", reflect.TypeOf((*MockVaultReadWriter[T])(nil).ListVersions), c, uid)
+}
+
 // Put mocks base method.
 func (m *MockVaultReadWriter[T]) Put(c context.Context, uid string, value T) error {
 	m.ctrl.T.Helper()
@@ -109,3 +154,179 @@ func (mr *MockVaultReadWriterMockRecorder[T]) Put(c, uid, value any) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockVaultReadWriter[T])(nil).Put), c, uid, value)
 }
+
+// Restore mocks base method.
+func (m *MockVaultReadWriter[T]) Restore(c context.Context, uid string, version int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", c, uid, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockVaultReadWriterMockRecorder[T]) Restore(c, uid, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockVaultReadWriter[T])(nil).Restore), c, uid, version)
+}
+
+// MockKeyProvider is a mock of KeyProvider interface.
+type MockKeyProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockKeyProviderMockRecorder is the mock recorder for MockKeyProvider.
+type MockKeyProviderMockRecorder struct {
+	mock *MockKeyProvider
+}
+
+// NewMockKeyProvider creates a new mock instance.
+func NewMockKeyProvider(ctrl *gomock.Controller) *MockKeyProvider {
+	mock := &MockKeyProvider{ctrl: ctrl}
+	mock.recorder = &MockKeyProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyProvider) EXPECT() *MockKeyProviderMockRecorder {
+	return m.recorder
+}
+
+// CurrentKeyID mocks base method.
+func (m *MockKeyProvider) CurrentKeyID(c context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CurrentKeyID", c)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CurrentKeyID indicates an expected call of CurrentKeyID.
+func (mr *MockKeyProviderMockRecorder) CurrentKeyID(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrentKeyID", reflect.TypeOf((*MockKeyProvider)(nil).CurrentKeyID), c)
+}
+
+// UnwrapKey mocks base method.
+func (m *MockKeyProvider) UnwrapKey(c context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnwrapKey", c, keyID, wrappedKey)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnwrapKey indicates an expected call of UnwrapKey.
+func (mr *MockKeyProviderMockRecorder) UnwrapKey(c, keyID, wrappedKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnwrapKey", reflect.TypeOf((*MockKeyProvider)(nil).UnwrapKey), c, keyID, wrappedKey)
+}
+
+// WrapKey mocks base method.
+func (m *MockKeyProvider) WrapKey(c context.Context, dataKey []byte) (string, []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WrapKey", c, dataKey)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// WrapKey indicates an expected call of WrapKey.
+func (mr *MockKeyProviderMockRecorder) WrapKey(c, dataKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WrapKey", reflect.TypeOf((*MockKeyProvider)(nil).WrapKey), c, dataKey)
+}
+
+// MockReEncrypter is a mock of ReEncrypter interface.
+type MockReEncrypter struct {
+	ctrl     *gomock.Controller
+	recorder *MockReEncrypterMockRecorder
+	isgomock struct{}
+}
+
+// MockReEncrypterMockRecorder is the mock recorder for MockReEncrypter.
+type MockReEncrypterMockRecorder struct {
+	mock *MockReEncrypter
+}
+
+// NewMockReEncrypter creates a new mock instance.
+func NewMockReEncrypter(ctrl *gomock.Controller) *MockReEncrypter {
+	mock := &MockReEncrypter{ctrl: ctrl}
+	mock.recorder = &MockReEncrypterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReEncrypter) EXPECT() *MockReEncrypterMockRecorder {
+	return m.recorder
+}
+
+// ReEncrypt mocks base method.
+func (m *MockReEncrypter) ReEncrypt(c context.Context) (ReEncryptReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReEncrypt", c)
+	ret0, _ := ret[0].(ReEncryptReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReEncrypt indicates an expected call of ReEncrypt.
+func (mr *MockReEncrypterMockRecorder) ReEncrypt(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReEncrypt", reflect.TypeOf((*MockReEncrypter)(nil).ReEncrypt), c)
+}
+
+// MockAuditTrail is a mock of AuditTrail interface.
+type MockAuditTrail struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditTrailMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditTrailMockRecorder is the mock recorder for MockAuditTrail.
+type MockAuditTrailMockRecorder struct {
+	mock *MockAuditTrail
+}
+
+// NewMockAuditTrail creates a new mock instance.
+func NewMockAuditTrail(ctrl *gomock.Controller) *MockAuditTrail {
+	mock := &MockAuditTrail{ctrl: ctrl}
+	mock.recorder = &MockAuditTrailMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditTrail) EXPECT() *MockAuditTrailMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockAuditTrail) Append(c context.Context, record AuditRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", c, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockAuditTrailMockRecorder) Append(c, record any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockAuditTrail)(nil).Append), c, record)
+}
+
+// ListByVaultUID mocks base method.
+func (m *MockAuditTrail) ListByVaultUID(c context.Context, vaultUID string) ([]AuditRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByVaultUID", c, vaultUID)
+	ret0, _ := ret[0].([]AuditRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByVaultUID indicates an expected call of ListByVaultUID.
+func (mr *MockAuditTrailMockRecorder) ListByVaultUID(c, vaultUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByVaultUID", reflect.TypeOf((*MockAuditTrail)(nil).ListByVaultUID), c, vaultUID)
+}
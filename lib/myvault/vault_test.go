@@ -0,0 +1,318 @@
+package myvault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/MarcGrol/shopbackend/lib/mystore"
+	"github.com/MarcGrol/shopbackend/lib/mytime"
+)
+
+type secret struct {
+	AccessToken string
+}
+
+const (
+	key1 = "MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="
+	key2 = "YWJjZGVmZ2hpamtsbW5vcHFyc3R1dnd4eXphYmNkZWY="
+)
+
+func TestVault(t *testing.T) {
+	c := context.TODO()
+
+	t.Run("Value is encrypted at rest", func(t *testing.T) {
+		// setup
+		sut, records, _ := setup(t, "k1:"+key1)
+
+		// when
+		err := sut.Put(c, "adyen", secret{AccessToken: "my-access-token"})
+
+		// then
+		assert.NoError(t, err)
+		record, exists, err := records.Get(c, "adyen")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "k1", record.KeyID)
+		assert.False(t, strings.Contains(string(record.Ciphertext), "my-access-token"))
+
+		value, exists, err := sut.Get(c, "adyen")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "my-access-token", value.AccessToken)
+	})
+
+	t.Run("Ciphertext is bound to its uid", func(t *testing.T) {
+		// setup
+		sut, records, _ := setup(t, "k1:"+key1)
+		err := sut.Put(c, "adyen", secret{AccessToken: "my-access-token"})
+		assert.NoError(t, err)
+
+		// given
+		record, _, err := records.Get(c, "adyen")
+		assert.NoError(t, err)
+		record.UID = "stripe"
+		err = records.Put(c, "stripe", record)
+		assert.NoError(t, err)
+
+		// when
+		_, _, err = sut.Get(c, "stripe")
+
+		// then
+		assert.Error(t, err)
+	})
+
+	t.Run("Unknown uid", func(t *testing.T) {
+		// setup
+		sut, _, _ := setup(t, "k1:"+key1)
+
+		// when
+		_, exists, err := sut.Get(c, "adyen")
+
+		// then
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("Plaintext record is readable and migrated", func(t *testing.T) {
+		// setup
+		sut, records, legacy := setup(t, "k1:"+key1)
+
+		// given
+		err := legacy.Put(c, "adyen", secret{AccessToken: "my-access-token"})
+		assert.NoError(t, err)
+		value, exists, err := sut.Get(c, "adyen")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "my-access-token", value.AccessToken)
+
+		// when
+		report, err := sut.ReEncrypt(c)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, ReEncryptReport{Migrated: 1}, report)
+		_, exists, err = legacy.Get(c, "adyen")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		_, exists, err = records.Get(c, "adyen")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("Rotate key-encryption-key", func(t *testing.T) {
+		// setup
+		sut, records, legacy := setup(t, "k1:"+key1)
+		err := sut.Put(c, "adyen", secret{AccessToken: "my-access-token"})
+		assert.NoError(t, err)
+
+		// given
+		rotated := newVaultWith(t, records, sut.history, legacy, "k2:"+key2+",k1:"+key1)
+
+		// when
+		report, err := rotated.ReEncrypt(c)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, ReEncryptReport{Rewrapped: 2}, report) // current version and its history
+
+		// old key can be dropped
+		withoutOldKey := newVaultWith(t, records, sut.history, legacy, "k2:"+key2)
+		value, exists, err := withoutOldKey.Get(c, "adyen")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "my-access-token", value.AccessToken)
+
+		report, err = withoutOldKey.ReEncrypt(c)
+		assert.NoError(t, err)
+		assert.Equal(t, ReEncryptReport{Unchanged: 2}, report)
+
+		version, exists, err := withoutOldKey.GetVersion(c, "adyen", 1)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "my-access-token", version.Value.AccessToken)
+	})
+}
+
+func TestVaultVersions(t *testing.T) {
+	c := context.TODO()
+
+	t.Run("Previous versions are kept, most recent first", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		sut, _, _ := setup(t, "k1:"+key1)
+		nower := mytime.NewMockNower(ctrl)
+		sut.nower = nower
+
+		// given
+		for i, token := range []string{"token-1", "token-2", "token-3"} {
+			nower.EXPECT().Now().Return(mytime.ExampleTime.Add(time.Duration(i) * time.Hour))
+			err := sut.Put(c, "adyen", secret{AccessToken: token})
+			assert.NoError(t, err)
+		}
+
+		// when
+		versions, err := sut.ListVersions(c, "adyen")
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, []VaultVersion[secret]{
+			{Version: 3, CreatedAt: mytime.ExampleTime.Add(2 * time.Hour), Value: secret{AccessToken: "token-3"}},
+			{Version: 2, CreatedAt: mytime.ExampleTime.Add(1 * time.Hour), Value: secret{AccessToken: "token-2"}},
+			{Version: 1, CreatedAt: mytime.ExampleTime, Value: secret{AccessToken: "token-1"}},
+		}, versions)
+
+		version, exists, err := sut.GetVersion(c, "adyen", 2)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "token-2", version.Value.AccessToken)
+	})
+
+	t.Run("Restore previous version", func(t *testing.T) {
+		// setup
+		sut, _, _ := setup(t, "k1:"+key1)
+		err := sut.Put(c, "adyen", secret{AccessToken: "good-token"})
+		assert.NoError(t, err)
+		err = sut.Put(c, "adyen", secret{AccessToken: "broken-token"})
+		assert.NoError(t, err)
+
+		// when
+		err = sut.Restore(c, "adyen", 1)
+
+		// then
+		assert.NoError(t, err)
+		value, exists, err := sut.Get(c, "adyen")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "good-token", value.AccessToken)
+
+		versions, err := sut.ListVersions(c, "adyen")
+		assert.NoError(t, err)
+		assert.Len(t, versions, 3)
+		assert.Equal(t, 3, versions[0].Version)
+	})
+
+	t.Run("Restore unknown version", func(t *testing.T) {
+		// setup
+		sut, _, _ := setup(t, "k1:"+key1)
+		err := sut.Put(c, "adyen", secret{AccessToken: "good-token"})
+		assert.NoError(t, err)
+
+		// when
+		err = sut.Restore(c, "adyen", 7)
+
+		// then
+		assert.Error(t, err)
+	})
+
+	t.Run("Only the last versions are retained", func(t *testing.T) {
+		// setup
+		sut, _, _ := setup(t, "k1:"+key1)
+		sut.maxVersions = 2
+
+		// given
+		for _, token := range []string{"token-1", "token-2", "token-3"} {
+			err := sut.Put(c, "adyen", secret{AccessToken: token})
+			assert.NoError(t, err)
+		}
+
+		// when
+		versions, err := sut.ListVersions(c, "adyen")
+
+		// then
+		assert.NoError(t, err)
+		assert.Len(t, versions, 2)
+		assert.Equal(t, "token-3", versions[0].Value.AccessToken)
+		assert.Equal(t, "token-2", versions[1].Value.AccessToken)
+		_, exists, err := sut.GetVersion(c, "adyen", 1)
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("Concurrent writes do not lose versions", func(t *testing.T) {
+		// setup
+		sut, _, _ := setup(t, "k1:"+key1)
+		sut.maxVersions = 20
+
+		// when
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := sut.Put(c, "adyen", secret{AccessToken: fmt.Sprintf("token-%d", i)})
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+
+		// then
+		versions, err := sut.ListVersions(c, "adyen")
+		assert.NoError(t, err)
+		assert.Len(t, versions, 10)
+		assert.Equal(t, 10, versions[0].Version)
+	})
+
+	t.Run("Record without history", func(t *testing.T) {
+		// setup
+		sut, _, _ := setup(t, "k1:"+key1)
+
+		// when
+		versions, err := sut.ListVersions(c, "adyen")
+
+		// then
+		assert.NoError(t, err)
+		assert.Empty(t, versions)
+	})
+}
+
+func TestLocalKeyProvider(t *testing.T) {
+	t.Run("First key is current", func(t *testing.T) {
+		provider, err := newLocalKeyProviderFromEnv("k2:" + key2 + ",k1:" + key1)
+		assert.NoError(t, err)
+		keyID, err := provider.CurrentKeyID(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, "k2", keyID)
+	})
+
+	t.Run("Invalid key length", func(t *testing.T) {
+		_, err := newLocalKeyProviderFromEnv("k1:c2hvcnQ=")
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		_, err := newLocalKeyProviderFromEnv(key1)
+		assert.Error(t, err)
+	})
+}
+
+func setup(t *testing.T, keys string) (*vault[secret], mystore.Store[EncryptedRecord], mystore.Store[secret]) {
+	records, _, err := mystore.NewInMemoryStore[EncryptedRecord](context.TODO())
+	assert.NoError(t, err)
+	history, _, err := mystore.NewInMemoryStore[EncryptedRecord](context.TODO())
+	assert.NoError(t, err)
+	legacy, _, err := mystore.NewInMemoryStore[secret](context.TODO())
+	assert.NoError(t, err)
+
+	return newVaultWith(t, records, history, legacy, keys), records, legacy
+}
+
+func newVaultWith(t *testing.T, records mystore.Store[EncryptedRecord], history mystore.Store[EncryptedRecord], legacy mystore.Store[secret], keys string) *vault[secret] {
+	provider, err := newLocalKeyProviderFromEnv(keys)
+	assert.NoError(t, err)
+
+	return &vault[secret]{
+		records:     records,
+		history:     history,
+		legacy:      legacy,
+		keys:        provider,
+		nower:       mytime.RealNower{},
+		maxVersions: defaultMaxVersions,
+	}
+}
@@ -2,17 +2,28 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/myeventstore"
+	"github.com/MarcGrol/shopbackend/lib/myhealth"
+	"github.com/MarcGrol/shopbackend/lib/myhttp"
+	"github.com/MarcGrol/shopbackend/lib/myjobs"
+	"github.com/MarcGrol/shopbackend/lib/mylog"
 	"github.com/MarcGrol/shopbackend/lib/mypublisher"
 	"github.com/MarcGrol/shopbackend/lib/mypubsub"
 	"github.com/MarcGrol/shopbackend/lib/myqueue"
+	"github.com/MarcGrol/shopbackend/lib/myreplay"
 	"github.com/MarcGrol/shopbackend/lib/mystore"
 	"github.com/MarcGrol/shopbackend/lib/mytime"
 	"github.com/MarcGrol/shopbackend/lib/myuuid"
@@ -22,6 +33,7 @@ import (
 	"github.com/MarcGrol/shopbackend/services/checkoutapi"
 	"github.com/MarcGrol/shopbackend/services/checkoutmollie"
 	"github.com/MarcGrol/shopbackend/services/checkoutstripe"
+	"github.com/MarcGrol/shopbackend/services/eventspec"
 	"github.com/MarcGrol/shopbackend/services/oauth"
 	"github.com/MarcGrol/shopbackend/services/oauth/oauthclient"
 	"github.com/MarcGrol/shopbackend/services/oauth/oauthclient/challenge"
@@ -34,24 +46,70 @@ import (
 
 func main() {
 	log.Printf("Version: %s", version.Commit)
+	// registered first, so it runs after all other deferred cleanups
+	exitCode := 0
+	defer func() {
+		os.Exit(exitCode)
+	}()
+
 	c := context.Background()
 	router := mux.NewRouter()
+	if getenvWithDefault("DEBUG_VARS", "false") == "true" {
+		// exposes the command-line and memory statistics: never enable on a public host
+		router.Handle("/debug/vars", expvar.Handler()).Methods("GET")
+	}
 	nower := mytime.RealNower{}
 	uuider := myuuid.RealUUIDer{}
+	health := myhealth.New()
+	health.RegisterEndpoints(c, router)
+	myhttp.RegisterProblemCatalogue(router)
+
+	secrets, secretsCleanup, err := myconfig.New(c, nower)
+	if err != nil {
+		log.Fatalf("Error creating secrets: %s", err)
+	}
+	defer secretsCleanup()
 
 	queue, queueCleanup, err := myqueue.New(c)
 	if err != nil {
 		log.Fatalf("Error creating queue: %s", err)
 	}
 	defer queueCleanup()
+	health.Register("queue", queue)
+
+	err = queue.Reconcile(c)
+	if err != nil {
+		log.Fatalf("Error reconciling queues: %s", err)
+	}
 
 	subscriber, pubsubCleanup, err := mypubsub.New(c)
 	if err != nil {
 		log.Fatalf("Error creating pubsub: %s", err)
 	}
 	defer pubsubCleanup()
+	health.Register("pubsub", subscriber)
+
+	// Register before the event store, so its route takes precedence over /api/events/{aggregateUID}
+	createEventSpecService(c, router)
+
+	eventStore, eventStoreCleanup, err := myeventstore.New(c)
+	if err != nil {
+		log.Fatalf("Error creating event store: %s", err)
+	}
+	defer eventStoreCleanup()
+	eventStore.RegisterEndpoints(c, router)
+
+	replayer := myreplay.New(eventStore)
+	replayer.RegisterEndpoints(c, router)
 
-	eventPublisher, eventPublisherCleanup, err := mypublisher.New(c, subscriber, queue, nower)
+	jobs, jobsCleanup, err := myjobs.New(c, queue, nower)
+	if err != nil {
+		log.Fatalf("Error creating jobs: %s", err)
+	}
+	defer jobsCleanup()
+	jobs.RegisterEndpoints(c, router)
+
+	eventPublisher, eventPublisherCleanup, err := mypublisher.New(c, subscriber, jobs, eventStore, nower)
 	if err != nil {
 		log.Fatalf("Error creating event publisher: %s", err)
 	}
@@ -63,33 +121,55 @@ func main() {
 		log.Fatalf("Error creating vault: %s", err)
 	}
 	defer vaultCleanup()
+	health.Register("vault", vault)
+
+	auditTrail, auditTrailCleanup, err := myvault.NewAuditTrail(c)
+	if err != nil {
+		log.Fatalf("Error creating vault audit trail: %s", err)
+	}
+	defer auditTrailCleanup()
+	// Every component gets its own view on the vault, so the audit trail tells who accessed what
+	auditedVault := func(component string) myvault.VaultReader[oauthvault.Token] {
+		return myvault.NewAuditingReader[oauthvault.Token](vault, auditTrail, component, nower, uuider)
+	}
 
 	checkoutStore, checkoutStoreCleanup, err := mystore.New[checkoutapi.CheckoutContext](c)
 	if err != nil {
 		log.Fatalf("Error creating checkout store: %s", err)
 	}
 	defer checkoutStoreCleanup()
+	health.Register("store", checkoutStore)
 
-	oauthServiceCleanup := createOAuthService(c, router, vault, nower, uuider, eventPublisher)
+	ledger, ledgerCleanup, err := mystore.New[myevents.ProcessedEnvelope](c)
+	if err != nil {
+		log.Fatalf("Error creating processed-envelope ledger: %s", err)
+	}
+	defer ledgerCleanup()
+
+	oauthServiceCleanup := createOAuthService(c, router, secrets, myvault.NewAuditingReadWriter[oauthvault.Token](vault, auditTrail, "oauth", nower, uuider), auditTrail, nower, uuider, eventPublisher)
 	defer oauthServiceCleanup()
 
-	createAdyenCheckoutService(c, router, checkoutStore, vault, nower, subscriber, eventPublisher)
+	createAdyenCheckoutService(c, router, secrets, replayer, checkoutStore, auditedVault("checkoutadyen"), ledger, nower, subscriber, eventPublisher)
 
-	createStripeCheckoutService(c, router, checkoutStore, vault, nower, subscriber, eventPublisher)
+	createStripeCheckoutService(c, router, secrets, checkoutStore, auditedVault("checkoutstripe"), nower, subscriber, eventPublisher)
 
-	createMollieCheckoutService(c, router, checkoutStore, vault, nower, subscriber, eventPublisher)
+	createMollieCheckoutService(c, router, secrets, checkoutStore, auditedVault("checkoutmollie"), nower, subscriber, eventPublisher)
 
-	shopServiceCleanup := createShopService(c, router, nower, uuider, subscriber, eventPublisher)
+	shopServiceCleanup := createShopService(c, router, replayer, ledger, eventStore, nower, uuider, subscriber, eventPublisher)
 	defer shopServiceCleanup()
 
-	createWarmupService(c, router, vault, uuider, eventPublisher)
+	createWarmupService(c, router, auditedVault("warmup"), uuider, eventPublisher)
 
 	createTermsConditionsService(c, router, eventPublisher)
 
-	startWebServerBlocking(router)
+	err = startWebServerBlocking(router, health, nower, uuider)
+	if err != nil {
+		log.Printf("Error running webserver: %s", err)
+		exitCode = 1
+	}
 }
 
-func createShopService(c context.Context, router *mux.Router, nower mytime.Nower,
+func createShopService(c context.Context, router *mux.Router, replayer *myreplay.Replayer, ledger mystore.Store[myevents.ProcessedEnvelope], eventStore myeventstore.EventStore, nower mytime.Nower,
 	uuider myuuid.UUIDer, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) func() {
 
 	basketStore, basketstoreCleanup, err := mystore.New[shop.Basket](c)
@@ -97,20 +177,22 @@ func createShopService(c context.Context, router *mux.Router, nower mytime.Nower
 		log.Fatalf("Error creating basket store: %s", err)
 	}
 
-	basketService := shop.NewService(basketStore, nower, uuider, subscriber, publisher)
+	basketService := shop.NewService(basketStore, eventStore, ledger, nower, uuider, subscriber, publisher)
 	err = basketService.RegisterEndpoints(c, router)
 	if err != nil {
 		log.Fatalf("Error registering basket store: %s", err)
 	}
+	replayer.RegisterConsumer("shop", basketService.EventDispatcher())
 
 	return basketstoreCleanup
 }
 
-func createOAuthService(c context.Context, router *mux.Router, vault myvault.VaultReadWriter[oauthvault.Token], nower mytime.Nower, uuider myuuid.UUIDer, pub mypublisher.Publisher) func() {
+func createOAuthService(c context.Context, router *mux.Router, secrets *myconfig.Secrets, vault myvault.VaultReadWriter[oauthvault.Token], auditTrail myvault.AuditTrail, nower mytime.Nower, uuider myuuid.UUIDer, pub mypublisher.Publisher) func() {
 	partyVault, partyStoreCleanup, err := myvault.NewReaderWriter[providers.OauthParty](c)
 	if err != nil {
 		log.Fatalf("Error creating oauth-party store: %s", err)
 	}
+	partyVault = myvault.NewAuditingReadWriter[providers.OauthParty](partyVault, auditTrail, "oauth", nower, uuider)
 
 	sessionStore, sessionStoreCleanup, err := mystore.New[oauth.OAuthSessionSetup](c)
 	if err != nil {
@@ -120,25 +202,25 @@ func createOAuthService(c context.Context, router *mux.Router, vault myvault.Vau
 	providers := providers.NewProviders()
 
 	{
-		clientID := getenvOrAbort("ADYEN_OAUTH_CLIENT_ID")
-		clientSecret := getenvOrAbort("ADYEN_OAUTH_CLIENT_SECRET")
+		clientID := getSecretValueOrAbort(c, secrets, "ADYEN_OAUTH_CLIENT_ID")
+		clientSecret := getSecretOrAbort(c, secrets, "ADYEN_OAUTH_CLIENT_SECRET")
 		providers.Set("adyen", clientID, clientSecret, "", "")
 	}
 
 	{
-		clientID := getenvOrAbort("STRIPE_OAUTH_CLIENT_ID")
-		clientSecret := getenvOrAbort("STRIPE_OAUTH_CLIENT_SECRET")
+		clientID := getSecretValueOrAbort(c, secrets, "STRIPE_OAUTH_CLIENT_ID")
+		clientSecret := getSecretOrAbort(c, secrets, "STRIPE_OAUTH_CLIENT_SECRET")
 		providers.Set("stripe", clientID, clientSecret, "", "")
 	}
 
 	{
-		clientID := getenvOrAbort("MOLLIE_OAUTH_CLIENT_ID")
-		clientSecret := getenvOrAbort("MOLLIE_OAUTH_CLIENT_SECRET")
+		clientID := getSecretValueOrAbort(c, secrets, "MOLLIE_OAUTH_CLIENT_ID")
+		clientSecret := getSecretOrAbort(c, secrets, "MOLLIE_OAUTH_CLIENT_SECRET")
 		providers.Set("mollie", clientID, clientSecret, "", "")
 	}
 
 	oauthClient := oauthclient.NewOAuthClient(providers, challenge.NewRandomStringer())
-	oauthService := oauth.NewService(partyVault, sessionStore, vault, nower, uuider, oauthClient, pub, providers)
+	oauthService := oauth.NewService(partyVault, sessionStore, vault, nower, uuider, oauthClient, pub, providers, auditTrail)
 
 	err = oauthService.RegisterEndpoints(c, router)
 	if err != nil {
@@ -151,11 +233,11 @@ func createOAuthService(c context.Context, router *mux.Router, vault myvault.Vau
 	}
 }
 
-func createAdyenCheckoutService(c context.Context, router *mux.Router, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], nower mytime.Nower, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) {
+func createAdyenCheckoutService(c context.Context, router *mux.Router, secrets *myconfig.Secrets, replayer *myreplay.Replayer, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], ledger mystore.Store[myevents.ProcessedEnvelope], nower mytime.Nower, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) {
 
 	merchantAccount := getenvOrAbort("ADYEN_MERCHANT_ACCOUNT")
 	environment := getenvOrAbort("ADYEN_ENVIRONMENT")
-	apiKey := getenvOrAbort("ADYEN_API_KEY")
+	apiKey := getSecretOrAbort(c, secrets, "ADYEN_API_KEY")
 	clientKey := getenvOrAbort("ADYEN_CLIENT_KEY")
 
 	cfg := checkoutadyen.Config{
@@ -165,9 +247,9 @@ func createAdyenCheckoutService(c context.Context, router *mux.Router, checkoutS
 		APIKey:          apiKey,
 	}
 
-	payer := checkoutadyen.NewPayer(environment, apiKey)
+	payer := checkoutadyen.NewPayer(environment)
 
-	checkoutService, err := checkoutadyen.NewWebService(cfg, payer, checkoutStore, vault, nower, subscriber, publisher)
+	checkoutService, err := checkoutadyen.NewWebService(cfg, payer, checkoutStore, vault, ledger, nower, subscriber, publisher)
 	if err != nil {
 		log.Fatalf("Error creating adyen checkoutService: %s", err)
 	}
@@ -176,11 +258,12 @@ func createAdyenCheckoutService(c context.Context, router *mux.Router, checkoutS
 	if err != nil {
 		log.Fatalf("Error registering adyen checkout service: %s", err)
 	}
+	replayer.RegisterConsumer("checkoutadyen", checkoutService.EventDispatcher())
 }
 
-func createStripeCheckoutService(c context.Context, router *mux.Router, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], nower mytime.Nower, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) {
+func createStripeCheckoutService(c context.Context, router *mux.Router, secrets *myconfig.Secrets, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], nower mytime.Nower, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) {
 
-	apiKey := getenvOrAbort("STRIPE_API_KEY")
+	apiKey := getSecretOrAbort(c, secrets, "STRIPE_API_KEY")
 
 	payer := checkoutstripe.NewPayer()
 
@@ -195,9 +278,9 @@ func createStripeCheckoutService(c context.Context, router *mux.Router, checkout
 	}
 }
 
-func createMollieCheckoutService(c context.Context, router *mux.Router, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], nower mytime.Nower, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) {
+func createMollieCheckoutService(c context.Context, router *mux.Router, secrets *myconfig.Secrets, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], nower mytime.Nower, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) {
 
-	apiKey := getenvOrAbort("MOLLIE_API_KEY")
+	apiKey := getSecretOrAbort(c, secrets, "MOLLIE_API_KEY")
 
 	payer, err := checkoutmollie.NewPayer()
 	if err != nil {
@@ -231,23 +314,77 @@ func createTermsConditionsService(c context.Context, router *mux.Router, pub myp
 	}
 }
 
-func startWebServerBlocking(router *mux.Router) {
+func createEventSpecService(c context.Context, router *mux.Router) {
+	service := eventspec.NewService()
+	err := service.RegisterEndpoints(c, router)
+	if err != nil {
+		log.Fatalf("Error registering eventspec service: %s", err)
+	}
+}
+
+// startWebServerBlocking returns after a graceful shutdown, so the deferred cleanups in main are run
+func startWebServerBlocking(router *mux.Router, health *myhealth.Health, nower mytime.Nower, uuider myuuid.UUIDer) error {
 	port := getenvWithDefault("PORT", "8080")
+	drainTimeout := getDurationWithDefault("SHUTDOWN_DRAIN_TIMEOUT", 10*time.Second)
+	// at least one period of the readiness-probe, so the load-balancer sees "draining" before the listener closes
+	readinessDelay := getDurationWithDefault("SHUTDOWN_READINESS_DELAY", 5*time.Second)
 
 	log.Printf("Starting webserver on port %s (try http://localhost:%s)", port, port)
 
+	// the access-log and recovery are outside the timeout-handler, so timeouts and panics are logged too
+	handler := myhttp.Chain(http.TimeoutHandler(router, 10*time.Second, "Timeout!\n"), myhttp.Standard(mylog.New("http"), nower, uuider)...)
+
 	srv := http.Server{
 		Addr:         fmt.Sprintf(":%s", port),
 		WriteTimeout: 10 * time.Second,
 		ReadTimeout:  5 * time.Second,
 		IdleTimeout:  5 * time.Second,
-		Handler:      http.TimeoutHandler(http.HandlerFunc(router.ServeHTTP), 10*time.Second, "Timeout!\n"),
+		Handler:      handler,
+	}
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		serverErrors <- srv.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErrors:
+		return fmt.Errorf("error starting webserver on port %s: %w", port, err)
+	case sig := <-signals:
+		log.Printf("Received signal %s: shutting down", sig)
+	}
+
+	// Stop receiving new traffic, but give the requests in flight time to complete
+	health.Drain()
+	time.Sleep(readinessDelay)
+
+	c, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(c)
+	if err != nil {
+		return fmt.Errorf("error draining webserver within %s: %w", drainTimeout, err)
 	}
+	log.Printf("Webserver stopped")
+
+	return nil
+}
 
-	err := srv.ListenAndServe()
+// getSecretOrAbort fails fast on a missing secret, but returns a handle so a rotated value is picked up at runtime
+func getSecretOrAbort(c context.Context, secrets *myconfig.Secrets, name string) myconfig.Secret {
+	getSecretValueOrAbort(c, secrets, name)
+	return secrets.Secret(name)
+}
+
+func getSecretValueOrAbort(c context.Context, secrets *myconfig.Secrets, name string) string {
+	value, err := secrets.Get(c, name)
 	if err != nil {
-		log.Fatalf("Error starting webserver on port %s: %s", port, err)
+		log.Fatalf("terminatiing because of missing mandatory secret %s: %s", name, err)
 	}
+	return value
 }
 
 func getenvOrAbort(name string) string {
@@ -265,3 +402,15 @@ func getenvWithDefault(name string, valueWhenNotSet string) string {
 	}
 	return value
 }
+
+func getDurationWithDefault(name string, valueWhenNotSet time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return valueWhenNotSet
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("terminating because of invalid duration %s=%s: %s", name, value, err)
+	}
+	return d
+}
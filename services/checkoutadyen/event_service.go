@@ -12,12 +12,12 @@ import (
 func (s *service) Subscribe(c context.Context) error {
 	err := s.subscriber.CreateTopic(c, oauthevents.TopicName)
 	if err != nil {
-		return fmt.Errorf("error creating topic %s: %s", oauthevents.TopicName, err)
+		return fmt.Errorf("error creating topic %s: %w", oauthevents.TopicName, err)
 	}
 
 	err = s.subscriber.Subscribe(c, oauthevents.TopicName, myhttp.GuessHostnameWithScheme()+"/api/adyen/checkout/event")
 	if err != nil {
-		return fmt.Errorf("error subscribing to topic %s: %s", checkoutevents.TopicName, err)
+		return fmt.Errorf("error subscribing to topic %s: %w", checkoutevents.TopicName, err)
 	}
 
 	return nil
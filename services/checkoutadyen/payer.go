@@ -3,11 +3,14 @@ package checkoutadyen
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/adyen/adyen-go-api-library/v6/src/adyen"
 	"github.com/adyen/adyen-go-api-library/v6/src/checkout"
 	"github.com/adyen/adyen-go-api-library/v6/src/common"
+
+	"github.com/MarcGrol/shopbackend/services/checkoutapi"
 )
 
 //go:generate mockgen -source=payer.go -package checkoutadyen -destination payer_mock.go Payer
@@ -23,12 +26,17 @@ type adyenPayer struct {
 	client *adyen.APIClient
 }
 
-func NewPayer(environment string, apiKey string) Payer {
+func NewPayer(environment string) Payer {
+	return NewPayerWithHTTPClient(environment, nil)
+}
+
+// NewPayerWithHTTPClient allows the http-traffic to adyen to be intercepted, like in tests
+func NewPayerWithHTTPClient(environment string, httpClient *http.Client) Payer {
 	return &adyenPayer{
 		client: adyen.NewClient(&common.Config{
-			ApiKey:      apiKey,
 			Environment: common.Environment(strings.ToUpper(environment)),
 			Debug:       false,
+			HTTPClient:  httpClient,
 		}),
 	}
 }
@@ -50,7 +58,7 @@ func (p *adyenPayer) UseToken(accessToken string) {
 func (p *adyenPayer) CreatePayByLink(ctx context.Context, req checkout.CreatePaymentLinkRequest) (checkout.PaymentLinkResponse, error) {
 	resp, _, err := p.client.Checkout.PaymentLinks(&req, ctx)
 	if err != nil {
-		return checkout.PaymentLinkResponse{}, err
+		return checkout.PaymentLinkResponse{}, checkoutapi.ClassifyPaymentProviderError(err)
 	}
 	return resp, err
 }
@@ -58,7 +66,7 @@ func (p *adyenPayer) CreatePayByLink(ctx context.Context, req checkout.CreatePay
 func (p *adyenPayer) Sessions(ctx context.Context, req checkout.CreateCheckoutSessionRequest) (checkout.CreateCheckoutSessionResponse, error) {
 	resp, _, err := p.client.Checkout.Sessions(&req, ctx)
 	if err != nil {
-		return checkout.CreateCheckoutSessionResponse{}, err
+		return checkout.CreateCheckoutSessionResponse{}, checkoutapi.ClassifyPaymentProviderError(err)
 	}
 	return resp, err
 }
@@ -66,7 +74,7 @@ func (p *adyenPayer) Sessions(ctx context.Context, req checkout.CreateCheckoutSe
 func (p *adyenPayer) PaymentMethods(ctx context.Context, req checkout.PaymentMethodsRequest) (checkout.PaymentMethodsResponse, error) {
 	resp, _, err := p.client.Checkout.PaymentMethods(&req, ctx)
 	if err != nil {
-		return checkout.PaymentMethodsResponse{}, err
+		return checkout.PaymentMethodsResponse{}, checkoutapi.ClassifyPaymentProviderError(err)
 	}
 	return resp, err
 }
@@ -0,0 +1,56 @@
+package checkoutadyen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adyen/adyen-go-api-library/v6/src/checkout"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myhttpclient/myhttpclienttest"
+)
+
+func TestPayer(t *testing.T) {
+	c := context.TODO()
+
+	t.Run("Create session", func(t *testing.T) {
+		// setup
+		recorder := myhttpclienttest.NewRecorder(t, "create_session")
+		sut := NewPayerWithHTTPClient("test", recorder.HTTPClient())
+		sut.UseAPIKey(recorder.CredentialFromEnv("ADYEN_API_KEY"))
+
+		// when
+		session, err := sut.Sessions(c, checkout.CreateCheckoutSessionRequest{
+			Amount:          checkout.Amount{Currency: "EUR", Value: 12000},
+			CountryCode:     "NL",
+			MerchantAccount: "MarcGrolECOM",
+			Reference:       "123",
+			ReturnUrl:       "http://localhost:8080/checkout/123/status/success",
+		})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, "CS451F2AB1ED897A94", session.Id)
+		assert.Equal(t, "Ab02b4c0!BQABAgBfYI29", session.SessionData)
+	})
+
+	t.Run("Provider rejects request", func(t *testing.T) {
+		// setup
+		recorder := myhttpclienttest.NewRecorder(t, "create_session_rejected")
+		sut := NewPayerWithHTTPClient("test", recorder.HTTPClient())
+		sut.UseAPIKey(recorder.CredentialFromEnv("ADYEN_API_KEY"))
+
+		// when
+		_, err := sut.Sessions(c, checkout.CreateCheckoutSessionRequest{
+			Amount:          checkout.Amount{Currency: "XXX", Value: 12000},
+			MerchantAccount: "MarcGrolECOM",
+			Reference:       "123",
+			ReturnUrl:       "http://localhost:8080/checkout/123/status/success",
+		})
+
+		// then
+		assert.Error(t, err)
+		assert.Equal(t, 400, myerrors.GetHTTPStatus(err))
+	})
+}
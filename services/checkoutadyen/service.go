@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
 	"github.com/MarcGrol/shopbackend/lib/mylog"
 	"github.com/MarcGrol/shopbackend/lib/mypublisher"
@@ -22,7 +23,7 @@ type service struct {
 	environment     string
 	merchantAccount string
 	clientKey       string
-	apiKey          string
+	apiKey          myconfig.Secret
 	payer           Payer
 	checkoutStore   mystore.Store[checkoutapi.CheckoutContext]
 	vault           myvault.VaultReader[oauthvault.Token]
@@ -52,7 +53,7 @@ func newCommandService(cfg Config, payer Payer, checkoutStorer mystore.Store[che
 func (s *service) CreateTopics(c context.Context) error {
 	err := s.publisher.CreateTopic(c, checkoutevents.TopicName)
 	if err != nil {
-		return fmt.Errorf("error creating topic %s: %s", checkoutevents.TopicName, err)
+		return fmt.Errorf("error creating topic %s: %w", checkoutevents.TopicName, err)
 	}
 
 	return nil
@@ -71,10 +72,13 @@ func (s *service) payByLink(c context.Context, basketUID string, req checkout.Cr
 	now := s.nower.Now()
 
 	// Initiate a checkout session on the Adyen platform
-	s.setupAuthentication(c, basketUID)
+	err = s.setupAuthentication(c, basketUID)
+	if err != nil {
+		return "", myerrors.NewInternalError(err)
+	}
 	resp, err := s.payer.CreatePayByLink(c, req)
 	if err != nil {
-		return "", myerrors.NewInternalError(fmt.Errorf("error creating pay-by-link for checkout %s: %s", basketUID, err))
+		return "", fmt.Errorf("error creating pay-by-link for checkout %s: %w", basketUID, err)
 	}
 
 	err = s.checkoutStore.RunInTransaction(c, func(c context.Context) error {
@@ -90,7 +94,7 @@ func (s *service) payByLink(c context.Context, basketUID string, req checkout.Cr
 			PayByLink:         true,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error storing checkout: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error storing checkout: %w", err))
 		}
 
 		err = s.publisher.Publish(c, checkoutevents.TopicName, checkoutevents.PayByLinkCreated{
@@ -102,7 +106,7 @@ func (s *service) payByLink(c context.Context, basketUID string, req checkout.Cr
 			MerchantUID:   req.MerchantAccount,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
@@ -138,16 +142,19 @@ func (s *service) startCheckout(c context.Context, basketUID string, req checkou
 	now := s.nower.Now()
 
 	// Initiate a checkout session on the Adyen platform
-	s.setupAuthentication(c, basketUID)
+	err = s.setupAuthentication(c, basketUID)
+	if err != nil {
+		return nil, myerrors.NewInternalError(err)
+	}
 	checkoutSessionResp, err := s.payer.Sessions(c, req)
 	if err != nil {
-		return nil, myerrors.NewInternalError(fmt.Errorf("error creating payment session for checkout %s: %s", basketUID, err))
+		return nil, fmt.Errorf("error creating payment session for checkout %s: %w", basketUID, err)
 	}
 
 	// Ask the Adyen platform to return payment methods that are allowed for this merchant
 	paymentMethodsResp, err := s.payer.PaymentMethods(c, checkoutToPaymentMethodsRequest(req))
 	if err != nil {
-		return nil, myerrors.NewInternalError(fmt.Errorf("error fetching payment methods for checkout %s: %s", basketUID, err))
+		return nil, fmt.Errorf("error fetching payment methods for checkout %s: %w", basketUID, err)
 	}
 
 	err = s.checkoutStore.RunInTransaction(c, func(c context.Context) error {
@@ -162,7 +169,7 @@ func (s *service) startCheckout(c context.Context, basketUID string, req checkou
 			SessionData:       checkoutSessionResp.SessionData,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error storing checkout: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error storing checkout: %w", err))
 		}
 
 		err = s.publisher.Publish(c, checkoutevents.TopicName, checkoutevents.CheckoutStarted{
@@ -174,7 +181,7 @@ func (s *service) startCheckout(c context.Context, basketUID string, req checkou
 			MerchantUID:   req.MerchantAccount,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
@@ -232,19 +239,24 @@ func validateRequest(req checkout.CreateCheckoutSessionRequest) error {
 	return nil
 }
 
-func (s *service) setupAuthentication(c context.Context, basketUID string) {
+func (s *service) setupAuthentication(c context.Context, basketUID string) error {
 	tokenUID := oauthvault.CurrentToken + "_" + "adyen"
 	accessToken, exist, err := s.vault.Get(c, tokenUID)
 	if err != nil || !exist || accessToken.ProviderName != "adyen" ||
 		accessToken.SessionUID == "" ||
 		(accessToken.ExpiresIn != nil && accessToken.ExpiresIn.Before(s.nower.Now())) {
-		s.payer.UseAPIKey(s.apiKey)
+		apiKey, err := s.apiKey.Value(c)
+		if err != nil {
+			return fmt.Errorf("error getting api-key: %w", err)
+		}
+		s.payer.UseAPIKey(apiKey)
 		s.logger.Log(c, basketUID, mylog.SeverityInfo, "Using api-key")
-		return
+		return nil
 	}
 
 	s.payer.UseToken(accessToken.AccessToken)
 	s.logger.Log(c, basketUID, mylog.SeverityInfo, "Using access token")
+	return nil
 }
 
 // resumeCheckout is called when the shopper has finished the checkout process
@@ -261,7 +273,7 @@ func (s *service) resumeCheckout(c context.Context, basketUID string) (*Checkout
 			return myerrors.NewInternalError(err)
 		}
 		if !found {
-			return myerrors.NewNotFoundError(fmt.Errorf("checkout with uid %s not found", basketUID))
+			return myerrors.NewProblemf(checkoutapi.ProblemCheckoutNotFound, "checkout with uid %s not found", basketUID)
 		}
 
 		return nil
@@ -294,10 +306,10 @@ func (s *service) finalizeCheckout(c context.Context, basketUID string, status s
 
 		checkoutContext, found, err = s.checkoutStore.Get(c, basketUID)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error fetching checkout with uid %s: %s", basketUID, err))
+			return myerrors.NewInternalError(fmt.Errorf("error fetching checkout with uid %s: %w", basketUID, err))
 		}
 		if !found {
-			return myerrors.NewNotFoundError(fmt.Errorf("checkout with uid %s not found", basketUID))
+			return myerrors.NewProblemf(checkoutapi.ProblemCheckoutNotFound, "checkout with uid %s not found", basketUID)
 		}
 
 		checkoutContext.Status = status
@@ -316,7 +328,7 @@ func (s *service) finalizeCheckout(c context.Context, basketUID string, status s
 
 	adjustedReturnURL, err := addStatusQueryParam(checkoutContext.OriginalReturnURL, status)
 	if err != nil {
-		return "", myerrors.NewInternalError(fmt.Errorf("error adjusting url: %s", err))
+		return "", myerrors.NewInternalError(fmt.Errorf("error adjusting url: %w", err))
 	}
 
 	s.logger.Log(c, basketUID, mylog.SeverityInfo, "Redirect (done): Checkout completed for basket %s -> %s", basketUID, status)
@@ -327,7 +339,7 @@ func (s *service) finalizeCheckout(c context.Context, basketUID string, status s
 func addStatusQueryParam(orgURL string, status string) (string, error) {
 	u, err := url.Parse(orgURL)
 	if err != nil {
-		return "", myerrors.NewInternalError(fmt.Errorf("error parsing return ReturnURL %s: %s", orgURL, err))
+		return "", myerrors.NewInternalError(fmt.Errorf("error parsing return ReturnURL %s: %w", orgURL, err))
 	}
 	params := u.Query()
 	params.Set("status", status)
@@ -370,7 +382,7 @@ func (s *service) processNotificationItem(c context.Context, item NotificationIt
 			return myerrors.NewInternalError(err)
 		}
 		if !found {
-			return myerrors.NewNotFoundError(fmt.Errorf("checkout with uid %s not found", basketUID))
+			return myerrors.NewProblemf(checkoutapi.ProblemCheckoutNotFound, "checkout with uid %s not found", basketUID)
 		}
 
 		eventStatus := classifyEventStatus(item.NotificationRequestItem.EventCode, item.NotificationRequestItem.Success == "true")
@@ -394,7 +406,7 @@ func (s *service) processNotificationItem(c context.Context, item NotificationIt
 			CheckoutStatusDetails: eventStatusDetails,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
@@ -11,8 +11,10 @@ import (
 	"github.com/adyen/adyen-go-api-library/v6/src/checkout"
 	"github.com/gorilla/mux"
 
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
 	"github.com/MarcGrol/shopbackend/lib/mycontext"
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myevents"
 	"github.com/MarcGrol/shopbackend/lib/myhttp"
 	"github.com/MarcGrol/shopbackend/lib/mylog"
 	"github.com/MarcGrol/shopbackend/lib/mypublisher"
@@ -70,25 +72,32 @@ type Config struct {
 	Environment     string
 	MerchantAccount string
 	ClientKey       string
-	APIKey          string
+	APIKey          myconfig.Secret
 }
 
 type webService struct {
-	logger  mylog.Logger
-	service *service
+	logger     mylog.Logger
+	service    *service
+	consumer   *myevents.IdempotentConsumer
+	dispatcher *myevents.Dispatcher
 }
 
 // Use dependency injection to isolate the infrastructure and easy testing
-func NewWebService(cfg Config, payer Payer, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], nower mytime.Nower, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) (*webService, error) {
+func NewWebService(cfg Config, payer Payer, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], ledger mystore.Store[myevents.ProcessedEnvelope], nower mytime.Nower, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) (*webService, error) {
 	logger := mylog.New("checkoutadyen")
 	s, err := newCommandService(cfg, payer, checkoutStore, vault, nower, logger, subscriber, publisher)
 	if err != nil {
 		return nil, err
 	}
 
+	dispatcher := myevents.NewDispatcher("checkoutadyen")
+	oauthevents.RegisterEventHandlers(dispatcher, s)
+
 	return &webService{
-		logger:  logger,
-		service: s,
+		logger:     logger,
+		service:    s,
+		consumer:   myevents.NewIdempotentConsumer("checkoutadyen", ledger, nower),
+		dispatcher: dispatcher,
 	}, nil
 }
 
@@ -121,6 +130,11 @@ func (s *webService) RegisterEndpoints(c context.Context, router *mux.Router) er
 	return nil
 }
 
+// EventDispatcher exposes the consumer of events, so stored events can be replayed into it
+func (s *webService) EventDispatcher() *myevents.Dispatcher {
+	return s.dispatcher
+}
+
 func (s *webService) payByLinkPage() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		c := mycontext.ContextFromHTTPRequest(r)
@@ -129,13 +143,13 @@ func (s *webService) payByLinkPage() http.HandlerFunc {
 		// Convert request-body into a CreatePaymentLinkRequest
 		payByLinkRequest, basketUID, returnURL, err := parsePaybylinkRequest(r)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("error parsing request: %s", err)))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("error parsing request: %w", err)))
 			return
 		}
 
 		link, err := s.service.payByLink(c, basketUID, payByLinkRequest, returnURL)
 		if err != nil {
-			responseWriter.WriteError(c, w, 2, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 		http.Redirect(w, r, link, http.StatusFound)
@@ -151,13 +165,13 @@ func (s *webService) startCheckoutPage() http.HandlerFunc {
 		// Convert request-body into a CreateCheckoutSessionRequest
 		sessionRequest, basketUID, returnURL, err := parseRequest(r)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("error parsing request: %s", err)))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("error parsing request: %w", err)))
 			return
 		}
 
 		resp, err := s.service.startCheckout(c, basketUID, sessionRequest, returnURL)
 		if err != nil {
-			responseWriter.WriteError(c, w, 2, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -165,7 +179,7 @@ func (s *webService) startCheckoutPage() http.HandlerFunc {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		err = checkoutPageTemplate.Execute(w, resp)
 		if err != nil {
-			responseWriter.WriteError(c, w, 5, myerrors.NewInternalError(fmt.Errorf("error executing template: %s", err)))
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(fmt.Errorf("error executing template: %w", err)))
 			return
 		}
 	}
@@ -181,7 +195,7 @@ func (s *webService) resumeCheckoutPage() http.HandlerFunc {
 
 		resp, err := s.service.resumeCheckout(c, basketUID)
 		if err != nil {
-			responseWriter.WriteError(c, w, 10, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -189,7 +203,7 @@ func (s *webService) resumeCheckoutPage() http.HandlerFunc {
 		// Second time, less data is needed
 		err = checkoutPageTemplate.Execute(w, resp)
 		if err != nil {
-			responseWriter.WriteError(c, w, 12, myerrors.NewInternalError(fmt.Errorf("error executing template: %s", err)))
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(fmt.Errorf("error executing template: %w", err)))
 			return
 		}
 	}
@@ -206,7 +220,7 @@ func (s webService) finalizeCheckoutPage() http.HandlerFunc {
 
 		redirectURL, err := s.service.finalizeCheckout(c, basketUID, status)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -226,7 +240,7 @@ func (s *webService) webhookNotification() http.HandlerFunc {
 		event := WebhookNotification{}
 		err := json.NewDecoder(r.Body).Decode(&event)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, fmt.Errorf("error parsing webhook notification event:%s", err))
+			responseWriter.WriteError(c, w, fmt.Errorf("error parsing webhook notification event:%w", err))
 			return
 		}
 
@@ -249,9 +263,17 @@ func (s *webService) handleEventEnvelope() http.HandlerFunc {
 		c := mycontext.ContextFromHTTPRequest(r)
 		responseWriter := myhttp.NewWriter(s.logger)
 
-		err := oauthevents.DispatchEvent(c, r.Body, s.service)
+		envelope, err := myevents.ParseEventEnvelope(r.Body)
+		if err != nil {
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(err))
+			return
+		}
+
+		err = s.consumer.Consume(c, envelope, func(c context.Context) error {
+			return s.dispatcher.Dispatch(c, envelope)
+		})
 		if err != nil {
-			responseWriter.WriteError(c, w, 4, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -264,12 +286,12 @@ func (s *webService) handleEventEnvelope() http.HandlerFunc {
 func parseRequest(r *http.Request) (checkout.CreateCheckoutSessionRequest, string, string, error) {
 	basketUID := mux.Vars(r)["basketUID"]
 	if basketUID == "" {
-		return checkout.CreateCheckoutSessionRequest{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("missing basketUID:%s", basketUID))
+		return checkout.CreateCheckoutSessionRequest{}, "", "", myerrors.NewValidationError(myerrors.FieldError{Field: "basketUID", Message: "is mandatory"})
 	}
 
 	co, err := checkoutapi.NewFromRequest(r)
 	if err != nil {
-		return checkout.CreateCheckoutSessionRequest{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("error parsing form: %s", err))
+		return checkout.CreateCheckoutSessionRequest{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("error parsing form: %w", err))
 	}
 
 	return checkout.CreateCheckoutSessionRequest{
@@ -367,12 +389,12 @@ func parseRequest(r *http.Request) (checkout.CreateCheckoutSessionRequest, strin
 func parsePaybylinkRequest(r *http.Request) (checkout.CreatePaymentLinkRequest, string, string, error) {
 	basketUID := mux.Vars(r)["basketUID"]
 	if basketUID == "" {
-		return checkout.CreatePaymentLinkRequest{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("missing basketUID:%s", basketUID))
+		return checkout.CreatePaymentLinkRequest{}, "", "", myerrors.NewValidationError(myerrors.FieldError{Field: "basketUID", Message: "is mandatory"})
 	}
 
 	co, err := checkoutapi.NewFromRequest(r)
 	if err != nil {
-		return checkout.CreatePaymentLinkRequest{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("error parsing form: %s", err))
+		return checkout.CreatePaymentLinkRequest{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("error parsing form: %w", err))
 	}
 
 	return checkout.CreatePaymentLinkRequest{
@@ -14,6 +14,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
+	"github.com/MarcGrol/shopbackend/lib/myevents"
 	"github.com/MarcGrol/shopbackend/lib/mypublisher"
 	"github.com/MarcGrol/shopbackend/lib/mypubsub"
 	"github.com/MarcGrol/shopbackend/lib/mystore"
@@ -305,13 +307,14 @@ func setup(t *testing.T, ctrl *gomock.Controller) (context.Context, *mux.Router,
 	payer := NewMockPayer(ctrl)
 	subscriber := mypubsub.NewMockPubSub(ctrl)
 	publisher := mypublisher.NewMockPublisher(ctrl)
+	ledger, _, _ := mystore.New[myevents.ProcessedEnvelope](c)
 
 	sut, err := NewWebService(Config{
 		Environment:     "Test",
 		MerchantAccount: "MyMerchantAccount",
 		ClientKey:       "my_client_key",
-		APIKey:          "my_api_key",
-	}, payer, storer, vault, nower, subscriber, publisher)
+		APIKey:          myconfig.StaticSecret("my_api_key"),
+	}, payer, storer, vault, ledger, nower, subscriber, publisher)
 	assert.NoError(t, err)
 	router := mux.NewRouter()
 
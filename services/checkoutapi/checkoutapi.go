@@ -77,7 +77,7 @@ func NewFromValues(values url.Values) (Checkout, error) {
 
 	err := formcodec.NewDecoder().Decode(&checkout, values)
 	if err != nil {
-		return checkout, fmt.Errorf("error decoding form: %s", err)
+		return checkout, fmt.Errorf("error decoding form: %w", err)
 	}
 
 	return checkout, nil
@@ -86,7 +86,7 @@ func NewFromValues(values url.Values) (Checkout, error) {
 func (c Checkout) ToFormValues() (url.Values, error) {
 	values, err := formcodec.NewEncoder().Encode(c)
 	if err != nil {
-		return nil, fmt.Errorf("error decoding form: %s", err)
+		return nil, fmt.Errorf("error decoding form: %w", err)
 	}
 
 	return values, nil
@@ -0,0 +1,15 @@
+package checkoutapi
+
+import (
+	"net/http"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+)
+
+// Problem types shared by the checkout services of all payment providers, as listed on /api/problems
+var (
+	// ProblemCheckoutNotFound is returned when no checkout was started for a basket
+	ProblemCheckoutNotFound = myerrors.RegisterProblemType("checkout", myerrors.ProblemType{Type: "/problems/checkout/checkout-not-found", Title: "Checkout not found", Status: http.StatusNotFound})
+	// ProblemPaymentRejected is returned when the payment provider refuses to create or return a payment
+	ProblemPaymentRejected = myerrors.RegisterProblemType("checkout", myerrors.ProblemType{Type: "/problems/checkout/payment-rejected", Title: "Payment provider rejected the request", Status: http.StatusBadRequest})
+)
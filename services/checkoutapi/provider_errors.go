@@ -0,0 +1,53 @@
+package checkoutapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/VictorAvelar/mollie-api-go/v3/mollie"
+	"github.com/adyen/adyen-go-api-library/v6/src/common"
+	"github.com/stripe/stripe-go/v74"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+)
+
+const paymentRejectedDetail = "The payment provider did not accept the payment: check the basket and payment details"
+
+// ClassifyPaymentProviderError turns an error of the Adyen, Stripe or Mollie SDK into a myerrors kind,
+// based on the http-status the payment provider responded with. Errors without a response are upstream failures.
+// A rejected payment is reported to the client with a fixed detail: the response of the provider stays internal.
+func ClassifyPaymentProviderError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	httpStatus, found := paymentProviderStatus(err)
+	if !found {
+		return myerrors.NewUpstreamError(err)
+	}
+
+	if httpStatus == http.StatusBadRequest || httpStatus == http.StatusUnprocessableEntity {
+		return myerrors.NewProblem(ProblemPaymentRejected, err).WithDetail(paymentRejectedDetail)
+	}
+
+	return myerrors.ClassifyUpstream(httpStatus, err)
+}
+
+func paymentProviderStatus(err error) (int, bool) {
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) && stripeErr.HTTPStatusCode != 0 {
+		return stripeErr.HTTPStatusCode, true
+	}
+
+	var mollieErr *mollie.BaseError
+	if errors.As(err, &mollieErr) && mollieErr.Status != 0 {
+		return mollieErr.Status, true
+	}
+
+	var adyenErr common.APIError
+	if errors.As(err, &adyenErr) && adyenErr.Status != 0 {
+		return int(adyenErr.Status), true
+	}
+
+	return 0, false
+}
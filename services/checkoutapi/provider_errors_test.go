@@ -0,0 +1,52 @@
+package checkoutapi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/VictorAvelar/mollie-api-go/v3/mollie"
+	"github.com/adyen/adyen-go-api-library/v6/src/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v74"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+)
+
+func TestClassifyPaymentProviderError(t *testing.T) {
+	testCases := []struct {
+		name       string
+		in         error
+		kind       myerrors.Kind
+		httpStatus int
+	}{
+		{name: "Stripe rejects", in: &stripe.Error{HTTPStatusCode: 400, Msg: "invalid currency"}, kind: myerrors.ErrInvalidInput, httpStatus: 400},
+		{name: "Stripe rate limit", in: &stripe.Error{HTTPStatusCode: 429}, kind: myerrors.ErrRateLimited, httpStatus: 429},
+		{name: "Stripe invalid api-key", in: &stripe.Error{HTTPStatusCode: 401}, kind: myerrors.ErrUpstream, httpStatus: 502},
+		{name: "Mollie not found", in: &mollie.BaseError{Status: 404, Title: "Not Found"}, kind: myerrors.ErrNotFound, httpStatus: 404},
+		{name: "Mollie unprocessable", in: &mollie.BaseError{Status: 422, Title: "Unprocessable Entity"}, kind: myerrors.ErrInvalidInput, httpStatus: 400},
+		{name: "Adyen outage", in: common.APIError{Status: 500, Message: "internal"}, kind: myerrors.ErrUpstream, httpStatus: 502},
+		{name: "Network failure", in: fmt.Errorf("dial tcp: connection refused"), kind: myerrors.ErrUpstream, httpStatus: 502},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ClassifyPaymentProviderError(fmt.Errorf("error creating payment: %w", tc.in))
+
+			assert.True(t, errors.Is(err, tc.kind))
+			assert.Equal(t, tc.httpStatus, myerrors.GetHTTPStatus(err))
+			assert.ErrorContains(t, err, tc.in.Error())
+		})
+	}
+
+	t.Run("Rejection has specific problem type", func(t *testing.T) {
+		err := ClassifyPaymentProviderError(&stripe.Error{HTTPStatusCode: 400, Msg: "No such merchant account"})
+
+		problem := myerrors.ToProblem(err)
+		assert.Equal(t, ProblemPaymentRejected.Type, problem.Type)
+		assert.Equal(t, paymentRejectedDetail, problem.Detail)
+	})
+
+	t.Run("No error", func(t *testing.T) {
+		assert.NoError(t, ClassifyPaymentProviderError(nil))
+	})
+}
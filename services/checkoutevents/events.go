@@ -2,11 +2,7 @@ package checkoutevents
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 
-	"github.com/MarcGrol/shopbackend/lib/myerrors"
 	"github.com/MarcGrol/shopbackend/lib/myevents"
 )
 
@@ -17,6 +13,12 @@ const (
 	checkoutPaybylinkName = TopicName + ".paybylinkCreated"
 )
 
+func init() {
+	myevents.RegisterEventType[CheckoutStarted](TopicName)
+	myevents.RegisterEventType[CheckoutCompleted](TopicName)
+	myevents.RegisterEventType[PayByLinkCreated](TopicName)
+}
+
 type CheckoutEventService interface {
 	Subscribe(c context.Context) error
 	OnCheckoutStarted(c context.Context, topic string, event CheckoutStarted) error
@@ -24,43 +26,10 @@ type CheckoutEventService interface {
 	OnPayByLinkCreated(c context.Context, topic string, event PayByLinkCreated) error
 }
 
-func DispatchEvent(c context.Context, reader io.Reader, service CheckoutEventService) error {
-	envelope, err := myevents.ParseEventEnvelope(reader)
-	if err != nil {
-		return myerrors.NewInvalidInputError(err)
-	}
-
-	switch envelope.EventTypeName {
-	case checkoutStartedName:
-		{
-			event := CheckoutStarted{}
-			err := json.Unmarshal([]byte(envelope.EventPayload), &event)
-			if err != nil {
-				return myerrors.NewInvalidInputError(err)
-			}
-			return service.OnCheckoutStarted(c, envelope.Topic, event)
-		}
-	case checkoutCompletedName:
-		{
-			event := CheckoutCompleted{}
-			err := json.Unmarshal([]byte(envelope.EventPayload), &event)
-			if err != nil {
-				return myerrors.NewInvalidInputError(err)
-			}
-			return service.OnCheckoutCompleted(c, envelope.Topic, event)
-		}
-	case checkoutPaybylinkName:
-		{
-			event := PayByLinkCreated{}
-			err := json.Unmarshal([]byte(envelope.EventPayload), &event)
-			if err != nil {
-				return myerrors.NewInvalidInputError(err)
-			}
-			return service.OnPayByLinkCreated(c, envelope.Topic, event)
-		}
-	default:
-		return myerrors.NewNotImplementedError(fmt.Errorf("%s", envelope.EventTypeName))
-	}
+func RegisterEventHandlers(d *myevents.Dispatcher, service CheckoutEventService) {
+	myevents.Register(d, service.OnCheckoutStarted)
+	myevents.Register(d, service.OnCheckoutCompleted)
+	myevents.Register(d, service.OnPayByLinkCreated)
 }
 
 type CheckoutStarted struct {
@@ -80,6 +49,10 @@ func (e CheckoutStarted) GetAggregateName() string {
 	return e.CheckoutUID
 }
 
+func (e CheckoutStarted) GetEventVersion() int {
+	return 1
+}
+
 type CheckoutStatus string
 
 const (
@@ -110,6 +83,10 @@ func (e CheckoutCompleted) GetAggregateName() string {
 	return e.CheckoutUID
 }
 
+func (e CheckoutCompleted) GetEventVersion() int {
+	return 1
+}
+
 type PayByLinkCreated struct {
 	ProviderName  string
 	CheckoutUID   string
@@ -126,3 +103,7 @@ func (e PayByLinkCreated) GetEventTypeName() string {
 func (e PayByLinkCreated) GetAggregateName() string {
 	return e.CheckoutUID
 }
+
+func (e PayByLinkCreated) GetEventVersion() int {
+	return 1
+}
@@ -3,8 +3,10 @@ package checkoutmollie
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/services/checkoutapi"
 	"github.com/VictorAvelar/mollie-api-go/v3/mollie"
 )
 
@@ -21,11 +23,16 @@ type molliePayer struct {
 }
 
 func NewPayer() (Payer, error) {
+	return NewPayerWithHTTPClient(nil)
+}
+
+// NewPayerWithHTTPClient allows the http-traffic to mollie to be intercepted, like in tests
+func NewPayerWithHTTPClient(httpClient *http.Client) (Payer, error) {
 	config := mollie.NewAPITestingConfig(true)
 
-	client, err := mollie.NewClient(nil, config)
+	client, err := mollie.NewClient(httpClient, config)
 	if err != nil {
-		return nil, myerrors.NewInternalError(fmt.Errorf("error creating mollie client: %s", err))
+		return nil, myerrors.NewInternalError(fmt.Errorf("error creating mollie client: %w", err))
 	}
 
 	return &molliePayer{
@@ -44,7 +51,7 @@ func (p *molliePayer) UseToken(accessToken string) {
 func (p *molliePayer) CreatePayment(ctx context.Context, request mollie.Payment) (mollie.Payment, error) {
 	_, payment, err := p.client.Payments.Create(ctx, request, nil)
 	if err != nil {
-		return mollie.Payment{}, myerrors.NewInvalidInputError(fmt.Errorf("error creating mollie payment: %s", err))
+		return mollie.Payment{}, checkoutapi.ClassifyPaymentProviderError(fmt.Errorf("error creating mollie payment: %w", err))
 	}
 
 	return *payment, nil
@@ -53,7 +60,7 @@ func (p *molliePayer) CreatePayment(ctx context.Context, request mollie.Payment)
 func (p *molliePayer) GetPaymentOnID(ctx context.Context, id string) (mollie.Payment, error) {
 	_, payment, err := p.client.Payments.Get(ctx, id, &mollie.PaymentOptions{})
 	if err != nil {
-		return mollie.Payment{}, myerrors.NewInvalidInputError(fmt.Errorf("error getting mollie payment: %s", err))
+		return mollie.Payment{}, checkoutapi.ClassifyPaymentProviderError(fmt.Errorf("error getting mollie payment: %w", err))
 	}
 
 	return *payment, nil
@@ -0,0 +1,44 @@
+package checkoutmollie
+
+import (
+	"context"
+	"testing"
+
+	"github.com/VictorAvelar/mollie-api-go/v3/mollie"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MarcGrol/shopbackend/lib/myhttpclient/myhttpclienttest"
+)
+
+func TestPayer(t *testing.T) {
+	c := context.TODO()
+
+	t.Run("Create and get payment", func(t *testing.T) {
+		// setup
+		recorder := myhttpclienttest.NewRecorder(t, "create_and_get_payment")
+		sut, err := NewPayerWithHTTPClient(recorder.HTTPClient())
+		assert.NoError(t, err)
+		sut.UseAPIKey(recorder.CredentialFromEnv("MOLLIE_API_KEY"))
+
+		// when
+		payment, err := sut.CreatePayment(c, mollie.Payment{
+			Amount:      &mollie.Amount{Currency: "EUR", Value: "120.00"},
+			Description: "Goods ordered in basket 123",
+			RedirectURL: "http://localhost:8080/mollie/checkout/123/status/success",
+			WebhookURL:  "http://localhost:8080/mollie/checkout/webhook/event/123",
+		})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, "tr_5B8cwPMGnU", payment.ID)
+		assert.Equal(t, "open", payment.Status)
+		assert.Equal(t, "https://www.mollie.com/checkout/select-method/5B8cwPMGnU", payment.Links.Checkout.Href)
+
+		// when
+		payment, err = sut.GetPaymentOnID(c, "tr_5B8cwPMGnU")
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, "paid", payment.Status)
+	})
+}
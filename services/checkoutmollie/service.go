@@ -8,6 +8,7 @@ import (
 
 	"github.com/VictorAvelar/mollie-api-go/v3/mollie"
 
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
 	"github.com/MarcGrol/shopbackend/lib/mylog"
 	"github.com/MarcGrol/shopbackend/lib/mypublisher"
@@ -20,7 +21,7 @@ import (
 )
 
 type service struct {
-	apiKey        string
+	apiKey        myconfig.Secret
 	payer         Payer
 	logger        mylog.Logger
 	nower         mytime.Nower
@@ -30,7 +31,7 @@ type service struct {
 }
 
 // Use dependency injection to isolate the infrastructure and easy testing
-func newService(apiKey string, payer Payer, logger mylog.Logger, nower mytime.Nower, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], publisher mypublisher.Publisher) (*service, error) {
+func newService(apiKey myconfig.Secret, payer Payer, logger mylog.Logger, nower mytime.Nower, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], publisher mypublisher.Publisher) (*service, error) {
 	return &service{
 		apiKey:        apiKey,
 		payer:         payer,
@@ -48,10 +49,14 @@ func (s *service) startCheckout(c context.Context, basketUID string, returnURL s
 	s.logger.Log(c, basketUID, mylog.SeverityInfo, "Start checkout for basket %s", basketUID)
 
 	// Iniitialize payment to the mollie platform
-	request.ProfileID, request.TestMode = s.setupAuthentication(c, basketUID)
+	profileID, testMode, err := s.setupAuthentication(c, basketUID)
+	if err != nil {
+		return "", myerrors.NewInternalError(err)
+	}
+	request.ProfileID, request.TestMode = profileID, testMode
 	paymentResp, err := s.payer.CreatePayment(c, request)
 	if err != nil {
-		return "", myerrors.NewInvalidInputError(err)
+		return "", err
 	}
 
 	err = s.checkoutStore.RunInTransaction(c, func(c context.Context) error {
@@ -65,7 +70,7 @@ func (s *service) startCheckout(c context.Context, basketUID string, returnURL s
 			OriginalReturnURL: returnURL,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error storing checkout: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error storing checkout: %w", err))
 		}
 
 		err = s.publisher.Publish(c, checkoutevents.TopicName, checkoutevents.CheckoutStarted{
@@ -79,7 +84,7 @@ func (s *service) startCheckout(c context.Context, basketUID string, returnURL s
 			ShopperUID: request.CustomerReference,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
@@ -93,20 +98,32 @@ func (s *service) startCheckout(c context.Context, basketUID string, returnURL s
 	return paymentResp.Links.Checkout.Href, nil
 }
 
-func (s *service) setupAuthentication(c context.Context, basketUID string) (string, bool) {
+func (s *service) setupAuthentication(c context.Context, basketUID string) (string, bool, error) {
 	tokenUID := oauthvault.CurrentToken + "_" + ("mollie")
 	accessToken, exist, err := s.vault.Get(c, tokenUID)
 	if err != nil || !exist || accessToken.ProviderName != "mollie" || accessToken.SessionUID == "" {
 		s.logger.Log(c, basketUID, mylog.SeverityInfo, "Using api key")
-		s.payer.UseAPIKey(s.apiKey)
-		return "", false
+		err := s.useAPIKey(c)
+		if err != nil {
+			return "", false, err
+		}
+		return "", false, nil
 	}
 
 	s.logger.Log(c, basketUID, mylog.SeverityInfo, "Using access token")
 	s.payer.UseToken(accessToken.AccessToken)
 	profileID := "pfl_Ns8niaVZaw" // TODO make env var out of this
 
-	return profileID, false
+	return profileID, false, nil
+}
+
+func (s *service) useAPIKey(c context.Context) error {
+	apiKey, err := s.apiKey.Value(c)
+	if err != nil {
+		return fmt.Errorf("error getting api-key: %w", err)
+	}
+	s.payer.UseAPIKey(apiKey)
+	return nil
 }
 
 func (s *service) finalizeCheckout(c context.Context, basketUID string, status string) (string, error) {
@@ -120,10 +137,10 @@ func (s *service) finalizeCheckout(c context.Context, basketUID string, status s
 
 		checkoutContext, found, err := s.checkoutStore.Get(c, basketUID)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error fetching checkout with uid %s: %s", basketUID, err))
+			return myerrors.NewInternalError(fmt.Errorf("error fetching checkout with uid %s: %w", basketUID, err))
 		}
 		if !found {
-			return myerrors.NewNotFoundError(fmt.Errorf("checkout with uid %s not found", basketUID))
+			return myerrors.NewProblemf(checkoutapi.ProblemCheckoutNotFound, "checkout with uid %s not found", basketUID)
 		}
 
 		checkoutContext.Status = status
@@ -136,7 +153,7 @@ func (s *service) finalizeCheckout(c context.Context, basketUID string, status s
 
 		adjustedReturnURL, err = addStatusQueryParam(checkoutContext.OriginalReturnURL, status)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error adjusting url: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error adjusting url: %w", err))
 		}
 
 		return nil
@@ -153,7 +170,7 @@ func (s *service) finalizeCheckout(c context.Context, basketUID string, status s
 func addStatusQueryParam(orgURL string, status string) (string, error) {
 	u, err := url.Parse(orgURL)
 	if err != nil {
-		return "", myerrors.NewInternalError(fmt.Errorf("error parsing return ReturnURL %s: %s", orgURL, err))
+		return "", myerrors.NewInternalError(fmt.Errorf("error parsing return ReturnURL %s: %w", orgURL, err))
 	}
 	params := u.Query()
 	params.Set("status", status)
@@ -164,10 +181,13 @@ func addStatusQueryParam(orgURL string, status string) (string, error) {
 func (s *service) webhookNotification(c context.Context, username, password string, basketUID string, id string) error {
 	s.logger.Log(c, basketUID, mylog.SeverityInfo, "Webhook: status update event '%s'", id)
 
-	s.payer.UseAPIKey(s.apiKey)
+	err := s.useAPIKey(c)
+	if err != nil {
+		return myerrors.NewInternalError(err)
+	}
 	payment, err := s.payer.GetPaymentOnID(c, id)
 	if err != nil {
-		return myerrors.NewInternalError(fmt.Errorf("error getting payment %s on id: %s", id, err))
+		return fmt.Errorf("error getting payment %s on id: %w", id, err)
 	}
 
 	s.logger.Log(c, basketUID, mylog.SeverityInfo, "Webhook: status update on payment '%+v'", payment)
@@ -182,7 +202,7 @@ func (s *service) webhookNotification(c context.Context, username, password stri
 			return myerrors.NewInternalError(err)
 		}
 		if !found {
-			return myerrors.NewNotFoundError(fmt.Errorf("checkout with uid %s not found", basketUID))
+			return myerrors.NewProblemf(checkoutapi.ProblemCheckoutNotFound, "checkout with uid %s not found", basketUID)
 		}
 
 		checkoutContext.PaymentMethod = string(payment.Method)
@@ -205,7 +225,7 @@ func (s *service) webhookNotification(c context.Context, username, password stri
 		}
 		err = s.publisher.Publish(c, checkoutevents.TopicName, event)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
@@ -8,6 +8,7 @@ import (
 	"github.com/VictorAvelar/mollie-api-go/v3/mollie"
 	"github.com/gorilla/mux"
 
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
 	"github.com/MarcGrol/shopbackend/lib/mycontext"
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
 	"github.com/MarcGrol/shopbackend/lib/myhttp"
@@ -27,7 +28,7 @@ type webService struct {
 }
 
 // Use dependency injection to isolate the infrastructure and easy testing
-func NewWebService(apiKey string, payer Payer, nower mytime.Nower, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], publisher mypublisher.Publisher) (*webService, error) {
+func NewWebService(apiKey myconfig.Secret, payer Payer, nower mytime.Nower, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], publisher mypublisher.Publisher) (*webService, error) {
 	logger := mylog.New("checkoutmollie")
 	s, err := newService(apiKey, payer, logger, nower, checkoutStore, vault, publisher)
 	if err != nil {
@@ -59,13 +60,13 @@ func (s *webService) startCheckoutPage() http.HandlerFunc {
 		// Convert request-body into a CreateCheckoutSessionRequest
 		params, basketUID, returnURL, err := s.parseRequest(r)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("error parsing request: %s", err)))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("error parsing request: %w", err)))
 			return
 		}
 
 		redirectURL, err := s.service.startCheckout(c, basketUID, returnURL, params)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("error starting checkout: %s", err)))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("error starting checkout: %w", err)))
 			return
 		}
 
@@ -83,7 +84,7 @@ func (s *webService) checkoutCompletedPage() http.HandlerFunc {
 
 		redirectURL, err := s.service.finalizeCheckout(c, basketUID, status)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("error starting checkout: %s", err)))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("error starting checkout: %w", err)))
 			return
 		}
 
@@ -102,19 +103,19 @@ func (s *webService) webhookNotification() http.HandlerFunc {
 
 		err := r.ParseForm()
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(err))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(err))
 			return
 		}
 
 		id := r.FormValue("id")
 		if id == "" {
-			responseWriter.WriteError(c, w, 2, myerrors.NewInvalidInputErrorf("missing id"))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputErrorf("missing id"))
 			return
 		}
 
 		err = s.service.webhookNotification(c, username, password, basketUID, id)
 		if err != nil {
-			responseWriter.WriteError(c, w, 4, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -125,12 +126,12 @@ func (s *webService) webhookNotification() http.HandlerFunc {
 func (s *webService) parseRequest(r *http.Request) (mollie.Payment, string, string, error) {
 	basketUID := mux.Vars(r)["basketUID"]
 	if basketUID == "" {
-		return mollie.Payment{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("missing basketUID:%s", basketUID))
+		return mollie.Payment{}, "", "", myerrors.NewValidationError(myerrors.FieldError{Field: "basketUID", Message: "is mandatory"})
 	}
 
 	co, err := checkoutapi.NewFromRequest(r)
 	if err != nil {
-		return mollie.Payment{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("error parsing form: %s", err))
+		return mollie.Payment{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("error parsing form: %w", err))
 	}
 
 	paymentRequest := mollie.Payment{
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/services/checkoutapi"
 	"github.com/stripe/stripe-go/v74"
 	"github.com/stripe/stripe-go/v74/checkout/session"
 )
@@ -16,24 +16,36 @@ type Payer interface {
 	CreateCheckoutSession(ctx context.Context, params stripe.CheckoutSessionParams) (stripe.CheckoutSession, error)
 }
 
-type stripePayer struct{}
+type stripePayer struct {
+	backend stripe.Backend
+	key     string
+}
 
 func NewPayer() Payer {
-	return &stripePayer{}
+	return NewPayerWithBackend(stripe.GetBackend(stripe.APIBackend))
+}
+
+// NewPayerWithBackend allows the http-traffic to stripe to be intercepted, like in tests
+func NewPayerWithBackend(backend stripe.Backend) Payer {
+	return &stripePayer{
+		backend: backend,
+	}
 }
 
 func (p *stripePayer) UseAPIKey(apiKey string) {
-	stripe.Key = apiKey
+	p.key = apiKey
 }
 
 func (p *stripePayer) UseToken(accessToken string) {
-	stripe.Key = accessToken
+	p.key = accessToken
 }
 
 func (p *stripePayer) CreateCheckoutSession(ctx context.Context, params stripe.CheckoutSessionParams) (stripe.CheckoutSession, error) {
-	session, err := session.New(&params)
+	params.Context = ctx
+	client := session.Client{B: p.backend, Key: p.key}
+	session, err := client.New(&params)
 	if err != nil {
-		return stripe.CheckoutSession{}, myerrors.NewInvalidInputError(fmt.Errorf("error creating stripe payment: %s", err))
+		return stripe.CheckoutSession{}, checkoutapi.ClassifyPaymentProviderError(fmt.Errorf("error creating stripe payment: %w", err))
 	}
 
 	return *session, nil
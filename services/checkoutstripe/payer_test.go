@@ -0,0 +1,51 @@
+package checkoutstripe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v74"
+
+	"github.com/MarcGrol/shopbackend/lib/myhttpclient/myhttpclienttest"
+)
+
+func TestPayer(t *testing.T) {
+	c := context.TODO()
+
+	t.Run("Create checkout session", func(t *testing.T) {
+		// setup
+		recorder := myhttpclienttest.NewRecorder(t, "create_checkout_session")
+		sut := NewPayerWithBackend(stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+			HTTPClient:        recorder.HTTPClient(),
+			MaxNetworkRetries: stripe.Int64(0),
+			LeveledLogger:     &stripe.LeveledLogger{Level: stripe.LevelError},
+		}))
+		sut.UseAPIKey(recorder.CredentialFromEnv("STRIPE_API_KEY"))
+
+		// when
+		session, err := sut.CreateCheckoutSession(c, stripe.CheckoutSessionParams{
+			ClientReferenceID: stripe.String("123"),
+			SuccessURL:        stripe.String("http://localhost:8080/stripe/checkout/123/status/success"),
+			CancelURL:         stripe.String("http://localhost:8080/stripe/checkout/123/status/cancelled"),
+			Mode:              stripe.String(string(stripe.CheckoutSessionModePayment)),
+			LineItems: []*stripe.CheckoutSessionLineItemParams{
+				{
+					PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+						Currency: stripe.String("eur"),
+						ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+							Name: stripe.String("Tennis racket"),
+						},
+						UnitAmount: stripe.Int64(12000),
+					},
+					Quantity: stripe.Int64(1),
+				},
+			},
+		})
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, "cs_test_a1b2c3", session.ID)
+		assert.Equal(t, "https://checkout.stripe.com/c/pay/cs_test_a1b2c3", session.URL)
+	})
+}
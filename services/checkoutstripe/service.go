@@ -8,6 +8,7 @@ import (
 
 	"github.com/stripe/stripe-go/v74"
 
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
 	"github.com/MarcGrol/shopbackend/lib/mylog"
 	"github.com/MarcGrol/shopbackend/lib/mypublisher"
@@ -20,7 +21,7 @@ import (
 )
 
 type service struct {
-	apiKey        string
+	apiKey        myconfig.Secret
 	payer         Payer
 	logger        mylog.Logger
 	nower         mytime.Nower
@@ -30,7 +31,7 @@ type service struct {
 }
 
 // Use dependency injection to isolate the infrastructure and easy testing
-func newService(apiKey string, payer Payer, logger mylog.Logger, nower mytime.Nower, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], publisher mypublisher.Publisher) (*service, error) {
+func newService(apiKey myconfig.Secret, payer Payer, logger mylog.Logger, nower mytime.Nower, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], publisher mypublisher.Publisher) (*service, error) {
 	return &service{
 		apiKey:        apiKey,
 		payer:         payer,
@@ -48,10 +49,13 @@ func (s *service) startCheckout(c context.Context, basketUID string, returnURL s
 	s.logger.Log(c, basketUID, mylog.SeverityInfo, "Start checkout for basket %s", basketUID)
 
 	// Iniitialize payment to the stripe platform
-	s.setupAuthentication(c, basketUID)
+	err := s.setupAuthentication(c, basketUID)
+	if err != nil {
+		return "", myerrors.NewInternalError(err)
+	}
 	session, err := s.payer.CreateCheckoutSession(c, params)
 	if err != nil {
-		return "", myerrors.NewInvalidInputError(err)
+		return "", err
 	}
 
 	err = s.checkoutStore.RunInTransaction(c, func(c context.Context) error {
@@ -65,7 +69,7 @@ func (s *service) startCheckout(c context.Context, basketUID string, returnURL s
 			OriginalReturnURL: returnURL,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error storing checkout: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error storing checkout: %w", err))
 		}
 
 		err = s.publisher.Publish(c, checkoutevents.TopicName, checkoutevents.CheckoutStarted{
@@ -76,7 +80,7 @@ func (s *service) startCheckout(c context.Context, basketUID string, returnURL s
 			ShopperUID:    *params.ClientReferenceID,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
@@ -90,16 +94,21 @@ func (s *service) startCheckout(c context.Context, basketUID string, returnURL s
 	return session.URL, nil
 }
 
-func (s *service) setupAuthentication(c context.Context, basketUID string) {
+func (s *service) setupAuthentication(c context.Context, basketUID string) error {
 	tokenUID := oauthvault.CurrentToken + "_" + ("stripe")
 	accessToken, exist, err := s.vault.Get(c, tokenUID)
 	if err != nil || !exist || accessToken.ProviderName != "stripe" || accessToken.SessionUID == "" {
 		s.logger.Log(c, basketUID, mylog.SeverityInfo, "Using api key")
-		s.payer.UseAPIKey(s.apiKey)
+		apiKey, err := s.apiKey.Value(c)
+		if err != nil {
+			return fmt.Errorf("error getting api-key: %w", err)
+		}
+		s.payer.UseAPIKey(apiKey)
 	} else {
 		s.logger.Log(c, basketUID, mylog.SeverityInfo, "Using access token")
 		s.payer.UseToken(accessToken.AccessToken)
 	}
+	return nil
 }
 
 func (s *service) finalizeCheckout(c context.Context, basketUID string, status string) (string, error) {
@@ -113,10 +122,10 @@ func (s *service) finalizeCheckout(c context.Context, basketUID string, status s
 
 		checkoutContext, found, err := s.checkoutStore.Get(c, basketUID)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error fetching checkout with uid %s: %s", basketUID, err))
+			return myerrors.NewInternalError(fmt.Errorf("error fetching checkout with uid %s: %w", basketUID, err))
 		}
 		if !found {
-			return myerrors.NewNotFoundError(fmt.Errorf("checkout with uid %s not found", basketUID))
+			return myerrors.NewProblemf(checkoutapi.ProblemCheckoutNotFound, "checkout with uid %s not found", basketUID)
 		}
 
 		checkoutContext.Status = status
@@ -129,7 +138,7 @@ func (s *service) finalizeCheckout(c context.Context, basketUID string, status s
 
 		adjustedReturnURL, err = addStatusQueryParam(checkoutContext.OriginalReturnURL, status)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error adjusting url: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error adjusting url: %w", err))
 		}
 
 		return nil
@@ -146,7 +155,7 @@ func (s *service) finalizeCheckout(c context.Context, basketUID string, status s
 func addStatusQueryParam(orgURL string, status string) (string, error) {
 	u, err := url.Parse(orgURL)
 	if err != nil {
-		return "", myerrors.NewInternalError(fmt.Errorf("error parsing return ReturnURL %s: %s", orgURL, err))
+		return "", myerrors.NewInternalError(fmt.Errorf("error parsing return ReturnURL %s: %w", orgURL, err))
 	}
 	params := u.Query()
 	params.Set("status", status)
@@ -172,7 +181,7 @@ func (s *service) webhookNotification(c context.Context, username, password stri
 			var paymentIntent stripe.PaymentIntent
 			err := json.Unmarshal(event.Data.Raw, &paymentIntent)
 			if err != nil {
-				return myerrors.NewInvalidInputError(fmt.Errorf("error parsing webhook %v JSON: %v", event.Type, err))
+				return myerrors.NewInvalidInputError(fmt.Errorf("error parsing webhook %v JSON: %w", event.Type, err))
 			}
 			return s.handlePaymentIntentEvent(c, event.Type, paymentIntent)
 		}
@@ -182,7 +191,7 @@ func (s *service) webhookNotification(c context.Context, username, password stri
 			var paymentMethod stripe.PaymentMethod
 			err := json.Unmarshal(event.Data.Raw, &paymentMethod)
 			if err != nil {
-				return myerrors.NewInvalidInputError(fmt.Errorf("error parsing webhook %v JSON: %v", event.Type, err))
+				return myerrors.NewInvalidInputError(fmt.Errorf("error parsing webhook %v JSON: %w", event.Type, err))
 			}
 			return s.handlePaymentMethodEvent(c, event.Type, paymentMethod)
 		}
@@ -226,7 +235,7 @@ func (s *service) handlePaymentIntentEvent(c context.Context, eventType string,
 			return myerrors.NewInternalError(err)
 		}
 		if !found {
-			return myerrors.NewNotFoundError(fmt.Errorf("checkout with uid %s not found", uid))
+			return myerrors.NewProblemf(checkoutapi.ProblemCheckoutNotFound, "checkout with uid %s not found", uid)
 		}
 
 		s.logger.Log(c, uid, mylog.SeverityInfo, "Webhook: Payment event %s is related to basket %s", eventType, checkoutContext.BasketUID)
@@ -256,7 +265,7 @@ func (s *service) handlePaymentIntentEvent(c context.Context, eventType string,
 			CheckoutStatusDetails: eventType,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
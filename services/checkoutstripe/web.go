@@ -9,6 +9,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/stripe/stripe-go/v74"
 
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
 	"github.com/MarcGrol/shopbackend/lib/mycontext"
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
 	"github.com/MarcGrol/shopbackend/lib/myhttp"
@@ -27,7 +28,7 @@ type webService struct {
 }
 
 // Use dependency injection to isolate the infrastructure and easy testing
-func NewWebService(apiKey string, payer Payer, nower mytime.Nower, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], publisher mypublisher.Publisher) (*webService, error) {
+func NewWebService(apiKey myconfig.Secret, payer Payer, nower mytime.Nower, checkoutStore mystore.Store[checkoutapi.CheckoutContext], vault myvault.VaultReader[oauthvault.Token], publisher mypublisher.Publisher) (*webService, error) {
 	logger := mylog.New("checkoutstripe")
 	s, err := newService(apiKey, payer, logger, nower, checkoutStore, vault, publisher)
 	if err != nil {
@@ -58,13 +59,13 @@ func (s *webService) startCheckoutPage() http.HandlerFunc {
 		// Convert request-body into a CreateCheckoutSessionRequest
 		params, basketUID, returnURL, err := parseRequest(r)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("error parsing request: %s", err)))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("error parsing request: %w", err)))
 			return
 		}
 
 		redirectURL, err := s.service.startCheckout(c, basketUID, returnURL, params)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("error starting checkout: %s", err)))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("error starting checkout: %w", err)))
 			return
 		}
 
@@ -82,7 +83,7 @@ func (s *webService) checkoutCompletedPage() http.HandlerFunc {
 
 		redirectURL, err := s.service.finalizeCheckout(c, basketUID, status)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("error starting checkout: %s", err)))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("error starting checkout: %w", err)))
 			return
 		}
 
@@ -101,13 +102,13 @@ func (s *webService) webhookNotification() http.HandlerFunc {
 		event := stripe.Event{}
 		err := json.NewDecoder(r.Body).Decode(&event)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
 		err = s.service.webhookNotification(c, username, password, event)
 		if err != nil {
-			responseWriter.WriteError(c, w, 2, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -118,12 +119,12 @@ func (s *webService) webhookNotification() http.HandlerFunc {
 func parseRequest(r *http.Request) (stripe.CheckoutSessionParams, string, string, error) {
 	basketUID := mux.Vars(r)["basketUID"]
 	if basketUID == "" {
-		return stripe.CheckoutSessionParams{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("missing basketUID:%s", basketUID))
+		return stripe.CheckoutSessionParams{}, "", "", myerrors.NewValidationError(myerrors.FieldError{Field: "basketUID", Message: "is mandatory"})
 	}
 
 	co, err := checkoutapi.NewFromRequest(r)
 	if err != nil {
-		return stripe.CheckoutSessionParams{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("error parsing form: %s", err))
+		return stripe.CheckoutSessionParams{}, "", "", myerrors.NewInvalidInputError(fmt.Errorf("error parsing form: %w", err))
 	}
 
 	return stripe.CheckoutSessionParams{
@@ -14,6 +14,7 @@ import (
 	"github.com/stripe/stripe-go/v74"
 	"go.uber.org/mock/gomock"
 
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
 	"github.com/MarcGrol/shopbackend/lib/mypublisher"
 	"github.com/MarcGrol/shopbackend/lib/mypubsub"
 	"github.com/MarcGrol/shopbackend/lib/mystore"
@@ -250,7 +251,7 @@ func setup(t *testing.T, ctrl *gomock.Controller) (context.Context, *mux.Router,
 	subscriber := mypubsub.NewMockPubSub(ctrl)
 	publisher := mypublisher.NewMockPublisher(ctrl)
 
-	sut, err := NewWebService("my_api_key", payer, nower, storer, vault, publisher)
+	sut, err := NewWebService(myconfig.StaticSecret("my_api_key"), payer, nower, storer, vault, publisher)
 	assert.NoError(t, err)
 	router := mux.NewRouter()
 
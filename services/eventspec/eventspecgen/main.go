@@ -0,0 +1,21 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/MarcGrol/shopbackend/services/eventspec"
+)
+
+// Regenerates the checked-in AsyncAPI document and json-schemas of all registered events
+func main() {
+	dir := flag.String("dir", "spec", "directory to write the spec-files to")
+	flag.Parse()
+
+	err := eventspec.WriteFiles(*dir)
+	if err != nil {
+		log.Fatalf("Error generating event spec: %s", err)
+	}
+
+	log.Printf("Generated event spec in %s", *dir)
+}
@@ -0,0 +1,238 @@
+package eventspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+	// Import all packages that publish events, so their event-types get registered
+	_ "github.com/MarcGrol/shopbackend/services/checkoutevents"
+	_ "github.com/MarcGrol/shopbackend/services/oauth/oauthevents"
+	_ "github.com/MarcGrol/shopbackend/services/shop/shopevents"
+	_ "github.com/MarcGrol/shopbackend/services/termsconditions"
+	_ "github.com/MarcGrol/shopbackend/services/warmup"
+)
+
+//go:generate go run ./eventspecgen -dir spec
+
+const (
+	asyncAPIFilename = "asyncapi.json"
+	schemasDirname   = "schemas"
+	jsonSchemaDraft  = "https://json-schema.org/draft/2020-12/schema"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Files returns the content of all spec-files, keyed by their path relative to the spec-directory
+func Files() (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	asyncAPI, err := AsyncAPI()
+	if err != nil {
+		return nil, err
+	}
+	files[asyncAPIFilename] = asyncAPI
+
+	for _, eventType := range myevents.RegisteredEventTypes() {
+		schema := schemaOf(eventType.PayloadType)
+		schema["$schema"] = jsonSchemaDraft
+		schema["$id"] = eventType.EventTypeName
+		schema["title"] = eventType.EventTypeName
+		schema["x-event-version"] = eventType.EventVersion
+
+		jsonBytes, err := marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling schema of %s: %w", eventType.EventTypeName, err)
+		}
+		files[filepath.Join(schemasDirname, eventType.EventTypeName+".json")] = jsonBytes
+	}
+
+	return files, nil
+}
+
+// WriteFiles (re)generates all spec-files in the given directory
+func WriteFiles(dir string) error {
+	files, err := Files()
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(filepath.Join(dir, schemasDirname))
+	if err != nil {
+		return fmt.Errorf("error removing old schemas: %w", err)
+	}
+
+	for filename, content := range files {
+		path := filepath.Join(dir, filename)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return fmt.Errorf("error creating directory for %s: %w", path, err)
+		}
+
+		err = os.WriteFile(path, content, 0644)
+		if err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// AsyncAPI returns an AsyncAPI document that describes all topics and the events published on them
+func AsyncAPI() ([]byte, error) {
+	channels := map[string]any{}
+	messages := map[string]any{}
+	schemas := map[string]any{}
+	messagesPerTopic := map[string][]any{}
+
+	for _, eventType := range myevents.RegisteredEventTypes() {
+		schemas[eventType.EventTypeName] = schemaOf(eventType.PayloadType)
+		messages[eventType.EventTypeName] = map[string]any{
+			"name":            eventType.EventTypeName,
+			"title":           eventType.PayloadType.Name(),
+			"summary":         fmt.Sprintf("Version %d of %s", eventType.EventVersion, eventType.EventTypeName),
+			"description":     "The payload is json-encoded in the EventPayload field of an EventEnvelope.",
+			"contentType":     "application/json",
+			"headers":         map[string]any{"$ref": "#/components/schemas/attributes"},
+			"payload":         map[string]any{"$ref": "#/components/schemas/" + eventType.EventTypeName},
+			"x-event-version": eventType.EventVersion,
+		}
+		messagesPerTopic[eventType.Topic] = append(messagesPerTopic[eventType.Topic],
+			map[string]any{"$ref": "#/components/messages/" + eventType.EventTypeName})
+	}
+
+	for topic, topicMessages := range messagesPerTopic {
+		channels[topic] = map[string]any{
+			"subscribe": map[string]any{
+				"operationId": "on_" + topic,
+				"message": map[string]any{
+					"oneOf": topicMessages,
+				},
+			},
+		}
+	}
+
+	schemas["attributes"] = attributesSchema()
+
+	return marshal(map[string]any{
+		"asyncapi": "2.6.0",
+		"info": map[string]any{
+			"title":       "shopbackend events",
+			"version":     "1.0.0",
+			"description": "Events published by the shopbackend",
+		},
+		"defaultContentType": "application/json",
+		"channels":           channels,
+		"components": map[string]any{
+			"messages": messages,
+			"schemas":  schemas,
+		},
+	})
+}
+
+func attributesSchema() map[string]any {
+	properties := map[string]any{}
+	for _, name := range []string{
+		myevents.AttributeUID,
+		myevents.AttributeTopic,
+		myevents.AttributeAggregateUID,
+		myevents.AttributeEventTypeName,
+		myevents.AttributeEventVersion,
+		myevents.AttributeCreatedAt,
+		myevents.AttributeTraceContext,
+	} {
+		properties[name] = map[string]any{"type": "string"}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+func schemaOf(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		return schemaOf(t.Elem())
+	}
+
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		return structSchemaOf(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+func structSchemaOf(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		omitEmpty := false
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, option := range parts[1:] {
+				if option == "omitempty" {
+					omitEmpty = true
+				}
+			}
+		}
+
+		properties[name] = schemaOf(field.Type)
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func marshal(document map[string]any) ([]byte, error) {
+	jsonBytes, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(jsonBytes, '\n'), nil
+}
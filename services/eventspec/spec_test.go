@@ -0,0 +1,36 @@
+package eventspec
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSpecIsUpToDate fails when an event struct has changed without regenerating the spec: run "go generate ./services/eventspec"
+func TestSpecIsUpToDate(t *testing.T) {
+	expected, err := Files()
+	assert.NoError(t, err)
+
+	for filename, content := range expected {
+		actual, err := os.ReadFile(filepath.Join("spec", filename))
+		assert.NoError(t, err, "missing spec-file %s", filename)
+		assert.Equal(t, string(content), string(actual), "spec-file %s is outdated", filename)
+	}
+
+	err = filepath.WalkDir("spec", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		filename, err := filepath.Rel("spec", path)
+		if err != nil {
+			return err
+		}
+		_, found := expected[filename]
+		assert.True(t, found, "spec-file %s no longer corresponds to an event", filename)
+		return nil
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,46 @@
+package eventspec
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/MarcGrol/shopbackend/lib/mycontext"
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myhttp"
+	"github.com/MarcGrol/shopbackend/lib/mylog"
+)
+
+type webService struct {
+	logger mylog.Logger
+}
+
+func NewService() *webService {
+	return &webService{
+		logger: mylog.New("eventspec"),
+	}
+}
+
+func (s *webService) RegisterEndpoints(c context.Context, router *mux.Router) error {
+	router.HandleFunc("/api/events/spec", s.getSpec()).Methods("GET")
+
+	return nil
+}
+
+func (s *webService) getSpec() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(s.logger)
+
+		spec, err := AsyncAPI()
+		if err != nil {
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(spec)
+	}
+}
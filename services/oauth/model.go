@@ -3,6 +3,7 @@ package oauth
 import (
 	"time"
 
+	"github.com/MarcGrol/shopbackend/lib/myvault"
 	"github.com/MarcGrol/shopbackend/services/oauth/oauthclient"
 )
 
@@ -29,3 +30,21 @@ type OAuthStatus struct {
 	ValidUntil   *time.Time
 	Status       bool
 }
+
+type AuditTrailPageInfo struct {
+	ProviderName string
+	Records      []myvault.AuditRecord
+}
+
+type TokenVersionsPageInfo struct {
+	ProviderName string
+	Versions     []TokenVersion
+}
+
+// TokenVersion describes a previous token without exposing its secrets
+type TokenVersion struct {
+	Version   int
+	CreatedAt time.Time
+	Current   bool
+	Status    OAuthStatus
+}
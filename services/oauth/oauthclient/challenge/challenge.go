@@ -30,7 +30,7 @@ func randomBytesInHex(count int) (string, error) {
 
 	_, err := io.ReadFull(rand.Reader, buf)
 	if err != nil {
-		return "", fmt.Errorf("could not generate random %d bytes: %v", count, err)
+		return "", fmt.Errorf("could not generate random %d bytes: %w", count, err)
 	}
 
 	return hex.EncodeToString(buf), nil
@@ -41,7 +41,7 @@ func Create(value string) (string, string, error) {
 
 	_, err := io.WriteString(sha2, value)
 	if err != nil {
-		return "", "", fmt.Errorf("could not write challenge: %v", err)
+		return "", "", fmt.Errorf("could not write challenge: %w", err)
 	}
 
 	codeChallenge := base64.RawURLEncoding.EncodeToString(sha2.Sum(nil))
@@ -8,6 +8,7 @@ import (
 	"net/url"
 
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myhttpclient"
 	"github.com/MarcGrol/shopbackend/services/oauth/oauthclient/challenge"
 	"github.com/MarcGrol/shopbackend/services/oauth/providers"
 )
@@ -56,12 +57,22 @@ type OauthClient interface {
 type oauthClient struct {
 	providers      *providers.OAuthProviders
 	randomStringer challenge.RandomStringer
+	httpClient     *myhttpclient.Client
 }
 
 func NewOAuthClient(providers *providers.OAuthProviders, randomStringer challenge.RandomStringer) *oauthClient {
+	return NewOAuthClientWithTransport(providers, randomStringer, nil)
+}
+
+// NewOAuthClientWithTransport allows the http-traffic to the providers to be intercepted, like in tests
+func NewOAuthClientWithTransport(providers *providers.OAuthProviders, randomStringer challenge.RandomStringer, transport http.RoundTripper) *oauthClient {
+	config := myhttpclient.DefaultConfig()
+	config.Transport = transport
+
 	return &oauthClient{
 		providers:      providers,
 		randomStringer: randomStringer,
+		httpClient:     myhttpclient.NewClient(config),
 	}
 }
 
@@ -79,7 +90,7 @@ func (oc oauthClient) ComposeAuthURL(c context.Context, req ComposeAuthURLReques
 
 	randomString, err := oc.randomStringer.Create()
 	if err != nil {
-		return "", "", myerrors.NewInternalError(fmt.Errorf("error creating seed: %s", err))
+		return "", "", myerrors.NewInternalError(fmt.Errorf("error creating seed: %w", err))
 	}
 
 	method, challenge, err := challenge.Create(randomString)
@@ -117,7 +128,10 @@ func (oc oauthClient) GetAccessToken(c context.Context, req GetTokenRequest) (Ge
 		return GetTokenResponse{}, fmt.Errorf("provider with name '%s' not known", req.ProviderName)
 	}
 
-	clientID, secret := provider.GetCredentials(provider)
+	clientID, secret, err := provider.Credentials(c)
+	if err != nil {
+		return GetTokenResponse{}, fmt.Errorf("error getting credentials of provider '%s': %w", req.ProviderName, err)
+	}
 
 	getTokenURL := provider.TokenEndpoint.GetFullURL()
 
@@ -126,12 +140,11 @@ func (oc oauthClient) GetAccessToken(c context.Context, req GetTokenRequest) (Ge
 		"redirect_uri":  {req.RedirectURI},
 		"code":          {req.Code},
 		"code_verifier": {req.CodeVerifier},
-	}.Encode()
+	}
 
-	httpClient := newHTTPClient(clientID, secret)
-	httpRespCode, respBody, err := httpClient.Send(c, http.MethodPost, getTokenURL, []byte(requestBody))
+	httpRespCode, respBody, err := oc.postForm(c, getTokenURL, clientID, secret, requestBody)
 	if err != nil {
-		return GetTokenResponse{}, fmt.Errorf("error getting token: %s", err)
+		return GetTokenResponse{}, fmt.Errorf("error getting token: %w", err)
 	}
 
 	if httpRespCode != 200 {
@@ -141,7 +154,7 @@ func (oc oauthClient) GetAccessToken(c context.Context, req GetTokenRequest) (Ge
 	resp := GetTokenResponse{}
 	err = json.Unmarshal(respBody, &resp)
 	if err != nil {
-		return GetTokenResponse{}, fmt.Errorf("error parsing response: %s", err)
+		return GetTokenResponse{}, fmt.Errorf("error parsing response: %w", err)
 	}
 
 	return resp, nil
@@ -153,19 +166,21 @@ func (oc oauthClient) RefreshAccessToken(c context.Context, req RefreshTokenRequ
 		return GetTokenResponse{}, fmt.Errorf("provider with name '%s' not known", req.ProviderName)
 	}
 
-	clientID, secret := provider.GetCredentials(provider)
+	clientID, secret, err := provider.Credentials(c)
+	if err != nil {
+		return GetTokenResponse{}, fmt.Errorf("error getting credentials of provider '%s': %w", req.ProviderName, err)
+	}
 
 	refreshTokenURL := provider.TokenEndpoint.GetFullURL()
 
 	requestBody := url.Values{
 		"grant_type":    {"refresh_token"},
 		"refresh_token": {req.RefreshToken},
-	}.Encode()
+	}
 
-	httpClient := newHTTPClient(clientID, secret)
-	httpRespCode, respBody, err := httpClient.Send(c, http.MethodPost, refreshTokenURL, []byte(requestBody))
+	httpRespCode, respBody, err := oc.postForm(c, refreshTokenURL, clientID, secret, requestBody)
 	if err != nil {
-		return GetTokenResponse{}, fmt.Errorf("error getting refresh-token: %s", err)
+		return GetTokenResponse{}, fmt.Errorf("error getting refresh-token: %w", err)
 	}
 
 	if httpRespCode != 200 {
@@ -175,7 +190,7 @@ func (oc oauthClient) RefreshAccessToken(c context.Context, req RefreshTokenRequ
 	resp := GetTokenResponse{}
 	err = json.Unmarshal(respBody, &resp)
 	if err != nil {
-		return GetTokenResponse{}, fmt.Errorf("error parsing response: %s", err)
+		return GetTokenResponse{}, fmt.Errorf("error parsing response: %w", err)
 	}
 
 	return resp, nil
@@ -10,6 +10,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	gomock "go.uber.org/mock/gomock"
 
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
+	"github.com/MarcGrol/shopbackend/lib/myhttpclient/myhttpclienttest"
 	"github.com/MarcGrol/shopbackend/services/oauth/oauthclient/challenge"
 	"github.com/MarcGrol/shopbackend/services/oauth/providers"
 )
@@ -51,7 +53,7 @@ func TestOAuthClient(t *testing.T) {
 		randomStringer.EXPECT().Create().Return("05796efe18af079dc654bb88c68f5cd8b8a5d378e7cec8e9856258f95d3b0b5a", nil)
 
 		providers := providers.NewProviders()
-		providers.Set("adyen", "123", "456", "https://ca-test.adyen.com", "https://oauth-test.adyen.com")
+		providers.Set("adyen", "123", myconfig.StaticSecret("456"), "https://ca-test.adyen.com", "https://oauth-test.adyen.com")
 
 		oauthClient := NewOAuthClient(providers, randomStringer)
 		url, randomString, err := oauthClient.ComposeAuthURL(context.TODO(), ComposeAuthURLRequest{
@@ -92,7 +94,7 @@ func TestOAuthClient(t *testing.T) {
 		defer cleanup()
 
 		providers := providers.NewProviders()
-		providers.Set("adyen", "123", "456", ts.URL, ts.URL)
+		providers.Set("adyen", "123", myconfig.StaticSecret("456"), ts.URL, ts.URL)
 
 		client := NewOAuthClient(providers, nil)
 		_, err := client.GetAccessToken(context.TODO(), GetTokenRequest{
@@ -143,7 +145,7 @@ func TestOAuthClient(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
-		providers.Set("adyen", "123", "456", ts.URL, ts.URL)
+		providers.Set("adyen", "123", myconfig.StaticSecret("456"), ts.URL, ts.URL)
 
 		client := NewOAuthClient(providers, nil)
 		resp, err := client.GetAccessToken(context.TODO(), GetTokenRequest{
@@ -194,7 +196,7 @@ func TestOAuthClient(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
-		providers.Set("adyen", "123", "456", ts.URL, ts.URL)
+		providers.Set("adyen", "123", myconfig.StaticSecret("456"), ts.URL, ts.URL)
 
 		client := NewOAuthClient(providers, nil)
 		resp, err := client.RefreshAccessToken(context.TODO(), RefreshTokenRequest{
@@ -223,3 +225,38 @@ func unserializeGetTokenRequest(t *testing.T, r *http.Request) GetTokenRequest {
 		CodeVerifier: r.Form.Get("code_verifier"),
 	}
 }
+
+func TestOAuthClientWithRecordedTraffic(t *testing.T) {
+	c := context.TODO()
+
+	// setup
+	recorder := myhttpclienttest.NewRecorder(t, "adyen_get_and_refresh_token")
+	providers := providers.NewProviders()
+	providers.Set("adyen", recorder.CredentialFromEnv("ADYEN_OAUTH_CLIENT_ID"), myconfig.StaticSecret(recorder.CredentialFromEnv("ADYEN_OAUTH_CLIENT_SECRET")),
+		"https://ca-test.adyen.com", "https://oauth-test.adyen.com")
+	sut := NewOAuthClientWithTransport(providers, nil, recorder)
+
+	// when
+	resp, err := sut.GetAccessToken(c, GetTokenRequest{
+		ProviderName: "adyen",
+		RedirectURI:  "http://localhost:8080/oauth/done",
+		Code:         "mycode",
+		CodeVerifier: "exampleHash",
+	})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "bearer", resp.TokenType)
+	assert.Equal(t, 3600, resp.ExpiresIn)
+	assert.Equal(t, adyenExampleScopes, resp.Scope)
+
+	// when
+	resp, err = sut.RefreshAccessToken(c, RefreshTokenRequest{
+		ProviderName: "adyen",
+		RefreshToken: resp.RefreshToken,
+	})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "bearer", resp.TokenType)
+}
@@ -2,11 +2,7 @@ package oauthevents
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 
-	"github.com/MarcGrol/shopbackend/lib/myerrors"
 	"github.com/MarcGrol/shopbackend/lib/myevents"
 )
 
@@ -19,6 +15,14 @@ const (
 	oauthTokenCancelCompletedName   = TopicName + ".tokenCancel.completed"
 )
 
+func init() {
+	myevents.RegisterEventType[OAuthSessionSetupStarted](TopicName)
+	myevents.RegisterEventType[OAuthSessionSetupCompleted](TopicName)
+	myevents.RegisterEventType[OAuthTokenCreationCompleted](TopicName)
+	myevents.RegisterEventType[OAuthTokenRefreshCompleted](TopicName)
+	myevents.RegisterEventType[OAuthTokenCancelCompleted](TopicName)
+}
+
 type OAuthEventService interface {
 	Subscribe(c context.Context) error
 	OnOAuthSessionSetupStarted(c context.Context, topic string, event OAuthSessionSetupStarted) error
@@ -28,61 +32,12 @@ type OAuthEventService interface {
 	OnOAuthTokenCancelCompleted(c context.Context, topic string, event OAuthTokenCancelCompleted) error
 }
 
-func DispatchEvent(c context.Context, reader io.Reader, service OAuthEventService) error {
-	envelope, err := myevents.ParseEventEnvelope(reader)
-	if err != nil {
-		return myerrors.NewInvalidInputError(err)
-	}
-
-	switch envelope.EventTypeName {
-	case oauthSessionSetupStartedName:
-		{
-			event := OAuthSessionSetupStarted{}
-			err := json.Unmarshal([]byte(envelope.EventPayload), &event)
-			if err != nil {
-				return myerrors.NewInvalidInputError(err)
-			}
-			return service.OnOAuthSessionSetupStarted(c, envelope.Topic, event)
-		}
-	case oauthSessionSetupCompletedName:
-		{
-			event := OAuthSessionSetupCompleted{}
-			err := json.Unmarshal([]byte(envelope.EventPayload), &event)
-			if err != nil {
-				return myerrors.NewInvalidInputError(err)
-			}
-			return service.OnOAuthSessionSetupCompleted(c, envelope.Topic, event)
-		}
-	case oauthTokenCreationCompletedName:
-		{
-			event := OAuthTokenCreationCompleted{}
-			err := json.Unmarshal([]byte(envelope.EventPayload), &event)
-			if err != nil {
-				return myerrors.NewInvalidInputError(err)
-			}
-			return service.OnOAuthTokenCreationCompleted(c, envelope.Topic, event)
-		}
-	case oauthTokenRefreshCompletedName:
-		{
-			event := OAuthTokenRefreshCompleted{}
-			err := json.Unmarshal([]byte(envelope.EventPayload), &event)
-			if err != nil {
-				return myerrors.NewInvalidInputError(err)
-			}
-			return service.OnOAuthTokenRefreshCompleted(c, envelope.Topic, event)
-		}
-	case oauthTokenCancelCompletedName:
-		{
-			event := OAuthTokenCancelCompleted{}
-			err := json.Unmarshal([]byte(envelope.EventPayload), &event)
-			if err != nil {
-				return myerrors.NewInvalidInputError(err)
-			}
-			return service.OnOAuthTokenCancelCompleted(c, envelope.Topic, event)
-		}
-	default:
-		return myerrors.NewNotImplementedError(fmt.Errorf("%s", envelope.EventTypeName))
-	}
+func RegisterEventHandlers(d *myevents.Dispatcher, service OAuthEventService) {
+	myevents.Register(d, service.OnOAuthSessionSetupStarted)
+	myevents.Register(d, service.OnOAuthSessionSetupCompleted)
+	myevents.Register(d, service.OnOAuthTokenCreationCompleted)
+	myevents.Register(d, service.OnOAuthTokenRefreshCompleted)
+	myevents.Register(d, service.OnOAuthTokenCancelCompleted)
 }
 
 type OAuthSessionSetupStarted struct {
@@ -100,6 +55,10 @@ func (e OAuthSessionSetupStarted) GetAggregateName() string {
 	return e.ClientID
 }
 
+func (e OAuthSessionSetupStarted) GetEventVersion() int {
+	return 1
+}
+
 type OAuthSessionSetupCompleted struct {
 	ProviderName string
 	ClientID     string
@@ -114,6 +73,10 @@ func (e OAuthSessionSetupCompleted) GetAggregateName() string {
 	return e.ClientID
 }
 
+func (e OAuthSessionSetupCompleted) GetEventVersion() int {
+	return 1
+}
+
 type OAuthTokenCreationCompleted struct {
 	ProviderName string
 	ClientID     string
@@ -128,6 +91,10 @@ func (e OAuthTokenCreationCompleted) GetAggregateName() string {
 	return e.ClientID
 }
 
+func (e OAuthTokenCreationCompleted) GetEventVersion() int {
+	return 1
+}
+
 type OAuthTokenRefreshCompleted struct {
 	ProviderName string
 	UID          string
@@ -143,6 +110,10 @@ func (e OAuthTokenRefreshCompleted) GetAggregateName() string {
 	return e.ClientID
 }
 
+func (e OAuthTokenRefreshCompleted) GetEventVersion() int {
+	return 1
+}
+
 type OAuthTokenCancelCompleted struct {
 	ProviderName string
 	UID          string
@@ -157,3 +128,7 @@ func (e OAuthTokenCancelCompleted) GetEventTypeName() string {
 func (e OAuthTokenCancelCompleted) GetAggregateName() string {
 	return e.ClientID
 }
+
+func (e OAuthTokenCancelCompleted) GetEventVersion() int {
+	return 1
+}
@@ -0,0 +1,19 @@
+package oauth
+
+import (
+	"net/http"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+)
+
+// Problem types of the oauth service, as listed on /api/problems
+var (
+	// ProblemUnknownProvider is returned for a provider that is not configured
+	ProblemUnknownProvider = myerrors.RegisterProblemType("oauth", myerrors.ProblemType{Type: "/problems/oauth/unknown-provider", Title: "Unknown oauth provider", Status: http.StatusNotFound})
+	// ProblemSessionNotFound is returned when the provider redirects back with an unknown or expired state
+	ProblemSessionNotFound = myerrors.RegisterProblemType("oauth", myerrors.ProblemType{Type: "/problems/oauth/session-not-found", Title: "OAuth session not found", Status: http.StatusNotFound})
+	// ProblemAuthorizationDenied is returned when the provider did not grant access
+	ProblemAuthorizationDenied = myerrors.RegisterProblemType("oauth", myerrors.ProblemType{Type: "/problems/oauth/authorization-denied", Title: "Authorization denied by provider", Status: http.StatusBadRequest})
+	// ProblemTokenVersionNotFound is returned when restoring a token version that is not retained
+	ProblemTokenVersionNotFound = myerrors.RegisterProblemType("oauth", myerrors.ProblemType{Type: "/problems/oauth/token-version-not-found", Title: "Token version not found", Status: http.StatusNotFound})
+)
@@ -1,6 +1,11 @@
 package providers
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+
+	"github.com/MarcGrol/shopbackend/lib/myconfig"
+)
 
 type EndPoint struct {
 	Hostname string
@@ -13,16 +18,35 @@ func (ep EndPoint) GetFullURL() string {
 
 type OauthParty struct {
 	ClientID       string
-	Secret         string
+	Secret         myconfig.Secret `datastore:"-" json:"-"`
 	AuthEndpoint   EndPoint
 	TokenEndpoint  EndPoint
 	DefaultScopes  string
-	GetCredentials func(p OauthParty) (string, string) `datastore:"-"`
+	GetCredentials func(clientID string, secret string) (string, string) `datastore:"-" json:"-"`
+}
+
+// Credentials resolves the current client-secret and returns the basic-auth credentials for the token endpoint
+func (p OauthParty) Credentials(c context.Context) (string, string, error) {
+	if p.Secret == nil {
+		return "", "", fmt.Errorf("no client-secret configured")
+	}
+
+	secret, err := p.Secret.Value(c)
+	if err != nil {
+		return "", "", fmt.Errorf("error getting client-secret: %w", err)
+	}
+
+	if p.GetCredentials == nil {
+		return p.ClientID, secret, nil
+	}
+
+	username, password := p.GetCredentials(p.ClientID, secret)
+	return username, password, nil
 }
 
 type OAuthProvider interface {
 	All() map[string]OauthParty
-	Set(providerName string, clientID string, secret string, authHostname string, tokenHostname string)
+	Set(providerName string, clientID string, secret myconfig.Secret, authHostname string, tokenHostname string)
 	Get(providerName string) (OauthParty, error)
 }
 
@@ -35,7 +59,7 @@ func NewProviders() *OAuthProviders {
 		providers: map[string]OauthParty{
 			"adyen": {
 				ClientID: "adyen_client_id",
-				Secret:   "adyen_secret",
+				Secret:   myconfig.StaticSecret("adyen_secret"),
 				AuthEndpoint: EndPoint{
 					Hostname: "https://ca-test.adyen.com",
 					Path:     "/ca/ca/oauth/connect.shtml",
@@ -45,13 +69,13 @@ func NewProviders() *OAuthProviders {
 					Path:     "/v1/token",
 				},
 				DefaultScopes: "psp.onlinepayment:write psp.onlinepayment.tokenization:write psp.paybylink:write psp.accountsettings:write psp.webhook:write",
-				GetCredentials: func(p OauthParty) (string, string) {
-					return p.ClientID, p.Secret
+				GetCredentials: func(clientID string, secret string) (string, string) {
+					return clientID, secret
 				},
 			},
 			"stripe": {
 				ClientID: "stripe_client_id",
-				Secret:   "stripe_secret",
+				Secret:   myconfig.StaticSecret("stripe_secret"),
 				AuthEndpoint: EndPoint{
 					Hostname: "https://connect.stripe.com",
 					Path:     "/oauth/authorize",
@@ -61,13 +85,13 @@ func NewProviders() *OAuthProviders {
 					Path:     "/oauth/token",
 				},
 				DefaultScopes: "read_write",
-				GetCredentials: func(p OauthParty) (string, string) {
-					return p.Secret, "" // secret is used as basic auth username with empty password
+				GetCredentials: func(clientID string, secret string) (string, string) {
+					return secret, "" // secret is used as basic auth username with empty password
 				},
 			},
 			"mollie": {
 				ClientID: "mollie_client_id",
-				Secret:   "mollie_secret",
+				Secret:   myconfig.StaticSecret("mollie_secret"),
 				AuthEndpoint: EndPoint{
 					Hostname: "https://www.mollie.com",
 					Path:     "/oauth2/authorize",
@@ -77,8 +101,8 @@ func NewProviders() *OAuthProviders {
 					Path:     "/oauth2/tokens",
 				},
 				DefaultScopes: "organizations.read profiles.read payments.read payments.write",
-				GetCredentials: func(p OauthParty) (string, string) {
-					return p.ClientID, p.Secret
+				GetCredentials: func(clientID string, secret string) (string, string) {
+					return clientID, secret
 				},
 			},
 		},
@@ -89,7 +113,7 @@ func (op *OAuthProviders) All() map[string]OauthParty {
 	return op.providers
 }
 
-func (op *OAuthProviders) Set(providerName string, clientID string, secret string, authHostname string, tokenHostname string) {
+func (op *OAuthProviders) Set(providerName string, clientID string, secret myconfig.Secret, authHostname string, tokenHostname string) {
 	provider, found := op.providers[providerName]
 	if !found {
 		provider = OauthParty{}
@@ -99,7 +123,7 @@ func (op *OAuthProviders) Set(providerName string, clientID string, secret strin
 		provider.ClientID = clientID
 	}
 
-	if secret != "" {
+	if secret != nil {
 		provider.Secret = secret
 	}
 
@@ -3,6 +3,7 @@ package oauth
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
@@ -28,9 +29,10 @@ type service struct {
 	oauthClient  oauthclient.OauthClient
 	publisher    mypublisher.Publisher
 	providers    providers.OAuthProvider
+	auditTrail   myvault.AuditTrail
 }
 
-func newService(partyVault myvault.VaultReadWriter[providers.OauthParty], sessionStore mystore.Store[OAuthSessionSetup], tokenVault myvault.VaultReadWriter[oauthvault.Token], nower mytime.Nower, uuider myuuid.UUIDer, oauthClient oauthclient.OauthClient, pub mypublisher.Publisher, providers providers.OAuthProvider) *service {
+func newService(partyVault myvault.VaultReadWriter[providers.OauthParty], sessionStore mystore.Store[OAuthSessionSetup], tokenVault myvault.VaultReadWriter[oauthvault.Token], nower mytime.Nower, uuider myuuid.UUIDer, oauthClient oauthclient.OauthClient, pub mypublisher.Publisher, providers providers.OAuthProvider, auditTrail myvault.AuditTrail) *service {
 	return &service{
 		partyVault:   partyVault,
 		sessionStore: sessionStore,
@@ -41,13 +43,14 @@ func newService(partyVault myvault.VaultReadWriter[providers.OauthParty], sessio
 		logger:       mylog.New("oauth"),
 		publisher:    pub,
 		providers:    providers,
+		auditTrail:   auditTrail,
 	}
 }
 
 func (s *service) CreateTopics(c context.Context) error {
 	err := s.publisher.CreateTopic(c, oauthevents.TopicName)
 	if err != nil {
-		return fmt.Errorf("error creating topic %s: %s", oauthevents.TopicName, err)
+		return fmt.Errorf("error creating topic %s: %w", oauthevents.TopicName, err)
 	}
 
 	return nil
@@ -71,6 +74,85 @@ func (s *service) getOauthStatus(c context.Context) (map[string]OAuthStatus, err
 	return statuses, nil
 }
 
+// getAuditTrail returns who accessed the token and the client-credentials of a provider, most recent first
+func (s *service) getAuditTrail(c context.Context, providerName string) (AuditTrailPageInfo, error) {
+	_, err := s.providers.Get(providerName)
+	if err != nil {
+		return AuditTrailPageInfo{}, myerrors.NewProblem(ProblemUnknownProvider, err)
+	}
+
+	records := []myvault.AuditRecord{}
+	for _, vaultUID := range []string{CreateTokenUID(providerName), providerName} {
+		vaultRecords, err := s.auditTrail.ListByVaultUID(c, vaultUID)
+		if err != nil {
+			return AuditTrailPageInfo{}, myerrors.NewInternalError(err)
+		}
+		records = append(records, vaultRecords...)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.After(records[j].Timestamp)
+	})
+
+	return AuditTrailPageInfo{
+		ProviderName: providerName,
+		Records:      records,
+	}, nil
+}
+
+// getTokenVersions returns the retained versions of the token of a provider, most recent first
+func (s *service) getTokenVersions(c context.Context, providerName string) (TokenVersionsPageInfo, error) {
+	_, err := s.providers.Get(providerName)
+	if err != nil {
+		return TokenVersionsPageInfo{}, myerrors.NewProblem(ProblemUnknownProvider, err)
+	}
+
+	versions, err := s.tokenVault.ListVersions(c, CreateTokenUID(providerName))
+	if err != nil {
+		return TokenVersionsPageInfo{}, myerrors.NewInternalError(err)
+	}
+
+	pageInfo := TokenVersionsPageInfo{
+		ProviderName: providerName,
+		Versions:     []TokenVersion{},
+	}
+	for i, version := range versions {
+		pageInfo.Versions = append(pageInfo.Versions, TokenVersion{
+			Version:   version.Version,
+			CreatedAt: version.CreatedAt,
+			Current:   i == 0,
+			Status:    tokenToStatus(version.Value, true),
+		})
+	}
+
+	return pageInfo, nil
+}
+
+// restoreToken makes a previous version of the token of a provider current again, e.g. after a broken refresh
+func (s *service) restoreToken(c context.Context, providerName string, version int) error {
+	s.logger.Log(c, "", mylog.SeverityInfo, "Restore version %d of %s token", version, providerName)
+
+	_, err := s.providers.Get(providerName)
+	if err != nil {
+		return myerrors.NewProblem(ProblemUnknownProvider, err)
+	}
+
+	tokenUID := CreateTokenUID(providerName)
+	_, exists, err := s.tokenVault.GetVersion(c, tokenUID, version)
+	if err != nil {
+		return myerrors.NewInternalError(fmt.Errorf("error fetching version %d of token %s: %w", version, tokenUID, err))
+	}
+	if !exists {
+		return myerrors.NewProblemf(ProblemTokenVersionNotFound, "version %d of token %s not found", version, tokenUID)
+	}
+
+	err = s.tokenVault.Restore(c, tokenUID, version)
+	if err != nil {
+		return myerrors.NewInternalError(fmt.Errorf("error restoring version %d of token %s: %w", version, tokenUID, err))
+	}
+
+	return nil
+}
+
 func tokenToStatus(token oauthvault.Token, exists bool) OAuthStatus {
 	return OAuthStatus{
 		ProviderName: token.ProviderName,
@@ -92,7 +174,7 @@ func (s *service) start(c context.Context, providerName string, clientID string,
 
 	provider, err := s.providers.Get(providerName)
 	if err != nil {
-		return "", myerrors.NewInvalidInputError(fmt.Errorf("provider with name '%s' not known", providerName))
+		return "", myerrors.NewProblemf(ProblemUnknownProvider, "provider with name '%s' not known", providerName)
 	}
 	if requestedScopes == "" {
 		requestedScopes = provider.DefaultScopes
@@ -106,7 +188,7 @@ func (s *service) start(c context.Context, providerName string, clientID string,
 		State:         sessionUID,
 	})
 	if err != nil {
-		return "", myerrors.NewInternalError(fmt.Errorf("error composing auth url: %s", err))
+		return "", myerrors.NewInternalError(fmt.Errorf("error composing auth url: %w", err))
 	}
 
 	err = s.sessionStore.RunInTransaction(c, func(c context.Context) error {
@@ -114,7 +196,7 @@ func (s *service) start(c context.Context, providerName string, clientID string,
 
 		err = s.partyVault.Put(c, providerName, provider)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error storing party details: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error storing party details: %w", err))
 		}
 
 		// Create new session
@@ -129,7 +211,7 @@ func (s *service) start(c context.Context, providerName string, clientID string,
 			LastModified: &now,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error storing: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error storing: %w", err))
 		}
 
 		err = s.publisher.Publish(c, oauthevents.TopicName, oauthevents.OAuthSessionSetupStarted{
@@ -139,7 +221,7 @@ func (s *service) start(c context.Context, providerName string, clientID string,
 			Scopes:       requestedScopes,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
@@ -166,10 +248,10 @@ func (s *service) done(c context.Context, sessionUID string, code string, curren
 
 		session, exist, err := s.sessionStore.Get(c, sessionUID)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error fetching session: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error fetching session: %w", err))
 		}
 		if !exist {
-			return myerrors.NewNotFoundError(fmt.Errorf("session with uid %s not found", sessionUID))
+			return myerrors.NewProblemf(ProblemSessionNotFound, "session with uid %s not found", sessionUID)
 		}
 		returnURL = session.ReturnURL
 
@@ -181,7 +263,7 @@ func (s *service) done(c context.Context, sessionUID string, code string, curren
 			CodeVerifier: session.Verifier,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error getting token: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error getting token: %w", err))
 		}
 
 		s.logger.Log(c, sessionUID, mylog.SeverityDebug, "token-resp: %+v", tokenResp)
@@ -192,7 +274,7 @@ func (s *service) done(c context.Context, sessionUID string, code string, curren
 		session.Done = true
 		err = s.sessionStore.Put(c, sessionUID, session)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error storing session: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error storing session: %w", err))
 		}
 
 		// Store new token in vault
@@ -208,7 +290,7 @@ func (s *service) done(c context.Context, sessionUID string, code string, curren
 			ExpiresIn:    calculateExpriesIn(session.CreatedAt, tokenResp.ExpiresIn),
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error storing token in vault: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error storing token in vault: %w", err))
 		}
 
 		err = s.publisher.Publish(c, oauthevents.TopicName, oauthevents.OAuthSessionSetupCompleted{
@@ -217,7 +299,7 @@ func (s *service) done(c context.Context, sessionUID string, code string, curren
 			SessionUID:   sessionUID,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
@@ -246,7 +328,7 @@ func (s *service) refreshToken(c context.Context, providerName string) (oauthvau
 		tokenUID := CreateTokenUID(providerName)
 		currentToken, exists, err := s.tokenVault.Get(c, tokenUID)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error fetching token %s:%s", tokenUID, err))
+			return myerrors.NewInternalError(fmt.Errorf("error fetching token %s:%w", tokenUID, err))
 		}
 
 		if !exists || currentToken.RefreshToken == "" {
@@ -260,7 +342,7 @@ func (s *service) refreshToken(c context.Context, providerName string) (oauthvau
 			RefreshToken: currentToken.RefreshToken,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error refreshing token: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error refreshing token: %w", err))
 		}
 
 		s.logger.Log(c, "", mylog.SeverityDebug, "refresh-token-resp: %+v", newTokenResp)
@@ -279,7 +361,7 @@ func (s *service) refreshToken(c context.Context, providerName string) (oauthvau
 		// Update token
 		err = s.tokenVault.Put(c, CreateTokenUID(currentToken.ProviderName), newToken)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error storing token: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error storing token: %w", err))
 		}
 
 		err = s.publisher.Publish(c, oauthevents.TopicName, oauthevents.OAuthTokenRefreshCompleted{
@@ -289,7 +371,7 @@ func (s *service) refreshToken(c context.Context, providerName string) (oauthvau
 			SessionUID:   currentToken.SessionUID,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
@@ -321,7 +403,7 @@ func (s *service) cancelToken(c context.Context, providerName string) error {
 		tokenUID := CreateTokenUID(providerName)
 		currentToken, exists, err := s.tokenVault.Get(c, tokenUID)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error fetching token %s:%s", tokenUID, err))
+			return myerrors.NewInternalError(fmt.Errorf("error fetching token %s:%w", tokenUID, err))
 		}
 
 		if !exists || currentToken.RefreshToken == "" {
@@ -335,7 +417,7 @@ func (s *service) cancelToken(c context.Context, providerName string) error {
 			AccessToken:  currentToken.AccessToken,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error canceling token: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error canceling token: %w", err))
 		}
 
 		newToken = oauthvault.Token{
@@ -352,7 +434,7 @@ func (s *service) cancelToken(c context.Context, providerName string) error {
 		// Update token
 		err = s.tokenVault.Put(c, CreateTokenUID(currentToken.ProviderName), newToken)
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error storing token: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error storing token: %w", err))
 		}
 
 		err = s.publisher.Publish(c, oauthevents.TopicName, oauthevents.OAuthTokenCancelCompleted{
@@ -361,7 +443,7 @@ func (s *service) cancelToken(c context.Context, providerName string) error {
 			SessionUID:   currentToken.SessionUID,
 		})
 		if err != nil {
-			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %s", err))
+			return myerrors.NewInternalError(fmt.Errorf("error publishing event: %w", err))
 		}
 
 		return nil
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/MarcGrol/shopbackend/lib/myvault"
+	"github.com/MarcGrol/shopbackend/services/oauth/oauthvault"
+	"github.com/MarcGrol/shopbackend/services/oauth/providers"
+)
+
+// Administers the vaults of the oauth service. Uses the same environment as the app itself:
+//
+//	go run ./services/oauth/vaultadmin generate-key   # prints a new key for VAULT_KEYS or a keyfile
+//	go run ./services/oauth/vaultadmin reencrypt      # encrypts plaintext records and rewraps with the current key
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatalf("Usage: %s generate-key|reencrypt", os.Args[0])
+	}
+
+	switch os.Args[1] {
+	case "generate-key":
+		key, err := myvault.GenerateEncodedKey()
+		if err != nil {
+			log.Fatalf("Error generating key: %s", err)
+		}
+		fmt.Println(key)
+	case "reencrypt":
+		c := context.Background()
+		reEncrypt[oauthvault.Token](c, "tokens")
+		reEncrypt[providers.OauthParty](c, "oauth-parties")
+	default:
+		log.Fatalf("Unknown command %s: use generate-key or reencrypt", os.Args[1])
+	}
+}
+
+func reEncrypt[T any](c context.Context, name string) {
+	reEncrypter, cleanup, err := myvault.NewReEncrypter[T](c)
+	if err != nil {
+		log.Fatalf("Error creating %s vault: %s", name, err)
+	}
+	defer cleanup()
+
+	report, err := reEncrypter.ReEncrypt(c)
+	if err != nil {
+		log.Fatalf("Error re-encrypting %s vault: %s", name, err)
+	}
+
+	log.Printf("Re-encrypted %s vault: %d migrated, %d rewrapped, %d unchanged", name, report.Migrated, report.Rewrapped, report.Unchanged)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 
@@ -28,15 +29,18 @@ type webService struct {
 	logger  mylog.Logger
 }
 
-func NewService(partyVault myvault.VaultReadWriter[providers.OauthParty], sessionStore mystore.Store[OAuthSessionSetup], vault myvault.VaultReadWriter[oauthvault.Token], nower mytime.Nower, uuider myuuid.UUIDer, oauthClient oauthclient.OauthClient, pub mypublisher.Publisher, providers providers.OAuthProvider) *webService {
+func NewService(partyVault myvault.VaultReadWriter[providers.OauthParty], sessionStore mystore.Store[OAuthSessionSetup], vault myvault.VaultReadWriter[oauthvault.Token], nower mytime.Nower, uuider myuuid.UUIDer, oauthClient oauthclient.OauthClient, pub mypublisher.Publisher, providers providers.OAuthProvider, auditTrail myvault.AuditTrail) *webService {
 	return &webService{
-		service: newService(partyVault, sessionStore, vault, nower, uuider, oauthClient, pub, providers),
+		service: newService(partyVault, sessionStore, vault, nower, uuider, oauthClient, pub, providers, auditTrail),
 		logger:  mylog.New("oauth"),
 	}
 }
 
 func (s *webService) RegisterEndpoints(c context.Context, router *mux.Router) error {
 	router.HandleFunc("/oauth/admin", s.adminPage()).Methods("GET")
+	router.HandleFunc("/oauth/admin/{providerName}/audit", s.auditTrailPage()).Methods("GET")
+	router.HandleFunc("/oauth/admin/{providerName}/versions", s.tokenVersionsPage()).Methods("GET")
+	router.HandleFunc("/oauth/admin/{providerName}/versions/{version}/restore", s.restoreTokenPage()).Methods("POST")
 
 	router.HandleFunc("/oauth/start/{providerName}", s.startPage()).Methods("POST")
 	router.HandleFunc("/oauth/done", s.donePage()).Methods("GET")
@@ -55,11 +59,15 @@ func (s *webService) RegisterEndpoints(c context.Context, router *mux.Router) er
 //go:embed templates
 var templateFolder embed.FS
 var (
-	adminPageTemplate *template.Template
+	adminPageTemplate         *template.Template
+	auditTrailPageTemplate    *template.Template
+	tokenVersionsPageTemplate *template.Template
 )
 
 func init() {
 	adminPageTemplate = template.Must(template.ParseFS(templateFolder, "templates/admin.html"))
+	auditTrailPageTemplate = template.Must(template.ParseFS(templateFolder, "templates/audit.html"))
+	tokenVersionsPageTemplate = template.Must(template.ParseFS(templateFolder, "templates/versions.html"))
 }
 
 func (s *webService) adminPage() http.HandlerFunc {
@@ -69,19 +77,82 @@ func (s *webService) adminPage() http.HandlerFunc {
 
 		oauthStatuses, err := s.service.getOauthStatus(c)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		err = adminPageTemplate.Execute(w, oauthStatuses)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInternalError(err))
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
 			return
 		}
 	}
 }
 
+func (s *webService) auditTrailPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(s.logger)
+
+		pageInfo, err := s.service.getAuditTrail(c, mux.Vars(r)["providerName"])
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = auditTrailPageTemplate.Execute(w, pageInfo)
+		if err != nil {
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
+			return
+		}
+	}
+}
+
+func (s *webService) tokenVersionsPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(s.logger)
+
+		pageInfo, err := s.service.getTokenVersions(c, mux.Vars(r)["providerName"])
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = tokenVersionsPageTemplate.Execute(w, pageInfo)
+		if err != nil {
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
+			return
+		}
+	}
+}
+
+func (s *webService) restoreTokenPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := mycontext.ContextFromHTTPRequest(r)
+		responseWriter := myhttp.NewWriter(s.logger)
+
+		providerName := mux.Vars(r)["providerName"]
+
+		version, err := strconv.Atoi(mux.Vars(r)["version"])
+		if err != nil {
+			responseWriter.WriteError(c, w, myerrors.NewValidationError(myerrors.FieldError{Field: "version", Message: "must be a number"}))
+			return
+		}
+
+		err = s.service.restoreToken(c, providerName, version)
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		http.Redirect(w, r, "/oauth/admin", http.StatusSeeOther)
+	}
+}
+
 func (s *webService) startPage() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		c := mycontext.ContextFromHTTPRequest(r)
@@ -89,13 +160,13 @@ func (s *webService) startPage() http.HandlerFunc {
 
 		providerName := mux.Vars(r)["providerName"]
 		if providerName == "" {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("missing providerName")))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("missing providerName")))
 			return
 		}
 
 		err := r.ParseForm()
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(err))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(err))
 			return
 		}
 
@@ -103,26 +174,26 @@ func (s *webService) startPage() http.HandlerFunc {
 
 		originalReturnURL := r.FormValue("returnURL")
 		if originalReturnURL == "" {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("missing returnURL")))
+			responseWriter.WriteError(c, w, myerrors.NewValidationError(myerrors.FieldError{Field: "returnURL", Message: "is mandatory"}))
 			return
 		}
 
 		clientID := r.FormValue("clientID")
 		if clientID == "" {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("missing clientID")))
+			responseWriter.WriteError(c, w, myerrors.NewValidationError(myerrors.FieldError{Field: "clientID", Message: "is mandatory"}))
 			return
 		}
 
 		clientSecret := r.FormValue("clientSecret")
 		if clientSecret == "" {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("missing clientSecret")))
+			responseWriter.WriteError(c, w, myerrors.NewValidationError(myerrors.FieldError{Field: "clientSecret", Message: "is mandatory"}))
 			return
 		}
 
 		authenticationURL, err := s.service.start(c, providerName, clientID, clientSecret,
 			requestedScopes, originalReturnURL, myhttp.HostnameWithScheme(r))
 		if err != nil {
-			responseWriter.WriteError(c, w, 2, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -138,25 +209,25 @@ func (s *webService) donePage() http.HandlerFunc {
 		error := r.URL.Query().Get("error")
 		if error != "" {
 			errorDescription := r.URL.Query().Get("error_description")
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("%s (%s)", error, errorDescription)))
+			responseWriter.WriteError(c, w, myerrors.NewProblemf(ProblemAuthorizationDenied, "%s (%s)", error, errorDescription))
 			return
 		}
 
 		sessionUID := r.URL.Query().Get("state")
 		if sessionUID == "" {
-			responseWriter.WriteError(c, w, 2, myerrors.NewInvalidInputError(fmt.Errorf("missing state")))
+			responseWriter.WriteError(c, w, myerrors.NewValidationError(myerrors.FieldError{Field: "state", Message: "is mandatory"}))
 			return
 		}
 
 		code := r.URL.Query().Get("code")
 		if code == "" {
-			responseWriter.WriteError(c, w, 3, myerrors.NewInvalidInputError(fmt.Errorf("missing code")))
+			responseWriter.WriteError(c, w, myerrors.NewValidationError(myerrors.FieldError{Field: "code", Message: "is mandatory"}))
 			return
 		}
 
 		originalRedirectURL, err := s.service.done(c, sessionUID, code, myhttp.HostnameWithScheme(r))
 		if err != nil {
-			responseWriter.WriteError(c, w, 4, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -171,13 +242,13 @@ func (s *webService) refreshTokenPage() http.HandlerFunc {
 
 		providerName := mux.Vars(r)["providerName"]
 		if providerName == "" {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("missing providerName")))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("missing providerName")))
 			return
 		}
 
 		_, err := s.service.refreshToken(c, providerName)
 		if err != nil {
-			responseWriter.WriteError(c, w, 4, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -192,13 +263,13 @@ func (s *webService) cancelTokenPage() http.HandlerFunc {
 
 		providerName := mux.Vars(r)["providerName"]
 		if providerName == "" {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInvalidInputError(fmt.Errorf("missing providerName")))
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(fmt.Errorf("missing providerName")))
 			return
 		}
 
 		err := s.service.cancelToken(c, providerName)
 		if err != nil {
-			responseWriter.WriteError(c, w, 4, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -34,7 +34,7 @@ func TestOauth(t *testing.T) {
 		defer ctrl.Finish()
 
 		// setup
-		_, router, _, _, tokenVault, _, _, _, _ := setup(t, ctrl)
+		_, router, _, _, tokenVault, _, _, _, _, _ := setup(t, ctrl)
 
 		tokenVault.EXPECT().Get(gomock.Any(), CreateTokenUID("adyen")).Return(oauthvault.Token{
 			ProviderName: "adyen",
@@ -98,7 +98,7 @@ func TestOauth(t *testing.T) {
 		defer ctrl.Finish()
 
 		// setup
-		_, router, partyVault, sessionStorer, _, nower, uuider, oauthClient, publisher := setup(t, ctrl)
+		_, router, partyVault, sessionStorer, _, nower, uuider, oauthClient, publisher, _ := setup(t, ctrl)
 
 		// given
 		nower.EXPECT().Now().Return(mytime.ExampleTime)
@@ -148,7 +148,7 @@ func TestOauth(t *testing.T) {
 		defer ctrl.Finish()
 
 		// setup
-		_, router, _, sessionStorer, tokenVault, nower, _, oauthClient, publisher := setup(t, ctrl)
+		_, router, _, sessionStorer, tokenVault, nower, _, oauthClient, publisher, _ := setup(t, ctrl)
 
 		exampleResp := oauthclient.GetTokenResponse{
 			TokenType:    "bearer",
@@ -224,7 +224,7 @@ func TestOauth(t *testing.T) {
 		defer ctrl.Finish()
 
 		// setup
-		_, router, _, sessionStorer, vault, nower, uuider, oauthClient, publisher := setup(t, ctrl)
+		_, router, _, sessionStorer, vault, nower, uuider, oauthClient, publisher, _ := setup(t, ctrl)
 
 		// given
 		sessionStorer.EXPECT().RunInTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
@@ -290,7 +290,7 @@ func TestOauth(t *testing.T) {
 		defer ctrl.Finish()
 
 		// setup
-		_, router, _, sessionStorer, vault, nower, _, oauthClient, publisher := setup(t, ctrl)
+		_, router, _, sessionStorer, vault, nower, _, oauthClient, publisher, _ := setup(t, ctrl)
 
 		// given
 		sessionStorer.EXPECT().RunInTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
@@ -341,9 +341,125 @@ func TestOauth(t *testing.T) {
 		assert.Equal(t, 303, response.Code)
 		assert.Equal(t, "/oauth/admin", response.Header().Get("Location"))
 	})
+
+	t.Run("Get audit trail page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, _, _, _, _, _, _, _, auditTrail := setup(t, ctrl)
+
+		// given
+		auditTrail.EXPECT().ListByVaultUID(gomock.Any(), CreateTokenUID("adyen")).Return([]myvault.AuditRecord{
+			{UID: "1", Vault: "Token", VaultUID: CreateTokenUID("adyen"), Operation: myvault.AuditOperationGet, Component: "checkoutadyen", Found: true, TraceID: "trace-123", Timestamp: mytime.ExampleTime},
+		}, nil)
+		auditTrail.EXPECT().ListByVaultUID(gomock.Any(), "adyen").Return([]myvault.AuditRecord{
+			{UID: "2", Vault: "OauthParty", VaultUID: "adyen", Operation: myvault.AuditOperationPut, Component: "oauth", Found: true, TraceID: "trace-456", Timestamp: mytime.ExampleTime.Add(time.Minute)},
+		}, nil)
+
+		// when
+		request, err := http.NewRequest(http.MethodGet, "/oauth/admin/adyen/audit", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 200, response.Code)
+		body := response.Body.String()
+		assert.Contains(t, body, "checkoutadyen")
+		assert.Contains(t, body, "trace-123")
+		assert.Less(t, strings.Index(body, "trace-456"), strings.Index(body, "trace-123"))
+	})
+
+	t.Run("Get audit trail of unknown provider", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, _, _, _, _, _, _, _, _ := setup(t, ctrl)
+
+		// when
+		request, err := http.NewRequest(http.MethodGet, "/oauth/admin/unknown/audit", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 404, response.Code)
+	})
+
+	t.Run("Get token versions page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, _, _, tokenVault, _, _, _, _, _ := setup(t, ctrl)
+
+		// given
+		tokenVault.EXPECT().ListVersions(gomock.Any(), CreateTokenUID("adyen")).Return([]myvault.VaultVersion[oauthvault.Token]{
+			{Version: 2, CreatedAt: mytime.ExampleTime.Add(time.Hour), Value: oauthvault.Token{ProviderName: "adyen", SessionUID: "session-2", AccessToken: "secret-access-token-2"}},
+			{Version: 1, CreatedAt: mytime.ExampleTime, Value: oauthvault.Token{ProviderName: "adyen", SessionUID: "session-1", AccessToken: "secret-access-token-1"}},
+		}, nil)
+
+		// when
+		request, err := http.NewRequest(http.MethodGet, "/oauth/admin/adyen/versions", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 200, response.Code)
+		body := response.Body.String()
+		assert.Contains(t, body, "session-2")
+		assert.Contains(t, body, "/oauth/admin/adyen/versions/1/restore")
+		assert.NotContains(t, body, "/oauth/admin/adyen/versions/2/restore")
+		assert.NotContains(t, body, "secret-access-token")
+	})
+
+	t.Run("Restore token version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, _, _, tokenVault, _, _, _, _, _ := setup(t, ctrl)
+
+		// given
+		tokenVault.EXPECT().GetVersion(gomock.Any(), CreateTokenUID("adyen"), 1).Return(myvault.VaultVersion[oauthvault.Token]{Version: 1}, true, nil)
+		tokenVault.EXPECT().Restore(gomock.Any(), CreateTokenUID("adyen"), 1).Return(nil)
+
+		// when
+		request, err := http.NewRequest(http.MethodPost, "/oauth/admin/adyen/versions/1/restore", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 303, response.Code)
+		assert.Equal(t, "/oauth/admin", response.Header().Get("Location"))
+	})
+
+	t.Run("Restore unknown token version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, _, _, tokenVault, _, _, _, _, _ := setup(t, ctrl)
+
+		// given
+		tokenVault.EXPECT().GetVersion(gomock.Any(), CreateTokenUID("adyen"), 7).Return(myvault.VaultVersion[oauthvault.Token]{}, false, nil)
+
+		// when
+		request, err := http.NewRequest(http.MethodPost, "/oauth/admin/adyen/versions/7/restore", nil)
+		assert.NoError(t, err)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		// then
+		assert.Equal(t, 404, response.Code)
+	})
 }
 
-func setup(t *testing.T, ctrl *gomock.Controller) (context.Context, *mux.Router, *myvault.MockVaultReadWriter[providers.OauthParty], *mystore.MockStore[OAuthSessionSetup], *myvault.MockVaultReadWriter[oauthvault.Token], *mytime.MockNower, *myuuid.MockUUIDer, *oauthclient.MockOauthClient, *mypublisher.MockPublisher) {
+func setup(t *testing.T, ctrl *gomock.Controller) (context.Context, *mux.Router, *myvault.MockVaultReadWriter[providers.OauthParty], *mystore.MockStore[OAuthSessionSetup], *myvault.MockVaultReadWriter[oauthvault.Token], *mytime.MockNower, *myuuid.MockUUIDer, *oauthclient.MockOauthClient, *mypublisher.MockPublisher, *myvault.MockAuditTrail) {
 	ctx := context.TODO()
 	router := mux.NewRouter()
 	partyVault := myvault.NewMockVaultReadWriter[providers.OauthParty](ctrl)
@@ -353,12 +469,13 @@ func setup(t *testing.T, ctrl *gomock.Controller) (context.Context, *mux.Router,
 	uuider := myuuid.NewMockUUIDer(ctrl)
 	oauthClient := oauthclient.NewMockOauthClient(ctrl)
 	publisher := mypublisher.NewMockPublisher(ctrl)
-	sut := NewService(partyVault, sessionStore, tokenVault, nower, uuider, oauthClient, publisher, providers.NewProviders())
+	auditTrail := myvault.NewMockAuditTrail(ctrl)
+	sut := NewService(partyVault, sessionStore, tokenVault, nower, uuider, oauthClient, publisher, providers.NewProviders(), auditTrail)
 
 	publisher.EXPECT().CreateTopic(gomock.Any(), oauthevents.TopicName).Return(nil)
 
 	err := sut.RegisterEndpoints(ctx, router)
 	assert.NoError(t, err)
 
-	return ctx, router, partyVault, sessionStore, tokenVault, nower, uuider, oauthClient, publisher
+	return ctx, router, partyVault, sessionStore, tokenVault, nower, uuider, oauthClient, publisher, auditTrail
 }
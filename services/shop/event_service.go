@@ -7,6 +7,7 @@ import (
 	"github.com/MarcGrol/shopbackend/lib/myhttp"
 
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myevents"
 	"github.com/MarcGrol/shopbackend/lib/mylog"
 	"github.com/MarcGrol/shopbackend/services/checkoutevents"
 	"github.com/MarcGrol/shopbackend/services/shop/shopevents"
@@ -15,12 +16,12 @@ import (
 func (s *service) Subscribe(c context.Context) error {
 	err := s.subscriber.CreateTopic(c, checkoutevents.TopicName)
 	if err != nil {
-		return fmt.Errorf("error creating topic %s: %s", checkoutevents.TopicName, err)
+		return fmt.Errorf("error creating topic %s: %w", checkoutevents.TopicName, err)
 	}
 
 	err = s.subscriber.Subscribe(c, checkoutevents.TopicName, myhttp.GuessHostnameWithScheme()+"/api/basket/event")
 	if err != nil {
-		return fmt.Errorf("error subscribing to topic %s: %s", checkoutevents.TopicName, err)
+		return fmt.Errorf("error subscribing to topic %s: %w", checkoutevents.TopicName, err)
 	}
 
 	return nil
@@ -36,10 +37,11 @@ func (s *service) OnCheckoutStarted(c context.Context, topic string, event check
 			return myerrors.NewInternalError(err)
 		}
 		if !found {
-			return myerrors.NewNotFoundError(fmt.Errorf("basket with uid %s not found", event.CheckoutUID))
+			return myerrors.NewProblemf(ProblemBasketNotFound, "basket with uid %s not found", event.CheckoutUID)
 		}
 
-		if basket.Done {
+		// a replay recomputes the projection, even when the basket is done
+		if basket.Done && !myevents.IsReplay(c) {
 			return nil
 		}
 
@@ -71,10 +73,11 @@ func (s *service) OnCheckoutCompleted(c context.Context, topic string, event che
 			return myerrors.NewInternalError(err)
 		}
 		if !found {
-			return myerrors.NewNotFoundError(fmt.Errorf("basket with uid %s not found", event.CheckoutUID))
+			return myerrors.NewProblemf(ProblemBasketNotFound, "basket with uid %s not found", event.CheckoutUID)
 		}
 
-		if basket.Done {
+		// a replay recomputes the projection, even when the basket is done
+		if basket.Done && !myevents.IsReplay(c) {
 			return nil
 		}
 
@@ -90,6 +93,11 @@ func (s *service) OnCheckoutCompleted(c context.Context, topic string, event che
 			return myerrors.NewInternalError(err)
 		}
 
+		if myevents.IsReplay(c) {
+			// other subscribers have already been informed
+			return nil
+		}
+
 		err = s.publisher.Publish(c, shopevents.TopicName, shopevents.BasketPaymentCompleted{
 			BasketUID: event.CheckoutUID},
 		)
@@ -5,6 +5,8 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/MarcGrol/shopbackend/lib/myeventstore"
 )
 
 type Shop struct {
@@ -110,4 +112,5 @@ func (p SelectedProduct) TotalPrice() int {
 type BasketDetailPageInfo struct {
 	Basket     Basket
 	FormValues url.Values
+	Events     []myeventstore.StoredEvent
 }
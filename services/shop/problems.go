@@ -0,0 +1,13 @@
+package shop
+
+import (
+	"net/http"
+
+	"github.com/MarcGrol/shopbackend/lib/myerrors"
+)
+
+// Problem types of the shop service, as listed on /api/problems
+var (
+	// ProblemBasketNotFound is returned when a basket does not exist
+	ProblemBasketNotFound = myerrors.RegisterProblemType("shop", myerrors.ProblemType{Type: "/problems/shop/basket-not-found", Title: "Basket not found", Status: http.StatusNotFound})
+)
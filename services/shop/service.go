@@ -6,6 +6,7 @@ import (
 	"sort"
 
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myeventstore"
 	"github.com/MarcGrol/shopbackend/lib/mylog"
 	"github.com/MarcGrol/shopbackend/lib/mypublisher"
 	"github.com/MarcGrol/shopbackend/lib/mypubsub"
@@ -18,6 +19,7 @@ import (
 
 type service struct {
 	basketStore mystore.Store[Basket]
+	eventStore  myeventstore.EventStore
 	subscriber  mypubsub.PubSub
 	publisher   mypublisher.Publisher
 	nower       mytime.Nower
@@ -26,9 +28,10 @@ type service struct {
 }
 
 // Use dependency injection to isolate the infrastructure and easy testing
-func newService(store mystore.Store[Basket], nower mytime.Nower, uuider myuuid.UUIDer, logger mylog.Logger, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) *service {
+func newService(store mystore.Store[Basket], eventStore myeventstore.EventStore, nower mytime.Nower, uuider myuuid.UUIDer, logger mylog.Logger, subscriber mypubsub.PubSub, publisher mypublisher.Publisher) *service {
 	return &service{
 		basketStore: store,
+		eventStore:  eventStore,
 		subscriber:  subscriber,
 		publisher:   publisher,
 		nower:       nower,
@@ -40,7 +43,7 @@ func newService(store mystore.Store[Basket], nower mytime.Nower, uuider myuuid.U
 func (s *service) CreateTopics(c context.Context) error {
 	err := s.publisher.CreateTopic(c, shopevents.TopicName)
 	if err != nil {
-		return fmt.Errorf("error creating topic %s: %s", shopevents.TopicName, err)
+		return fmt.Errorf("error creating topic %s: %w", shopevents.TopicName, err)
 	}
 	return nil
 }
@@ -100,12 +103,21 @@ func (s service) getBasket(c context.Context, basketUID string) (Basket, error)
 	}
 
 	if !found {
-		return Basket{}, myerrors.NewNotFoundError(fmt.Errorf("basket with uid %s not found", basketUID))
+		return Basket{}, myerrors.NewProblemf(ProblemBasketNotFound, "basket with uid %s not found", basketUID)
 	}
 
 	return basket, nil
 }
 
+func (s service) getBasketEvents(c context.Context, basketUID string) ([]myeventstore.StoredEvent, error) {
+	events, err := s.eventStore.ListByAggregate(c, basketUID)
+	if err != nil {
+		return nil, myerrors.NewInternalError(err)
+	}
+
+	return events, nil
+}
+
 func (s *service) checkoutFinalized(c context.Context, basketUID string, status string) (Basket, error) {
 	s.logger.Log(c, basketUID, mylog.SeverityInfo, "Redirect: Checkout finalized for basket %s -> %s", basketUID, status)
 
@@ -123,7 +135,7 @@ func (s *service) checkoutFinalized(c context.Context, basketUID string, status
 			return myerrors.NewInternalError(err)
 		}
 		if !found {
-			return myerrors.NewNotFoundError(fmt.Errorf("basket with uid %s not found", basketUID))
+			return myerrors.NewProblemf(ProblemBasketNotFound, "basket with uid %s not found", basketUID)
 		}
 
 		basket.InitialPaymentStatus = status
@@ -2,11 +2,7 @@ package shopevents
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 
-	"github.com/MarcGrol/shopbackend/lib/myerrors"
 	"github.com/MarcGrol/shopbackend/lib/myevents"
 )
 
@@ -16,40 +12,20 @@ const (
 	basketPaymentCompleted = TopicName + ".payment.completed"
 )
 
+func init() {
+	myevents.RegisterEventType[BasketCreated](TopicName)
+	myevents.RegisterEventType[BasketPaymentCompleted](TopicName)
+}
+
 type BasketEventService interface {
 	Subscribe(c context.Context) error
 	OnBasketCreated(c context.Context, topic string, event BasketCreated) error
 	OnBasketPaymentCompleted(c context.Context, topic string, event BasketPaymentCompleted) error
 }
 
-func DispatchEvent(c context.Context, reader io.Reader, service BasketEventService) error {
-	envelope, err := myevents.ParseEventEnvelope(reader)
-	if err != nil {
-		return myerrors.NewInvalidInputError(err)
-	}
-
-	switch envelope.EventTypeName {
-	case basketCreateName:
-		{
-			event := BasketCreated{}
-			err := json.Unmarshal([]byte(envelope.EventPayload), &event)
-			if err != nil {
-				return myerrors.NewInvalidInputError(err)
-			}
-			return service.OnBasketCreated(c, envelope.Topic, event)
-		}
-	case basketPaymentCompleted:
-		{
-			event := BasketPaymentCompleted{}
-			err := json.Unmarshal([]byte(envelope.EventPayload), &event)
-			if err != nil {
-				return myerrors.NewInvalidInputError(err)
-			}
-			return service.OnBasketPaymentCompleted(c, envelope.Topic, event)
-		}
-	default:
-		return myerrors.NewNotImplementedError(fmt.Errorf("%s", envelope.EventTypeName))
-	}
+func RegisterEventHandlers(d *myevents.Dispatcher, service BasketEventService) {
+	myevents.Register(d, service.OnBasketCreated)
+	myevents.Register(d, service.OnBasketPaymentCompleted)
 }
 
 type BasketCreated struct {
@@ -64,6 +40,10 @@ func (e BasketCreated) GetAggregateName() string {
 	return e.BasketUID
 }
 
+func (e BasketCreated) GetEventVersion() int {
+	return 1
+}
+
 type BasketPaymentCompleted struct {
 	BasketUID string
 }
@@ -75,3 +55,7 @@ func (e BasketPaymentCompleted) GetEventTypeName() string {
 func (e BasketPaymentCompleted) GetAggregateName() string {
 	return e.BasketUID
 }
+
+func (e BasketPaymentCompleted) GetEventVersion() int {
+	return 1
+}
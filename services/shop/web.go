@@ -11,6 +11,8 @@ import (
 
 	"github.com/MarcGrol/shopbackend/lib/mycontext"
 	"github.com/MarcGrol/shopbackend/lib/myerrors"
+	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/myeventstore"
 	"github.com/MarcGrol/shopbackend/lib/myhttp"
 	"github.com/MarcGrol/shopbackend/lib/mylog"
 	"github.com/MarcGrol/shopbackend/lib/mypublisher"
@@ -22,16 +24,25 @@ import (
 )
 
 type webService struct {
-	logger  mylog.Logger
-	service *service
+	logger     mylog.Logger
+	service    *service
+	consumer   *myevents.IdempotentConsumer
+	dispatcher *myevents.Dispatcher
 }
 
 // Use dependency injection to isolate the infrastructure and ease testing
-func NewService(store mystore.Store[Basket], nower mytime.Nower, uuider myuuid.UUIDer, subsriber mypubsub.PubSub, publisher mypublisher.Publisher) *webService {
+func NewService(store mystore.Store[Basket], eventStore myeventstore.EventStore, ledger mystore.Store[myevents.ProcessedEnvelope], nower mytime.Nower, uuider myuuid.UUIDer, subsriber mypubsub.PubSub, publisher mypublisher.Publisher) *webService {
 	logger := mylog.New("basket")
+	s := newService(store, eventStore, nower, uuider, logger, subsriber, publisher)
+
+	dispatcher := myevents.NewDispatcher("shop")
+	checkoutevents.RegisterEventHandlers(dispatcher, s)
+
 	return &webService{
-		logger:  logger,
-		service: newService(store, nower, uuider, logger, subsriber, publisher),
+		logger:     logger,
+		service:    s,
+		consumer:   myevents.NewIdempotentConsumer("shop", ledger, nower),
+		dispatcher: dispatcher,
 	}
 }
 
@@ -55,6 +66,11 @@ func (s webService) RegisterEndpoints(c context.Context, router *mux.Router) err
 	return s.service.Subscribe(c)
 }
 
+// EventDispatcher exposes the consumer of events, so stored events can be replayed into it
+func (s *webService) EventDispatcher() *myevents.Dispatcher {
+	return s.dispatcher
+}
+
 //go:embed templates
 var templateFolder embed.FS
 var (
@@ -74,14 +90,14 @@ func (s *webService) basketListPage() http.HandlerFunc {
 
 		baskets, err := s.service.listBaskets(c)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		err = basketListPageTemplate.Execute(w, baskets)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInternalError(err))
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
 			return
 		}
 	}
@@ -94,7 +110,7 @@ func (s *webService) createNewBasketPage() http.HandlerFunc {
 
 		basket, err := s.service.createNewBasket(c, myhttp.HostnameWithScheme(r))
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInternalError(err))
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
 			return
 		}
 
@@ -112,26 +128,33 @@ func (s *webService) basketDetailsPage() http.HandlerFunc {
 
 		basket, err := s.service.getBasket(c, basketUID)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
 		checkout := convertBasketToCheckout(basket)
 		values, err := checkout.ToFormValues()
 		if err != nil {
-			responseWriter.WriteError(c, w, 2, err)
+			responseWriter.WriteError(c, w, err)
+			return
+		}
+
+		events, err := s.service.getBasketEvents(c, basketUID)
+		if err != nil {
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
 		pageInfo := BasketDetailPageInfo{
 			Basket:     basket,
 			FormValues: values,
+			Events:     events,
 		}
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		err = basketDetailPageTemplate.Execute(w, pageInfo)
 		if err != nil {
-			responseWriter.WriteError(c, w, 3, myerrors.NewInternalError(err))
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
 			return
 		}
 	}
@@ -144,20 +167,20 @@ func (s *webService) checkoutFinalized() http.HandlerFunc {
 
 		basketUID := mux.Vars(r)["basketUID"]
 		if basketUID == "" {
-			responseWriter.WriteError(c, w, 2, myerrors.NewInvalidInputErrorf("Missing query paramter 'basketUID'"))
+			responseWriter.WriteError(c, w, myerrors.NewValidationError(myerrors.FieldError{Field: "basketUID", Message: "is mandatory"}))
 			return
 		}
 
 		basket, err := s.service.checkoutFinalized(c, basketUID, "completed")
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
 		checkout := convertBasketToCheckout(basket)
 		values, err := checkout.ToFormValues()
 		if err != nil {
-			responseWriter.WriteError(c, w, 2, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -169,7 +192,7 @@ func (s *webService) checkoutFinalized() http.HandlerFunc {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		err = basketDetailPageTemplate.Execute(w, pageInfo)
 		if err != nil {
-			responseWriter.WriteError(c, w, 3, myerrors.NewInternalError(err))
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
 			return
 		}
 	}
@@ -180,9 +203,17 @@ func (s *webService) handleEventEnvelope() http.HandlerFunc {
 		c := mycontext.ContextFromHTTPRequest(r)
 		responseWriter := myhttp.NewWriter(s.logger)
 
-		err := checkoutevents.DispatchEvent(c, r.Body, s.service)
+		envelope, err := myevents.ParseEventEnvelope(r.Body)
+		if err != nil {
+			responseWriter.WriteError(c, w, myerrors.NewInvalidInputError(err))
+			return
+		}
+
+		err = s.consumer.Consume(c, envelope, func(c context.Context) error {
+			return s.dispatcher.Dispatch(c, envelope)
+		})
 		if err != nil {
-			responseWriter.WriteError(c, w, 4, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -10,12 +10,14 @@ import (
 	"time"
 
 	"github.com/MarcGrol/shopbackend/lib/myevents"
+	"github.com/MarcGrol/shopbackend/lib/myeventstore"
 	"github.com/MarcGrol/shopbackend/lib/mypubsub"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"github.com/MarcGrol/shopbackend/lib/mylog"
 	"github.com/MarcGrol/shopbackend/lib/mypublisher"
 	"github.com/MarcGrol/shopbackend/lib/mystore"
 	"github.com/MarcGrol/shopbackend/lib/mytime"
@@ -35,7 +37,7 @@ func TestBasketService(t *testing.T) {
 		defer ctrl.Finish()
 
 		// setup
-		_, router, storer, _, _, _ := setup(t, ctrl)
+		_, router, storer, _, _, _, _ := setup(t, ctrl)
 
 		// given
 		basket2 := Basket{UID: "456", CreatedAt: mytime.ExampleTime.Add(time.Minute), TotalPrice: 200, Currency: "EUR", InitialPaymentStatus: "success", CheckoutStatus: string(checkoutevents.CheckoutStatusSuccess), CheckoutStatusDetails: "AUTHORIZED=true"}
@@ -60,10 +62,25 @@ func TestBasketService(t *testing.T) {
 		defer ctrl.Finish()
 
 		// given
-		_, router, storer, _, _, _ := setup(t, ctrl)
+		_, router, storer, _, _, _, eventStore := setup(t, ctrl)
 
 		// given
 		storer.EXPECT().Get(gomock.Any(), "123").Return(basket1, true, nil)
+		eventStore.EXPECT().ListByAggregate(gomock.Any(), "123").Return([]myeventstore.StoredEvent{
+			{
+				UID:          "abc",
+				AggregateUID: "123",
+				Sequence:     1,
+				Envelope: myevents.EventEnvelope{
+					UID:           "abc",
+					CreatedAt:     mytime.ExampleTime,
+					Topic:         checkoutevents.TopicName,
+					AggregateUID:  "123",
+					EventTypeName: "checkout.completed",
+					EventPayload:  "{}",
+				},
+			},
+		}, nil)
 
 		// when
 		request, err := http.NewRequest(http.MethodGet, "/basket/123", nil)
@@ -76,6 +93,7 @@ func TestBasketService(t *testing.T) {
 		assert.Equal(t, 200, response.Code)
 		got := response.Body.String()
 		assert.Contains(t, got, "<td>123</td>")
+		assert.Contains(t, got, "<td>checkout.completed</td>")
 	})
 
 	t.Run("Get basket not exists", func(t *testing.T) {
@@ -83,7 +101,7 @@ func TestBasketService(t *testing.T) {
 		defer ctrl.Finish()
 
 		// given
-		_, router, storer, _, _, _ := setup(t, ctrl)
+		_, router, storer, _, _, _, _ := setup(t, ctrl)
 		storer.EXPECT().Get(gomock.Any(), "123").Return(Basket{}, false, nil)
 
 		// when
@@ -102,7 +120,7 @@ func TestBasketService(t *testing.T) {
 		defer ctrl.Finish()
 
 		// setup
-		_, router, storer, nower, uuider, publisher := setup(t, ctrl)
+		_, router, storer, nower, uuider, publisher, _ := setup(t, ctrl)
 
 		// given
 		nower.EXPECT().Now().Return(mytime.ExampleTime)
@@ -138,7 +156,7 @@ func TestBasketService(t *testing.T) {
 		defer ctrl.Finish()
 
 		// setup
-		_, router, storer, nower, _, _ := setup(t, ctrl)
+		_, router, storer, nower, _, _, _ := setup(t, ctrl)
 
 		// given
 		nower.EXPECT().Now().Return(mytime.ExampleTime)
@@ -175,10 +193,10 @@ func TestBasketService(t *testing.T) {
 		defer ctrl.Finish()
 
 		// setup
-		_, router, storer, nower, _, publisher := setup(t, ctrl)
+		_, router, storer, nower, _, publisher, _ := setup(t, ctrl)
 
 		// given
-		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		nower.EXPECT().Now().Return(mytime.ExampleTime).Times(2)
 		storer.EXPECT().RunInTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
 			func(ctx context.Context, f func(ctx context.Context) error) error {
 				return f(ctx)
@@ -216,14 +234,87 @@ func TestBasketService(t *testing.T) {
 
 	})
 
+	t.Run("Handle redelivered event only once", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		_, router, storer, nower, _, publisher, _ := setup(t, ctrl)
+
+		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime).Times(2)
+		storer.EXPECT().RunInTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, f func(ctx context.Context) error) error {
+				return f(ctx)
+			})
+		storer.EXPECT().Get(gomock.Any(), "123").Return(basket1, true, nil)
+		storer.EXPECT().Put(gomock.Any(), "123", gomock.Any()).Return(nil)
+		publisher.EXPECT().Publish(gomock.Any(), shopevents.TopicName,
+			shopevents.BasketPaymentCompleted{BasketUID: basket1.UID})
+
+		// when
+		for i := 0; i < 2; i++ {
+			request, err := http.NewRequest(http.MethodPost, "/api/basket/event", strings.NewReader(createCheckoutCompletedPubsubMessage(
+				checkoutevents.CheckoutCompleted{
+					CheckoutUID:           "123",
+					PaymentMethod:         "ideal",
+					CheckoutStatus:        checkoutevents.CheckoutStatusSuccess,
+					CheckoutStatusDetails: "AUTHORIZED=true",
+				})))
+			assert.NoError(t, err)
+			request.Host = "localhost:8888"
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, request)
+
+			// then
+			assert.Equal(t, 200, response.Code)
+		}
+	})
+
+	t.Run("Replay recomputes completed basket without publishing", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// setup
+		c, _, storer, nower, _, _, _ := setup(t, ctrl)
+		sut := newService(storer, nil, nower, nil, mylog.New("basket"), nil, nil)
+
+		// given
+		completedBasket := basket1
+		completedBasket.Done = true
+		completedBasket.PaymentMethod = "corrupted"
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
+		storer.EXPECT().RunInTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, f func(ctx context.Context) error) error {
+				return f(ctx)
+			})
+		storer.EXPECT().Get(gomock.Any(), "123").Return(completedBasket, true, nil)
+		storer.EXPECT().Put(gomock.Any(), "123", gomock.Any()).DoAndReturn(
+			func(ctx context.Context, uid string, basket Basket) error {
+				assert.Equal(t, "ideal", basket.PaymentMethod)
+				return nil
+			})
+
+		// when
+		err := sut.OnCheckoutCompleted(myevents.WithReplay(c), checkoutevents.TopicName, checkoutevents.CheckoutCompleted{
+			CheckoutUID:    "123",
+			PaymentMethod:  "ideal",
+			CheckoutStatus: checkoutevents.CheckoutStatusSuccess,
+		})
+
+		// then
+		assert.NoError(t, err)
+	})
+
 	t.Run("Handle pbl started", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		// setup
-		_, router, _, _, _, _ := setup(t, ctrl)
+		_, router, _, nower, _, _, _ := setup(t, ctrl)
 
 		// given
+		nower.EXPECT().Now().Return(mytime.ExampleTime)
 
 		// when
 
@@ -281,15 +372,17 @@ func createPubsubMessage(name string, eventBytes []byte) string {
 	return string(reqBytes)
 }
 
-func setup(t *testing.T, ctrl *gomock.Controller) (context.Context, *mux.Router, *mystore.MockStore[Basket], *mytime.MockNower, *myuuid.MockUUIDer, *mypublisher.MockPublisher) {
+func setup(t *testing.T, ctrl *gomock.Controller) (context.Context, *mux.Router, *mystore.MockStore[Basket], *mytime.MockNower, *myuuid.MockUUIDer, *mypublisher.MockPublisher, *myeventstore.MockEventStore) {
 	c := context.TODO()
 	storer := mystore.NewMockStore[Basket](ctrl)
+	eventStore := myeventstore.NewMockEventStore(ctrl)
 	nower := mytime.NewMockNower(ctrl)
 	uuider := myuuid.NewMockUUIDer(ctrl)
 	subscriber := mypubsub.NewMockPubSub(ctrl)
 	publisher := mypublisher.NewMockPublisher(ctrl)
+	ledger, _, _ := mystore.New[myevents.ProcessedEnvelope](c)
 
-	sut := NewService(storer, nower, uuider, subscriber, publisher)
+	sut := NewService(storer, eventStore, ledger, nower, uuider, subscriber, publisher)
 	router := mux.NewRouter()
 
 	// These are called by the following call to RegisterEndpoints()
@@ -300,5 +393,5 @@ func setup(t *testing.T, ctrl *gomock.Controller) (context.Context, *mux.Router,
 	err := sut.RegisterEndpoints(c, router)
 	assert.NoError(t, err)
 
-	return c, router, storer, nower, uuider, publisher
+	return c, router, storer, nower, uuider, publisher, eventStore
 }
@@ -1,10 +1,16 @@
 package termsconditions
 
+import "github.com/MarcGrol/shopbackend/lib/myevents"
+
 const (
 	TopicName    = "termsconditions"
 	acceptedName = TopicName + ".accepted"
 )
 
+func init() {
+	myevents.RegisterEventType[TermsConditionsAccepted](TopicName)
+}
+
 type TermsConditionsAccepted struct {
 	EmailAddress string
 	Version      string
@@ -17,3 +23,7 @@ func (e TermsConditionsAccepted) GetEventTypeName() string {
 func (e TermsConditionsAccepted) GetAggregateName() string {
 	return e.EmailAddress
 }
+
+func (e TermsConditionsAccepted) GetEventVersion() int {
+	return 1
+}
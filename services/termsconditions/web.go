@@ -41,7 +41,7 @@ func (s *webService) RegisterEndpoints(c context.Context, router *mux.Router) er
 func (s *webService) Subscribe(c context.Context) error {
 	err := s.publisher.CreateTopic(c, TopicName)
 	if err != nil {
-		return fmt.Errorf("error creating topic %s: %s", TopicName, err)
+		return fmt.Errorf("error creating topic %s: %w", TopicName, err)
 	}
 
 	return nil
@@ -66,7 +66,7 @@ func (s *webService) getTermsAndConditions() http.HandlerFunc {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		err := termsConditionsPageTemplate.Execute(w, nil)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, myerrors.NewInternalError(err))
+			responseWriter.WriteError(c, w, myerrors.NewInternalError(err))
 			return
 		}
 	}
@@ -83,7 +83,7 @@ func (s *webService) acceptTermsAndConditions() http.HandlerFunc {
 			Version:      "0.0.1",
 		})
 		if err != nil {
-			responseWriter.WriteError(c, w, 2, err)
+			responseWriter.WriteError(c, w, err)
 		}
 
 		http.Redirect(w, r, "/", http.StatusSeeOther)
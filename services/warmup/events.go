@@ -1,10 +1,16 @@
 package warmup
 
+import "github.com/MarcGrol/shopbackend/lib/myevents"
+
 const (
 	TopicName        = "warmup"
 	wwrmupKickedName = TopicName + ".kicked"
 )
 
+func init() {
+	myevents.RegisterEventType[WarmupKicked](TopicName)
+}
+
 type WarmupKicked struct {
 	UID string
 }
@@ -16,3 +22,7 @@ func (e WarmupKicked) GetEventTypeName() string {
 func (e WarmupKicked) GetAggregateName() string {
 	return e.UID
 }
+
+func (e WarmupKicked) GetEventVersion() int {
+	return 1
+}
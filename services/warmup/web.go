@@ -45,7 +45,7 @@ func (s webService) RegisterEndpoints(c context.Context, router *mux.Router) err
 func (s *webService) Subscribe(c context.Context) error {
 	err := s.publisher.CreateTopic(c, TopicName)
 	if err != nil {
-		return fmt.Errorf("error creating topic %s: %s", TopicName, err)
+		return fmt.Errorf("error creating topic %s: %w", TopicName, err)
 	}
 
 	return nil
@@ -60,7 +60,7 @@ func (s *webService) warmupPage() http.HandlerFunc {
 
 		_, _, err := s.vault.Get(c, oauthvault.CurrentToken)
 		if err != nil {
-			responseWriter.WriteError(c, w, 1, err)
+			responseWriter.WriteError(c, w, err)
 			return
 		}
 
@@ -68,7 +68,7 @@ func (s *webService) warmupPage() http.HandlerFunc {
 			UID: uid,
 		})
 		if err != nil {
-			responseWriter.WriteError(c, w, 2, err)
+			responseWriter.WriteError(c, w, err)
 		}
 
 		responseWriter.Write(c, w, http.StatusOK, myhttp.SuccessResponse{